@@ -0,0 +1,113 @@
+// Command fluence-fake-api serves an in-memory fake of the Fluence API, for
+// running Terraform configurations against the provider without a Fluence
+// account. Point the provider's host attribute at the address it listens on.
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"log"
+	"net/http"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+
+	"terraform-provider-fluence/internal/fakeapi"
+)
+
+func main() {
+	var (
+		addr        string
+		apiKey      string
+		fixturesDir string
+		capacity    uint64
+		epoch       time.Duration
+		clockMode   string
+		clockStart  string
+		listLag     int
+		transitions string
+	)
+
+	flag.StringVar(&addr, "addr", "127.0.0.1:8080", "address to listen on")
+	flag.StringVar(&apiKey, "api-key", "", "API key clients must send; any key is accepted when empty")
+	flag.StringVar(&fixturesDir, "fixtures", "", "directory with JSON fixtures overriding the built-in ones")
+	flag.Uint64Var(&capacity, "capacity", 0, "instances of each configuration every datacenter can host (default from fixtures)")
+	flag.DurationVar(&epoch, "epoch", 24*time.Hour, "billing epoch duration")
+	flag.StringVar(&clockMode, "clock", "system", "clock used for billing: system, or manual to move it through POST /_fake/clock")
+	flag.StringVar(&clockStart, "clock-start", "", "RFC 3339 start time of the manual clock (default now)")
+	flag.IntVar(&listLag, "list-lag", 0, "number of VM list requests that do not show a new VM")
+	flag.StringVar(&transitions, "transitions", "Launching,Active", "comma-separated statuses new VMs go through")
+	flag.Parse()
+
+	if epoch <= 0 {
+		log.Fatalf("invalid -epoch %s: must be positive", epoch)
+	}
+
+	fixtures := fakeapi.DefaultFixtures()
+	if fixturesDir != "" {
+		var err error
+		fixtures, err = fakeapi.LoadFixtures(fixturesDir)
+		if err != nil {
+			log.Fatalf("loading fixtures: %s", err)
+		}
+	}
+	if capacity > 0 {
+		fixtures.Capacity = capacity
+	}
+
+	opts := []fakeapi.Option{
+		fakeapi.WithFixtures(fixtures),
+		fakeapi.WithEpochDuration(epoch),
+	}
+	if apiKey != "" {
+		opts = append(opts, fakeapi.WithApiKey(apiKey))
+	}
+
+	switch clockMode {
+	case "system":
+	case "manual":
+		start := time.Now()
+		if clockStart != "" {
+			var err error
+			start, err = time.Parse(time.RFC3339, clockStart)
+			if err != nil {
+				log.Fatalf("invalid -clock-start: %s", err)
+			}
+		}
+		opts = append(opts, fakeapi.WithClock(fakeapi.NewManualClock(start)))
+	default:
+		log.Fatalf("invalid -clock %q: must be system or manual", clockMode)
+	}
+
+	api := fakeapi.New(opts...)
+	api.SetListLag(listLag)
+	if transitions != "" {
+		api.SetStatusTransitions(strings.Split(transitions, ",")...)
+	}
+
+	server := &http.Server{
+		Addr:              addr,
+		Handler:           api,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		if err := server.Shutdown(shutdownCtx); err != nil {
+			log.Printf("shutting down: %s", err)
+		}
+	}()
+
+	log.Printf("fake Fluence API listening on http://%s", addr)
+	if err := server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		log.Fatal(err.Error())
+	}
+}
@@ -27,22 +27,34 @@ data "fluence_default_images" "images" {}
 # Estimate deposit for different VM configurations
 data "fluence_vm_estimate_deposit" "small_vm" {
   instances = 1
-  
-  constraints = {
-    basic_configuration           = "cpu-2-ram-4gb-storage-25gb"
-    max_total_price_per_epoch_usd = "3.0"
-    datacenter_countries          = ["US"]
-  }
+
+  # Same constraint attributes as fluence_vm
+  basic_configuration           = "cpu-2-ram-4gb-storage-25gb"
+  max_total_price_per_epoch_usd = "3.0"
+  datacenter_countries          = ["US"]
 }
 
 data "fluence_vm_estimate_deposit" "large_cluster" {
   instances = 3
-  
-  constraints = {
-    basic_configuration           = "cpu-8-ram-16gb-storage-50gb"
-    max_total_price_per_epoch_usd = "50.0"
-    datacenter_countries          = ["US", "DE", "CA"]
-  }
+
+  basic_configuration           = "cpu-8-ram-16gb-storage-50gb"
+  max_total_price_per_epoch_usd = "50.0"
+  datacenter_countries          = ["US", "DE", "CA"]
+}
+
+# List the concrete offers a small VM in the US or Germany could land on
+data "fluence_offers" "small_vm" {
+  basic_configuration  = "cpu-2-ram-4gb-storage-25gb"
+  datacenter_countries = ["US", "DE"]
+}
+
+output "small_vm_offers" {
+  value = [for offer in data.fluence_offers.small_vm.offers : {
+    country             = offer.datacenter.country_code
+    price_per_epoch     = offer.price_per_epoch
+    available_instances = offer.available_instances
+  }]
+  description = "Offers matching the small VM constraints"
 }
 
 # Output all available marketplace information
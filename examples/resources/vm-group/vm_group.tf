@@ -0,0 +1,49 @@
+terraform {
+  required_providers {
+    fluence = {
+      source = "hashicorp.com/decentralized-infrastructure/fluence"
+      version = "~> 1.0"
+    }
+  }
+}
+
+provider "fluence" {
+  # api_key = "your-api-key"  # Or set FLUENCE_API_KEY env var
+}
+
+# Use existing SSH keys
+data "fluence_ssh_keys" "existing" {}
+
+data "fluence_default_images" "available" {}
+
+locals {
+  ubuntu_24_image = [for img in data.fluence_default_images.available.images : img if img.distribution == "Ubuntu" && strcontains(img.name, "24.04")][0]
+}
+
+# Three identical web servers. Changing os_image replaces them one at a time
+resource "fluence_vm_group" "web" {
+  name     = "web"
+  os_image = local.ubuntu_24_image.download_url
+  ssh_keys = [data.fluence_ssh_keys.existing.ssh_keys[0].fingerprint]
+
+  instances       = 3
+  max_unavailable = 1
+
+  open_ports = [
+    {
+      port     = 22
+      protocol = "tcp"
+    },
+    {
+      port     = 443
+      protocol = "tcp"
+    }
+  ]
+
+  basic_configuration = "cpu-2-ram-4gb-storage-25gb"
+}
+
+output "web_public_ips" {
+  description = "Public IP address of each web server, by member index"
+  value       = { for index, member in fluence_vm_group.web.members : index => member.public_ip }
+}
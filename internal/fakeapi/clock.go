@@ -0,0 +1,113 @@
+package fakeapi
+
+import (
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// clockPath is the control route used to read and move the fake's clock.
+const clockPath = "/_fake/clock"
+
+// clockState is the body of clock control requests and responses.
+type clockState struct {
+	Now     string `json:"now,omitempty"`
+	Advance string `json:"advance,omitempty"`
+	Epoch   string `json:"epoch,omitempty"`
+}
+
+// Clock tells the fake what time it is. It drives VM timestamps and epoch
+// billing.
+type Clock interface {
+	Now() time.Time
+}
+
+// systemClock is a Clock that follows the wall clock.
+type systemClock struct{}
+
+func (systemClock) Now() time.Time {
+	return time.Now().UTC()
+}
+
+// ManualClock is a Clock that only moves when told to, so that billing
+// epochs can be crossed instantly.
+type ManualClock struct {
+	mu  sync.Mutex
+	now time.Time
+}
+
+// NewManualClock returns a ManualClock stopped at start.
+func NewManualClock(start time.Time) *ManualClock {
+	return &ManualClock{now: start.UTC()}
+}
+
+// Now implements Clock.
+func (c *ManualClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.now
+}
+
+// Advance moves the clock forward by d.
+func (c *ManualClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.now = c.now.Add(d)
+}
+
+// Set moves the clock to t.
+func (c *ManualClock) Set(t time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.now = t.UTC()
+}
+
+// serveClock reports the current time on GET. On POST, it moves a
+// ManualClock either to "now" (RFC 3339) or forward by "advance" (a Go
+// duration). Must be called with a.mu held.
+func (a *API) serveClock(w http.ResponseWriter, r *http.Request, body []byte) {
+	switch r.Method {
+	case http.MethodGet:
+	case http.MethodPost:
+		clock, ok := a.clock.(*ManualClock)
+		if !ok {
+			writeError(w, http.StatusConflict, "the clock follows the system time and cannot be moved")
+			return
+		}
+
+		var req clockState
+		if !decodeJSON(w, body, &req) {
+			return
+		}
+
+		if req.Now != "" {
+			t, err := time.Parse(time.RFC3339, req.Now)
+			if err != nil {
+				writeError(w, http.StatusBadRequest, fmt.Sprintf("invalid now: %s", err))
+				return
+			}
+			clock.Set(t)
+		}
+		if req.Advance != "" {
+			d, err := time.ParseDuration(req.Advance)
+			if err != nil || d < 0 {
+				writeError(w, http.StatusBadRequest, fmt.Sprintf("invalid advance %q: must be a positive duration", req.Advance))
+				return
+			}
+			clock.Advance(d)
+		}
+	default:
+		writeError(w, http.StatusMethodNotAllowed, fmt.Sprintf("method %s not allowed", r.Method))
+		return
+	}
+
+	a.bill()
+	writeJSON(w, http.StatusOK, clockState{
+		Now:   a.clock.Now().Format(time.RFC3339),
+		Epoch: a.epoch.String(),
+	})
+}
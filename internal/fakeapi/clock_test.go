@@ -0,0 +1,126 @@
+package fakeapi
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	fluenceapi "github.com/decentralized-infrastructure/fluence-api-client-go"
+)
+
+func TestBilling(t *testing.T) {
+	start := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	clock := NewManualClock(start)
+	srv := testServer(t, WithClock(clock), WithEpochDuration(time.Hour))
+
+	createVms(t, srv, "DE", 1, nil)
+
+	checkBilling := func(spent, reserved string, nextBillingAt time.Time) {
+		t.Helper()
+
+		vm := listVms(t, srv)[0]
+		if vm.TotalSpent != spent || vm.ReservedBalance != reserved || vm.NextBillingAt != nextBillingAt.Format(time.RFC3339) {
+			t.Errorf("billing = spent %s, reserved %s, next %s, want %s, %s, %s",
+				vm.TotalSpent, vm.ReservedBalance, vm.NextBillingAt, spent, reserved, nextBillingAt.Format(time.RFC3339))
+		}
+	}
+
+	// The deposit covers two epochs of the 1.5 USD offer
+	checkBilling("0", "3", start.Add(time.Hour))
+
+	clock.Advance(59 * time.Minute)
+	checkBilling("0", "3", start.Add(time.Hour))
+
+	clock.Advance(time.Minute)
+	checkBilling("1.5", "1.5", start.Add(2*time.Hour))
+
+	// Every epoch crossed is billed, and the deposit does not go negative
+	clock.Advance(2 * time.Hour)
+	checkBilling("4.5", "0", start.Add(4*time.Hour))
+}
+
+func TestWithEpochDuration(t *testing.T) {
+	for _, epoch := range []time.Duration{0, -time.Hour} {
+		if a := New(WithEpochDuration(epoch)); a.epoch != defaultEpochDuration {
+			t.Errorf("WithEpochDuration(%s) set the epoch to %s, want the default %s", epoch, a.epoch, defaultEpochDuration)
+		}
+	}
+
+	if a := New(WithEpochDuration(time.Minute)); a.epoch != time.Minute {
+		t.Errorf("WithEpochDuration(1m) set the epoch to %s", a.epoch)
+	}
+}
+
+func TestClockRoute(t *testing.T) {
+	start := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	clock := NewManualClock(start)
+	srv := testServer(t, WithClock(clock))
+	createVms(t, srv, "US", 1, nil)
+
+	var state clockState
+	if status := call(t, srv, http.MethodGet, clockPath, nil, &state); status != http.StatusOK {
+		t.Fatalf("status = %d, want %d", status, http.StatusOK)
+	}
+	if state.Now != "2025-01-01T00:00:00Z" || state.Epoch != "24h0m0s" {
+		t.Errorf("clock = %+v", state)
+	}
+
+	call(t, srv, http.MethodPost, clockPath, clockState{Advance: "48h"}, &state)
+	if state.Now != "2025-01-03T00:00:00Z" {
+		t.Errorf("clock after advancing = %s", state.Now)
+	}
+
+	// Moving the clock bills the VMs right away
+	if vm := srv.Vms()[0]; vm.TotalSpent != "3" {
+		t.Errorf("spent = %s after two epochs, want 3", vm.TotalSpent)
+	}
+
+	call(t, srv, http.MethodPost, clockPath, clockState{Now: "2025-02-01T12:00:00Z"}, &state)
+	if state.Now != "2025-02-01T12:00:00Z" || !clock.Now().Equal(time.Date(2025, 2, 1, 12, 0, 0, 0, time.UTC)) {
+		t.Errorf("clock after setting = %s", state.Now)
+	}
+
+	for _, body := range []clockState{{Now: "tomorrow"}, {Advance: "-1h"}} {
+		if status := call(t, srv, http.MethodPost, clockPath, body, nil); status != http.StatusBadRequest {
+			t.Errorf("POST %+v: status = %d, want %d", body, status, http.StatusBadRequest)
+		}
+	}
+	if status := call(t, srv, http.MethodDelete, clockPath, nil, nil); status != http.StatusMethodNotAllowed {
+		t.Errorf("DELETE: status = %d, want %d", status, http.StatusMethodNotAllowed)
+	}
+}
+
+func TestClockRoute_systemClock(t *testing.T) {
+	srv := testServer(t)
+
+	var state clockState
+	call(t, srv, http.MethodGet, clockPath, nil, &state)
+	now, err := time.Parse(time.RFC3339, state.Now)
+	if err != nil || time.Since(now) > time.Minute {
+		t.Errorf("clock = %s, want the current time", state.Now)
+	}
+
+	if status := call(t, srv, http.MethodPost, clockPath, clockState{Advance: "1h"}, nil); status != http.StatusConflict {
+		t.Errorf("status = %d, want %d", status, http.StatusConflict)
+	}
+}
+
+func TestManualClock_createdAt(t *testing.T) {
+	clock := NewManualClock(time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC))
+	srv := testServer(t, WithClock(clock))
+
+	createVms(t, srv, "DE", 1, nil)
+	clock.Advance(time.Hour)
+	createVms(t, srv, "DE", 1, nil)
+
+	vms := srv.Vms()
+	want := []string{"2025-06-01T00:00:00Z", "2025-06-01T01:00:00Z"}
+	for i, vm := range vms {
+		if vm.CreatedAt != want[i] {
+			t.Errorf("VM %d created at %s, want %s", i, vm.CreatedAt, want[i])
+		}
+	}
+	if len(vms) != 2 || vms[0].Status != fluenceapi.VmStatusNew {
+		t.Errorf("VMs = %+v", vms)
+	}
+}
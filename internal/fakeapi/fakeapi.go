@@ -0,0 +1,350 @@
+// Package fakeapi implements an in-memory fake of the Fluence HTTP API.
+//
+// It serves the endpoints used by the provider (VMs, SSH keys, marketplace
+// listings and deposit estimates) from fixtures and in-memory state. The fake
+// can inject errors, delay the visibility of new VMs and walk VMs through a
+// configurable sequence of statuses, so the provider's polling and retry
+// logic can be exercised without a Fluence account.
+package fakeapi
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"time"
+
+	fluenceapi "github.com/decentralized-infrastructure/fluence-api-client-go"
+)
+
+// API is the fake Fluence API. It implements http.Handler.
+type API struct {
+	mu sync.Mutex
+
+	apiKey   string
+	fixtures *Fixtures
+	clock    Clock
+	epoch    time.Duration
+
+	vms     []*vmRecord
+	sshKeys []fluenceapi.SshKey
+
+	faults      []*fault
+	ipIndex     int
+	transitions []string
+	listLag     int
+	requests    []Request
+}
+
+// Server is an API served by an httptest.Server on a local address.
+type Server struct {
+	*API
+	*httptest.Server
+}
+
+// Request records a request received by the fake.
+type Request struct {
+	Method string
+	Path   string
+	Body   string
+}
+
+// fault describes an injected error response.
+type fault struct {
+	method     string
+	path       string
+	status     int
+	remaining  int
+	retryAfter time.Duration
+}
+
+// Option configures an API.
+type Option func(*API)
+
+// WithApiKey makes the fake reject requests that do not carry the given
+// bearer token.
+func WithApiKey(apiKey string) Option {
+	return func(a *API) {
+		a.apiKey = apiKey
+	}
+}
+
+// WithFixtures replaces the built-in marketplace fixtures.
+func WithFixtures(fixtures *Fixtures) Option {
+	return func(a *API) {
+		a.fixtures = fixtures
+	}
+}
+
+// WithClock makes the fake read the time from clock instead of the wall
+// clock. Use a ManualClock to cross billing epochs on demand.
+func WithClock(clock Clock) Option {
+	return func(a *API) {
+		a.clock = clock
+	}
+}
+
+// WithEpochDuration sets the billing period of VMs. The default is one day,
+// which is also kept when epoch is not positive.
+func WithEpochDuration(epoch time.Duration) Option {
+	return func(a *API) {
+		if epoch > 0 {
+			a.epoch = epoch
+		}
+	}
+}
+
+// New creates a fake API with the built-in fixtures and no VMs or SSH keys.
+func New(opts ...Option) *API {
+	a := &API{
+		fixtures:    DefaultFixtures(),
+		clock:       systemClock{},
+		epoch:       defaultEpochDuration,
+		transitions: []string{fluenceapi.VmStatusLaunching, fluenceapi.VmStatusActive},
+	}
+	for _, opt := range opts {
+		opt(a)
+	}
+	return a
+}
+
+// NewServer starts a fake API on a local address. Callers must Close it.
+func NewServer(opts ...Option) *Server {
+	api := New(opts...)
+	return &Server{
+		API:    api,
+		Server: httptest.NewServer(api),
+	}
+}
+
+// InjectFault makes the next count requests matching method and path fail
+// with status. A positive retryAfter is sent in the Retry-After header.
+func (a *API) InjectFault(method, path string, status, count int, retryAfter time.Duration) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	a.faults = append(a.faults, &fault{
+		method:     method,
+		path:       path,
+		status:     status,
+		remaining:  count,
+		retryAfter: retryAfter,
+	})
+}
+
+// SetStatusTransitions sets the statuses new VMs go through. A VM advances to
+// the next status each time the VM list is requested and stays in the last
+// one. The default is Launching followed by Active.
+func (a *API) SetStatusTransitions(statuses ...string) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	a.transitions = append([]string{}, statuses...)
+}
+
+// SetListLag hides new VMs from the next n VM list requests that follow
+// their creation, to mimic eventual consistency.
+func (a *API) SetListLag(n int) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	a.listLag = n
+}
+
+// SetVmStatus changes the status of a VM, for example to simulate a failure
+// or a termination made outside of Terraform.
+func (a *API) SetVmStatus(id, status string) bool {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	for _, vm := range a.vms {
+		if vm.Id == id {
+			vm.Status = status
+			vm.transitions = nil
+			return true
+		}
+	}
+	return false
+}
+
+// DeleteVm removes a VM immediately, as if it was deleted from the console.
+func (a *API) DeleteVm(id string) bool {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	for i, vm := range a.vms {
+		if vm.Id == id {
+			a.vms = append(a.vms[:i], a.vms[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+// SetVmPorts changes the open ports of a VM, as if the firewall was edited
+// from the console.
+func (a *API) SetVmPorts(id string, ports []fluenceapi.PortSpec) bool {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	for _, vm := range a.vms {
+		if vm.Id == id {
+			portsCopy := append([]fluenceapi.PortSpec{}, ports...)
+			vm.Ports = &portsCopy
+			return true
+		}
+	}
+	return false
+}
+
+// Vms returns a snapshot of every VM, including hidden and terminated ones.
+func (a *API) Vms() []fluenceapi.RunningInstanceV3 {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	a.bill()
+	vms := make([]fluenceapi.RunningInstanceV3, len(a.vms))
+	for i, vm := range a.vms {
+		vms[i] = vm.RunningInstanceV3
+	}
+	return vms
+}
+
+// SshKeys returns a snapshot of every SSH key.
+func (a *API) SshKeys() []fluenceapi.SshKey {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	return append([]fluenceapi.SshKey{}, a.sshKeys...)
+}
+
+// Requests returns the requests received so far.
+func (a *API) Requests() []Request {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	return append([]Request{}, a.requests...)
+}
+
+// ServeHTTP implements http.Handler.
+func (a *API) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "unable to read request body")
+		return
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	// The fake's own control routes bypass authentication and faults
+	if r.URL.Path == clockPath {
+		a.serveClock(w, r, body)
+		return
+	}
+
+	a.requests = append(a.requests, Request{
+		Method: r.Method,
+		Path:   r.URL.Path,
+		Body:   string(body),
+	})
+
+	if a.apiKey != "" && r.Header.Get("Authorization") != "Bearer "+a.apiKey {
+		writeError(w, http.StatusUnauthorized, "invalid API key")
+		return
+	}
+
+	if a.injectFault(w, r) {
+		return
+	}
+
+	a.bill()
+
+	switch r.Method + " " + r.URL.Path {
+	case "GET /ssh_keys":
+		a.listSshKeys(w)
+	case "POST /ssh_keys":
+		a.createSshKey(w, body)
+	case "DELETE /ssh_keys":
+		a.removeSshKey(w, body)
+	case "GET /vms/v3":
+		a.listVms(w)
+	case "POST /vms/v3":
+		a.createVms(w, body)
+	case "PATCH /vms/v3":
+		a.updateVms(w, body)
+	case "DELETE /vms/v3":
+		a.removeVms(w, body)
+	case "GET /vms/v3/status":
+		a.vmStatuses(w, r.URL.Query().Get("ids"))
+	case "POST /vms/v3/estimate":
+		a.estimateDeposit(w, body)
+	case "GET /vms/v3/default_images":
+		writeJSON(w, http.StatusOK, a.fixtures.DefaultImages)
+	case "GET /marketplace/basic_configurations":
+		writeJSON(w, http.StatusOK, a.fixtures.configurationSlugs())
+	case "GET /marketplace/countries":
+		writeJSON(w, http.StatusOK, a.fixtures.countries())
+	case "GET /marketplace/hardware":
+		writeJSON(w, http.StatusOK, a.fixtures.Hardware)
+	case "POST /marketplace/offers":
+		a.listOffers(w, body)
+	case "GET /v1/marketplace/datacenters":
+		writeJSON(w, http.StatusOK, a.fixtures.Datacenters)
+	default:
+		writeError(w, http.StatusNotFound, fmt.Sprintf("no route for %s %s", r.Method, r.URL.Path))
+	}
+}
+
+// injectFault writes the first pending fault that matches r, if any.
+func (a *API) injectFault(w http.ResponseWriter, r *http.Request) bool {
+	for i, f := range a.faults {
+		if f.method != r.Method || f.path != r.URL.Path {
+			continue
+		}
+
+		f.remaining--
+		if f.remaining <= 0 {
+			a.faults = append(a.faults[:i], a.faults[i+1:]...)
+		}
+
+		if f.retryAfter > 0 {
+			w.Header().Set("Retry-After", strconv.Itoa(int(f.retryAfter.Seconds())))
+		}
+		writeError(w, f.status, fmt.Sprintf("injected fault: %s", http.StatusText(f.status)))
+		return true
+	}
+	return false
+}
+
+// decodeJSON decodes a request body, writing a 400 response on failure.
+func decodeJSON(w http.ResponseWriter, body []byte, v interface{}) bool {
+	if err := json.Unmarshal(body, v); err != nil {
+		writeError(w, http.StatusBadRequest, fmt.Sprintf("invalid request body: %s", err))
+		return false
+	}
+	return true
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+func writeError(w http.ResponseWriter, status int, message string) {
+	writeJSON(w, status, fluenceapi.ErrorBody{Error: message})
+}
+
+// newId returns a random identifier formatted like a UUID.
+func newId() string {
+	b := make([]byte, 16)
+	_, _ = rand.Read(b)
+	h := hex.EncodeToString(b)
+	return h[0:8] + "-" + h[8:12] + "-" + h[12:16] + "-" + h[16:20] + "-" + h[20:32]
+}
@@ -0,0 +1,400 @@
+package fakeapi
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"testing"
+	"time"
+
+	fluenceapi "github.com/decentralized-infrastructure/fluence-api-client-go"
+)
+
+const testApiKey = "test-api-key"
+
+// testServer starts a fake that is closed when the test ends.
+func testServer(t *testing.T, opts ...Option) *Server {
+	t.Helper()
+
+	srv := NewServer(append([]Option{WithApiKey(testApiKey)}, opts...)...)
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+// call sends a request with the API key and decodes the JSON response into
+// out, unless out is nil. It returns the response status.
+func call(t *testing.T, srv *Server, method, path string, body, out interface{}) int {
+	t.Helper()
+
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			t.Fatal(err)
+		}
+		reader = bytes.NewReader(data)
+	}
+
+	req, err := http.NewRequest(method, srv.URL+path, reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set("Authorization", "Bearer "+testApiKey)
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := srv.Client().Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+
+	if out != nil && resp.StatusCode < 300 {
+		if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+			t.Fatalf("decoding %s %s: %s", method, path, err)
+		}
+	}
+	return resp.StatusCode
+}
+
+// createVms creates instances VMs of the cheapest offer in country.
+func createVms(t *testing.T, srv *Server, country string, instances int, requestId *string) []fluenceapi.CreatedVm {
+	t.Helper()
+
+	var created []fluenceapi.CreatedVm
+	status := call(t, srv, http.MethodPost, "/vms/v3", fluenceapi.CreateVmV3{
+		Constraints: &fluenceapi.OfferConstraints{
+			Datacenter: &fluenceapi.DatacenterConstraint{Countries: []string{country}},
+		},
+		Instances: instances,
+		RequestId: requestId,
+		VmConfiguration: fluenceapi.VmConfiguration{
+			Name:      "vm",
+			OsImage:   "https://example.com/image.img",
+			OpenPorts: []fluenceapi.OpenPorts{{Port: 22, Protocol: fluenceapi.ProtocolTCP}},
+			SshKeys:   []string{},
+		},
+	}, &created)
+	if status != http.StatusCreated {
+		t.Fatalf("create status = %d, want %d", status, http.StatusCreated)
+	}
+	return created
+}
+
+// listVms lists the VMs the way the provider does.
+func listVms(t *testing.T, srv *Server) []fluenceapi.RunningInstanceV3 {
+	t.Helper()
+
+	var vms []fluenceapi.RunningInstanceV3
+	if status := call(t, srv, http.MethodGet, "/vms/v3", nil, &vms); status != http.StatusOK {
+		t.Fatalf("list status = %d, want %d", status, http.StatusOK)
+	}
+	return vms
+}
+
+func TestApiKey(t *testing.T) {
+	srv := testServer(t)
+
+	resp, err := srv.Client().Get(srv.URL + "/ssh_keys")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusUnauthorized {
+		t.Errorf("status without API key = %d, want %d", resp.StatusCode, http.StatusUnauthorized)
+	}
+
+	if status := call(t, srv, http.MethodGet, "/ssh_keys", nil, nil); status != http.StatusOK {
+		t.Errorf("status with API key = %d, want %d", status, http.StatusOK)
+	}
+}
+
+func TestUnknownRoute(t *testing.T) {
+	srv := testServer(t)
+
+	if status := call(t, srv, http.MethodGet, "/unknown", nil, nil); status != http.StatusNotFound {
+		t.Errorf("status = %d, want %d", status, http.StatusNotFound)
+	}
+}
+
+func TestInjectFault(t *testing.T) {
+	srv := testServer(t)
+	srv.InjectFault(http.MethodGet, "/ssh_keys", http.StatusTooManyRequests, 2, 3*time.Second)
+
+	for i := 0; i < 2; i++ {
+		req, _ := http.NewRequest(http.MethodGet, srv.URL+"/ssh_keys", nil)
+		req.Header.Set("Authorization", "Bearer "+testApiKey)
+		resp, err := srv.Client().Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+
+		if resp.StatusCode != http.StatusTooManyRequests {
+			t.Errorf("attempt %d: status = %d, want %d", i, resp.StatusCode, http.StatusTooManyRequests)
+		}
+		if retryAfter := resp.Header.Get("Retry-After"); retryAfter != "3" {
+			t.Errorf("attempt %d: Retry-After = %q, want \"3\"", i, retryAfter)
+		}
+	}
+
+	// Faults only match their method and path, and run out
+	if status := call(t, srv, http.MethodGet, "/vms/v3", nil, nil); status != http.StatusOK {
+		t.Errorf("other path status = %d, want %d", status, http.StatusOK)
+	}
+	if status := call(t, srv, http.MethodGet, "/ssh_keys", nil, nil); status != http.StatusOK {
+		t.Errorf("status after the faults = %d, want %d", status, http.StatusOK)
+	}
+
+	// Every request is recorded, including the failed ones
+	if n := len(srv.Requests()); n != 4 {
+		t.Errorf("recorded %d requests, want 4", n)
+	}
+}
+
+func TestVmLifecycle(t *testing.T) {
+	srv := testServer(t)
+	srv.SetStatusTransitions(fluenceapi.VmStatusLaunching, fluenceapi.VmStatusLaunching, fluenceapi.VmStatusActive)
+	srv.SetListLag(1)
+
+	created := createVms(t, srv, "DE", 2, nil)
+	if len(created) != 2 {
+		t.Fatalf("created %d VMs, want 2", len(created))
+	}
+
+	// New VMs are hidden from the first listing
+	if vms := listVms(t, srv); len(vms) != 0 {
+		t.Fatalf("first listing has %d VMs, want 0", len(vms))
+	}
+
+	// Each listing moves the VMs one status further
+	for _, want := range []string{fluenceapi.VmStatusLaunching, fluenceapi.VmStatusLaunching, fluenceapi.VmStatusActive, fluenceapi.VmStatusActive} {
+		vms := listVms(t, srv)
+		if len(vms) != 2 {
+			t.Fatalf("listed %d VMs, want 2", len(vms))
+		}
+		for _, vm := range vms {
+			if vm.Status != want {
+				t.Errorf("VM %s status = %s, want %s", vm.Id, vm.Status, want)
+			}
+			if hasIp := vm.PublicIp != nil; hasIp != (want == fluenceapi.VmStatusActive) {
+				t.Errorf("VM %s in status %s has public IP %v", vm.Id, vm.Status, vm.PublicIp)
+			}
+			if vm.Datacenter == nil || vm.Datacenter.CountryCode != "DE" {
+				t.Errorf("VM %s was not placed in DE: %+v", vm.Id, vm.Datacenter)
+			}
+			if vm.PricePerEpoch != "1.5" {
+				t.Errorf("VM %s price = %s, want the cheapest offer", vm.Id, vm.PricePerEpoch)
+			}
+		}
+	}
+
+	var statuses []fluenceapi.VmStatusInfoDTO
+	call(t, srv, http.MethodGet, "/vms/v3/status?ids="+created[0].VmId+",unknown", nil, &statuses)
+	if len(statuses) != 1 || string(statuses[0].Id) != created[0].VmId || statuses[0].Status != fluenceapi.VmStatusActive {
+		t.Errorf("statuses = %+v, want the active first VM only", statuses)
+	}
+}
+
+func TestCreateVms_requestId(t *testing.T) {
+	srv := testServer(t)
+	requestId := "request-1"
+
+	first := createVms(t, srv, "US", 2, &requestId)
+	second := createVms(t, srv, "US", 2, &requestId)
+
+	if first[0].VmId != second[0].VmId || first[1].VmId != second[1].VmId {
+		t.Errorf("a repeated request created new VMs: %v then %v", first, second)
+	}
+	if n := len(srv.Vms()); n != 2 {
+		t.Errorf("the fake holds %d VMs, want 2", n)
+	}
+}
+
+func TestCreateVms_noOffer(t *testing.T) {
+	srv := testServer(t)
+
+	status := call(t, srv, http.MethodPost, "/vms/v3", fluenceapi.CreateVmV3{
+		Constraints: &fluenceapi.OfferConstraints{
+			Datacenter: &fluenceapi.DatacenterConstraint{Countries: []string{"FR"}},
+		},
+		Instances:       1,
+		VmConfiguration: fluenceapi.VmConfiguration{Name: "vm"},
+	}, nil)
+	if status != http.StatusUnprocessableEntity {
+		t.Errorf("status = %d, want %d", status, http.StatusUnprocessableEntity)
+	}
+}
+
+func TestUpdateVms(t *testing.T) {
+	srv := testServer(t)
+	created := createVms(t, srv, "DE", 1, nil)
+
+	name := "renamed"
+	ports := []fluenceapi.OpenPorts{{Port: 443, Protocol: fluenceapi.ProtocolTCP}}
+
+	// An unknown VM fails the whole request before anything is applied
+	status := call(t, srv, http.MethodPatch, "/vms/v3", fluenceapi.UpdateVms{Updates: []fluenceapi.UpdateVm{
+		{Id: created[0].VmId, VmName: &name},
+		{Id: "unknown", VmName: &name},
+	}}, nil)
+	if status != http.StatusNotFound {
+		t.Errorf("status = %d, want %d", status, http.StatusNotFound)
+	}
+	if vm := srv.Vms()[0]; *vm.VmName != "vm" {
+		t.Errorf("VM was renamed to %s by a failed request", *vm.VmName)
+	}
+
+	status = call(t, srv, http.MethodPatch, "/vms/v3", fluenceapi.UpdateVms{Updates: []fluenceapi.UpdateVm{
+		{Id: created[0].VmId, VmName: &name, OpenPorts: &ports},
+	}}, nil)
+	if status != http.StatusOK {
+		t.Fatalf("status = %d, want %d", status, http.StatusOK)
+	}
+	vm := srv.Vms()[0]
+	if *vm.VmName != name || len(*vm.Ports) != 1 || (*vm.Ports)[0].Port != 443 {
+		t.Errorf("VM = %s with ports %v, want renamed with port 443", *vm.VmName, *vm.Ports)
+	}
+}
+
+func TestRemoveVms(t *testing.T) {
+	srv := testServer(t)
+	created := createVms(t, srv, "DE", 1, nil)
+
+	var removed fluenceapi.VmsRemoved
+	status := call(t, srv, http.MethodDelete, "/vms/v3", fluenceapi.RemoveVms{VmIds: []string{created[0].VmId}}, &removed)
+	if status != http.StatusOK || len(removed.RemovedIds) != 1 {
+		t.Fatalf("remove = %d %+v, want the VM removed", status, removed)
+	}
+
+	// A terminated VM is listed once, then disappears
+	vms := listVms(t, srv)
+	if len(vms) != 1 || vms[0].Status != fluenceapi.VmStatusTerminated {
+		t.Errorf("first listing = %+v, want the terminated VM", vms)
+	}
+	if vms := listVms(t, srv); len(vms) != 0 {
+		t.Errorf("second listing has %d VMs, want 0", len(vms))
+	}
+
+	status = call(t, srv, http.MethodDelete, "/vms/v3", fluenceapi.RemoveVms{VmIds: []string{created[0].VmId}}, nil)
+	if status != http.StatusNotFound {
+		t.Errorf("removing a gone VM: status = %d, want %d", status, http.StatusNotFound)
+	}
+}
+
+func TestOutOfBandChanges(t *testing.T) {
+	srv := testServer(t)
+	created := createVms(t, srv, "DE", 2, nil)
+
+	if !srv.SetVmStatus(created[0].VmId, fluenceapi.VmStatusFailed) {
+		t.Fatal("SetVmStatus did not find the VM")
+	}
+	if !srv.SetVmPorts(created[0].VmId, []fluenceapi.PortSpec{{Port: 8080, Protocol: fluenceapi.ProtocolTCP}}) {
+		t.Fatal("SetVmPorts did not find the VM")
+	}
+	if !srv.DeleteVm(created[1].VmId) {
+		t.Fatal("DeleteVm did not find the VM")
+	}
+	if srv.DeleteVm("unknown") || srv.SetVmStatus("unknown", fluenceapi.VmStatusActive) {
+		t.Error("an unknown VM was found")
+	}
+
+	// The status set out of band is not moved further by listings
+	vms := listVms(t, srv)
+	if len(vms) != 1 || vms[0].Status != fluenceapi.VmStatusFailed || (*vms[0].Ports)[0].Port != 8080 {
+		t.Errorf("listing = %+v, want the failed VM with port 8080", vms)
+	}
+}
+
+func TestSshKeys(t *testing.T) {
+	srv := testServer(t)
+	publicKey := "ssh-ed25519 AAAAC3NzaC1lZDI1NTE5AAAAIGb3s8Yw5Uj3qFZcw5hJq8qL8Yb1W2o7L9aQk1dZr6hN user@host"
+
+	var key fluenceapi.SshKey
+	status := call(t, srv, http.MethodPost, "/ssh_keys", fluenceapi.AddSshKey{Name: "key", PublicKey: publicKey + "\n"}, &key)
+	if status != http.StatusCreated {
+		t.Fatalf("create status = %d, want %d", status, http.StatusCreated)
+	}
+	if key.Algorithm != "ssh-ed25519" || key.Comment != "user@host" || key.PublicKey != publicKey || key.Name == nil || *key.Name != "key" {
+		t.Errorf("created key = %+v", key)
+	}
+	if key.Fingerprint != "SHA256:ywkqmXhYhU9WxqkCmunJLKIiWXVNZdJ7mRlb3c3b9Ts" {
+		t.Errorf("fingerprint = %s", key.Fingerprint)
+	}
+
+	status = call(t, srv, http.MethodPost, "/ssh_keys", fluenceapi.AddSshKey{Name: "again", PublicKey: publicKey}, nil)
+	if status != http.StatusConflict {
+		t.Errorf("duplicate key status = %d, want %d", status, http.StatusConflict)
+	}
+	status = call(t, srv, http.MethodPost, "/ssh_keys", fluenceapi.AddSshKey{PublicKey: "not-a-key"}, nil)
+	if status != http.StatusBadRequest {
+		t.Errorf("invalid key status = %d, want %d", status, http.StatusBadRequest)
+	}
+
+	var keys []fluenceapi.SshKey
+	call(t, srv, http.MethodGet, "/ssh_keys", nil, &keys)
+	if len(keys) != 1 {
+		t.Errorf("listed %d keys, want 1", len(keys))
+	}
+
+	remove := fluenceapi.RemoveSshKey{Fingerprint: key.Fingerprint}
+	if status := call(t, srv, http.MethodDelete, "/ssh_keys", remove, nil); status != http.StatusOK {
+		t.Errorf("remove status = %d, want %d", status, http.StatusOK)
+	}
+	if status := call(t, srv, http.MethodDelete, "/ssh_keys", remove, nil); status != http.StatusNotFound {
+		t.Errorf("second remove status = %d, want %d", status, http.StatusNotFound)
+	}
+}
+
+func TestMarketplace(t *testing.T) {
+	fixtures := DefaultFixtures()
+	fixtures.Capacity = 2
+	srv := testServer(t, WithFixtures(fixtures))
+
+	basicConfiguration := "cpu-4-ram-8gb-storage-25gb"
+	constraints := fluenceapi.OfferConstraints{
+		BasicConfiguration: &basicConfiguration,
+		Datacenter:         &fluenceapi.DatacenterConstraint{Countries: []string{"DE", "CA"}},
+	}
+
+	var offers []fluenceapi.MarketOffering
+	call(t, srv, http.MethodPost, "/marketplace/offers", constraints, &offers)
+	if len(offers) != 2 {
+		t.Fatalf("listed %d offers, want 2", len(offers))
+	}
+	// Offers of the same price are ordered by datacenter
+	if offers[0].Datacenter.CountryCode != "CA" || offers[0].Servers[0].AvailableBasicInstances != 2 {
+		t.Errorf("first offer = %+v", offers[0])
+	}
+
+	var estimate fluenceapi.EstimatedDepositV3DTO
+	call(t, srv, http.MethodPost, "/vms/v3/estimate", fluenceapi.EstimateDepositRequestV3{Constraints: &constraints, Instances: 2}, &estimate)
+	if estimate.TotalPricePerEpoch != "6" || estimate.MaxPricePerEpoch != "6" || estimate.DepositAmountUsdc != "12" || estimate.DepositEpochs != 2 {
+		t.Errorf("estimate = %+v", estimate)
+	}
+
+	// Running VMs use up the capacity of their offer
+	createVms(t, srv, "CA", 2, nil)
+	call(t, srv, http.MethodPost, "/marketplace/offers", fluenceapi.OfferConstraints{
+		Datacenter: &fluenceapi.DatacenterConstraint{Countries: []string{"CA"}},
+	}, &offers)
+	if len(offers) != 3 {
+		t.Errorf("listed %d offers in CA, want the 3 configurations with capacity left", len(offers))
+	}
+
+	status := call(t, srv, http.MethodPost, "/vms/v3/estimate", fluenceapi.EstimateDepositRequestV3{
+		Constraints: &fluenceapi.OfferConstraints{
+			Hardware: &fluenceapi.HardwareConstraints{Cpu: []fluenceapi.CpuHardware{{Manufacturer: "Apple"}}},
+		},
+		Instances: 1,
+	}, nil)
+	if status != http.StatusUnprocessableEntity {
+		t.Errorf("estimate without offers: status = %d, want %d", status, http.StatusUnprocessableEntity)
+	}
+
+	var countries []string
+	call(t, srv, http.MethodGet, "/marketplace/countries", nil, &countries)
+	if len(countries) != 3 || countries[0] != "CA" || countries[2] != "US" {
+		t.Errorf("countries = %v, want them sorted", countries)
+	}
+}
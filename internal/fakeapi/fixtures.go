@@ -0,0 +1,113 @@
+package fakeapi
+
+import (
+	"embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"sort"
+
+	fluenceapi "github.com/decentralized-infrastructure/fluence-api-client-go"
+)
+
+//go:embed fixtures/*.json
+var defaultFixtureFiles embed.FS
+
+// Configuration is a basic configuration offered by the fake marketplace,
+// together with its price per epoch in USD for one instance.
+type Configuration struct {
+	Slug  string `json:"slug"`
+	Price string `json:"price"`
+}
+
+// Fixtures holds the static marketplace data served by the fake.
+type Fixtures struct {
+	Datacenters    []fluenceapi.DatacenterDTO   `json:"datacenters"`
+	DefaultImages  []fluenceapi.DefaultImageDTO `json:"default_images"`
+	Hardware       fluenceapi.AvailableHardware `json:"hardware"`
+	Configurations []Configuration              `json:"configurations"`
+
+	// Capacity is the number of instances of every configuration that each
+	// datacenter can host.
+	Capacity uint64 `json:"capacity"`
+}
+
+// fixtureFiles maps the name of each fixture file to the field it fills.
+func (f *Fixtures) fixtureFiles() map[string]interface{} {
+	return map[string]interface{}{
+		"datacenters.json":    &f.Datacenters,
+		"default_images.json": &f.DefaultImages,
+		"hardware.json":       &f.Hardware,
+		"configurations.json": &f.Configurations,
+	}
+}
+
+// DefaultFixtures returns the fixtures embedded in the package.
+func DefaultFixtures() *Fixtures {
+	fixtures := &Fixtures{Capacity: 10}
+
+	fsys, err := fs.Sub(defaultFixtureFiles, "fixtures")
+	if err != nil {
+		panic(fmt.Sprintf("fakeapi: missing embedded fixtures: %s", err))
+	}
+	if err := fixtures.load(fsys, false); err != nil {
+		panic(fmt.Sprintf("fakeapi: invalid embedded fixtures: %s", err))
+	}
+
+	return fixtures
+}
+
+// LoadFixtures reads fixtures from the JSON files in dir. Files are named
+// like the embedded ones (datacenters.json, default_images.json,
+// hardware.json and configurations.json); missing files keep the embedded
+// data.
+func LoadFixtures(dir string) (*Fixtures, error) {
+	fixtures := DefaultFixtures()
+	if err := fixtures.load(os.DirFS(dir), true); err != nil {
+		return nil, err
+	}
+	return fixtures, nil
+}
+
+// load decodes the fixture files found in fsys. When skipMissing is false,
+// every file must be present.
+func (f *Fixtures) load(fsys fs.FS, skipMissing bool) error {
+	for name, target := range f.fixtureFiles() {
+		data, err := fs.ReadFile(fsys, name)
+		if skipMissing && errors.Is(err, fs.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return fmt.Errorf("reading fixture %s: %w", name, err)
+		}
+		if err := json.Unmarshal(data, target); err != nil {
+			return fmt.Errorf("decoding fixture %s: %w", name, err)
+		}
+	}
+	return nil
+}
+
+// configurationSlugs returns the slugs of every basic configuration.
+func (f *Fixtures) configurationSlugs() []string {
+	slugs := make([]string, len(f.Configurations))
+	for i, config := range f.Configurations {
+		slugs[i] = config.Slug
+	}
+	return slugs
+}
+
+// countries returns the sorted country codes of every datacenter.
+func (f *Fixtures) countries() []string {
+	seen := map[string]bool{}
+	countries := []string{}
+	for _, dc := range f.Datacenters {
+		if !seen[dc.CountryCode] {
+			seen[dc.CountryCode] = true
+			countries = append(countries, dc.CountryCode)
+		}
+	}
+	sort.Strings(countries)
+	return countries
+}
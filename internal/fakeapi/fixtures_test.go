@@ -0,0 +1,59 @@
+package fakeapi
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestLoadFixtures(t *testing.T) {
+	dir := t.TempDir()
+	configurations := `[{"slug": "cpu-1-ram-2gb-storage-10gb", "price": "0.75"}]`
+	if err := os.WriteFile(filepath.Join(dir, "configurations.json"), []byte(configurations), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	fixtures, err := LoadFixtures(dir)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	want := []Configuration{{Slug: "cpu-1-ram-2gb-storage-10gb", Price: "0.75"}}
+	if !reflect.DeepEqual(fixtures.Configurations, want) {
+		t.Errorf("configurations = %+v, want %+v", fixtures.Configurations, want)
+	}
+
+	// Missing files keep the built-in data
+	defaults := DefaultFixtures()
+	if !reflect.DeepEqual(fixtures.Datacenters, defaults.Datacenters) || !reflect.DeepEqual(fixtures.DefaultImages, defaults.DefaultImages) {
+		t.Error("the built-in datacenters or images were replaced")
+	}
+	if fixtures.Capacity != defaults.Capacity {
+		t.Errorf("capacity = %d, want %d", fixtures.Capacity, defaults.Capacity)
+	}
+}
+
+func TestLoadFixtures_invalid(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "datacenters.json"), []byte(`{"not": "a list"}`), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := LoadFixtures(dir); err == nil {
+		t.Error("expected an error")
+	}
+}
+
+func TestDefaultFixtures(t *testing.T) {
+	fixtures := DefaultFixtures()
+	if len(fixtures.Datacenters) != 3 || len(fixtures.DefaultImages) != 3 || len(fixtures.Configurations) != 4 {
+		t.Errorf("fixtures = %d datacenters, %d images, %d configurations", len(fixtures.Datacenters), len(fixtures.DefaultImages), len(fixtures.Configurations))
+	}
+
+	// Each call returns a copy that can be changed freely
+	fixtures.Configurations[0].Price = "100"
+	if DefaultFixtures().Configurations[0].Price == "100" {
+		t.Error("changing the fixtures changed the built-in ones")
+	}
+}
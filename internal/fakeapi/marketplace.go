@@ -0,0 +1,226 @@
+package fakeapi
+
+import (
+	"net/http"
+	"sort"
+	"strconv"
+
+	fluenceapi "github.com/decentralized-infrastructure/fluence-api-client-go"
+)
+
+// depositEpochs is the number of epochs covered by the deposit of a new VM.
+const depositEpochs = 2
+
+// offer is one basic configuration available in one datacenter.
+type offer struct {
+	datacenter fluenceapi.DatacenterDTO
+	config     Configuration
+	price      float64
+	available  uint64
+}
+
+// matchingOffers returns the offers that satisfy constraints and can host
+// the given number of instances, cheapest first. Must be called with a.mu held.
+func (a *API) matchingOffers(constraints *fluenceapi.OfferConstraints, instances int) []offer {
+	if constraints == nil {
+		constraints = &fluenceapi.OfferConstraints{}
+	}
+
+	if !a.hardwareAvailable(constraints.Hardware) {
+		return nil
+	}
+
+	var maxPrice float64
+	hasMaxPrice := false
+	if constraints.MaxTotalPricePerEpochUsd != nil {
+		if v, err := strconv.ParseFloat(*constraints.MaxTotalPricePerEpochUsd, 64); err == nil {
+			maxPrice = v
+			hasMaxPrice = true
+		}
+	}
+
+	offers := []offer{}
+	for _, dc := range a.fixtures.Datacenters {
+		if constraints.Datacenter != nil && len(constraints.Datacenter.Countries) > 0 && !contains(constraints.Datacenter.Countries, dc.CountryCode) {
+			continue
+		}
+
+		for _, config := range a.fixtures.Configurations {
+			if constraints.BasicConfiguration != nil && *constraints.BasicConfiguration != config.Slug {
+				continue
+			}
+
+			price, err := strconv.ParseFloat(config.Price, 64)
+			if err != nil {
+				continue
+			}
+			if hasMaxPrice && price*float64(instances) > maxPrice {
+				continue
+			}
+
+			available := a.availableCapacity(dc, config)
+			if available < uint64(instances) {
+				continue
+			}
+
+			offers = append(offers, offer{
+				datacenter: dc,
+				config:     config,
+				price:      price,
+				available:  available,
+			})
+		}
+	}
+
+	sort.SliceStable(offers, func(i, j int) bool {
+		if offers[i].price != offers[j].price {
+			return offers[i].price < offers[j].price
+		}
+		return offers[i].datacenter.Slug < offers[j].datacenter.Slug
+	})
+	return offers
+}
+
+// hardwareAvailable reports whether every hardware constraint matches the
+// available hardware. Empty fields in a constraint match any value.
+func (a *API) hardwareAvailable(hw *fluenceapi.HardwareConstraints) bool {
+	if hw == nil {
+		return true
+	}
+
+	for _, want := range hw.Cpu {
+		found := false
+		for _, have := range a.fixtures.Hardware.Cpu {
+			if matches(want.Architecture, have.Architecture) && matches(want.Manufacturer, have.Manufacturer) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+
+	for _, want := range hw.Memory {
+		found := false
+		for _, have := range a.fixtures.Hardware.Memory {
+			if matches(want.Type, have.Type) && matches(want.Generation, have.Generation) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+
+	for _, want := range hw.Storage {
+		found := false
+		for _, have := range a.fixtures.Hardware.Storage {
+			if matches(want.Type, have.Type) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+
+	return true
+}
+
+// availableCapacity returns how many more instances of config fit in dc.
+func (a *API) availableCapacity(dc fluenceapi.DatacenterDTO, config Configuration) uint64 {
+	var used uint64
+	for _, vm := range a.vms {
+		if vm.datacenter == dc.Slug && vm.configuration == config.Slug && vm.Status != fluenceapi.VmStatusTerminated {
+			used++
+		}
+	}
+	if used >= a.fixtures.Capacity {
+		return 0
+	}
+	return a.fixtures.Capacity - used
+}
+
+func (a *API) listOffers(w http.ResponseWriter, body []byte) {
+	var constraints fluenceapi.OfferConstraints
+	if !decodeJSON(w, body, &constraints) {
+		return
+	}
+
+	offerings := []fluenceapi.MarketOffering{}
+	for _, o := range a.matchingOffers(&constraints, 1) {
+		offerings = append(offerings, fluenceapi.MarketOffering{
+			Configuration: fluenceapi.ConfigurationPrice{
+				Slug:  o.config.Slug,
+				Price: o.config.Price,
+			},
+			Resources: []fluenceapi.Resource{},
+			Datacenter: fluenceapi.Datacenter{
+				CountryCode:    o.datacenter.CountryCode,
+				CityCode:       o.datacenter.CityCode,
+				CityIndex:      uint32(o.datacenter.Index),
+				Tier:           uint32(o.datacenter.Tier),
+				Certifications: o.datacenter.Certifications,
+			},
+			Servers: []fluenceapi.ServerOffering{
+				{
+					AvailableBasicInstances: o.available,
+					AdditionalResources:     []fluenceapi.AdditionalSupply{},
+				},
+			},
+			MaxAdditionalSupply: []fluenceapi.AdditionalSupply{},
+		})
+	}
+
+	writeJSON(w, http.StatusOK, offerings)
+}
+
+func (a *API) estimateDeposit(w http.ResponseWriter, body []byte) {
+	var req fluenceapi.EstimateDepositRequestV3
+	if !decodeJSON(w, body, &req) {
+		return
+	}
+
+	if req.Instances < 1 {
+		writeError(w, http.StatusBadRequest, "instances must be at least 1")
+		return
+	}
+
+	offers := a.matchingOffers(req.Constraints, req.Instances)
+	if len(offers) == 0 {
+		writeError(w, http.StatusUnprocessableEntity, "no offers match the given constraints")
+		return
+	}
+
+	total := offers[0].price * float64(req.Instances)
+	maxTotal := offers[len(offers)-1].price * float64(req.Instances)
+
+	writeJSON(w, http.StatusOK, fluenceapi.EstimatedDepositV3DTO{
+		DepositAmountUsdc:  formatAmount(maxTotal * depositEpochs),
+		DepositEpochs:      depositEpochs,
+		TotalPricePerEpoch: formatAmount(total),
+		MaxPricePerEpoch:   formatAmount(maxTotal),
+		Instances:          req.Instances,
+	})
+}
+
+// matches reports whether a constraint value accepts have. Empty values match anything.
+func matches(want, have string) bool {
+	return want == "" || want == have
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+func formatAmount(v float64) string {
+	return strconv.FormatFloat(v, 'f', -1, 64)
+}
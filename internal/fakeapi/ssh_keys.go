@@ -0,0 +1,91 @@
+package fakeapi
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	fluenceapi "github.com/decentralized-infrastructure/fluence-api-client-go"
+)
+
+func (a *API) listSshKeys(w http.ResponseWriter) {
+	keys := a.sshKeys
+	if keys == nil {
+		keys = []fluenceapi.SshKey{}
+	}
+	writeJSON(w, http.StatusOK, keys)
+}
+
+func (a *API) createSshKey(w http.ResponseWriter, body []byte) {
+	var req fluenceapi.AddSshKey
+	if !decodeJSON(w, body, &req) {
+		return
+	}
+
+	algorithm, fingerprint, comment, err := parsePublicKey(req.PublicKey)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	for _, key := range a.sshKeys {
+		if key.Fingerprint == fingerprint {
+			writeError(w, http.StatusConflict, fmt.Sprintf("SSH key %s already exists", fingerprint))
+			return
+		}
+	}
+
+	key := fluenceapi.SshKey{
+		Fingerprint: fingerprint,
+		Algorithm:   algorithm,
+		Comment:     comment,
+		PublicKey:   strings.TrimSpace(req.PublicKey),
+		Active:      true,
+		CreatedAt:   a.clock.Now().Format(time.RFC3339),
+	}
+	if req.Name != "" {
+		name := req.Name
+		key.Name = &name
+	}
+
+	a.sshKeys = append(a.sshKeys, key)
+	writeJSON(w, http.StatusCreated, key)
+}
+
+func (a *API) removeSshKey(w http.ResponseWriter, body []byte) {
+	var req fluenceapi.RemoveSshKey
+	if !decodeJSON(w, body, &req) {
+		return
+	}
+
+	for i, key := range a.sshKeys {
+		if key.Fingerprint == req.Fingerprint {
+			a.sshKeys = append(a.sshKeys[:i], a.sshKeys[i+1:]...)
+			w.WriteHeader(http.StatusOK)
+			return
+		}
+	}
+
+	writeError(w, http.StatusNotFound, fmt.Sprintf("SSH key %s not found", req.Fingerprint))
+}
+
+// parsePublicKey splits an authorized_keys line and computes the OpenSSH
+// SHA256 fingerprint of the key.
+func parsePublicKey(publicKey string) (algorithm, fingerprint, comment string, err error) {
+	fields := strings.Fields(publicKey)
+	if len(fields) < 2 {
+		return "", "", "", fmt.Errorf("invalid public key: expected \"<algorithm> <base64 key> [comment]\"")
+	}
+
+	blob, err := base64.StdEncoding.DecodeString(fields[1])
+	if err != nil {
+		return "", "", "", fmt.Errorf("invalid public key: %s", err)
+	}
+
+	sum := sha256.Sum256(blob)
+	fingerprint = "SHA256:" + base64.RawStdEncoding.EncodeToString(sum[:])
+	return fields[0], fingerprint, strings.Join(fields[2:], " "), nil
+}
@@ -0,0 +1,287 @@
+package fakeapi
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	fluenceapi "github.com/decentralized-infrastructure/fluence-api-client-go"
+)
+
+// defaultEpochDuration is the default billing period of the fake marketplace.
+const defaultEpochDuration = 24 * time.Hour
+
+// vmRecord is a VM stored by the fake together with its simulation state.
+type vmRecord struct {
+	fluenceapi.RunningInstanceV3
+
+	datacenter    string
+	configuration string
+	requestId     string
+
+	// price, reserved and totalSpent are the numeric forms of the billing
+	// fields of RunningInstanceV3, in USD
+	price         float64
+	reserved      float64
+	totalSpent    float64
+	nextBillingAt time.Time
+
+	// transitions holds the statuses the VM still has to go through
+	transitions []string
+
+	// hiddenFor is the number of list requests that will not show the VM
+	hiddenFor int
+
+	// listedTerminated is set once a terminated VM has been listed, after
+	// which it disappears
+	listedTerminated bool
+}
+
+// advance moves the VM to its next status, if any.
+func (vm *vmRecord) advance(now time.Time, ipIndex *int) {
+	if len(vm.transitions) == 0 {
+		return
+	}
+
+	vm.Status = vm.transitions[0]
+	vm.StatusChangedAt = now.Format(time.RFC3339)
+	vm.transitions = vm.transitions[1:]
+
+	if vm.Status == fluenceapi.VmStatusActive && vm.PublicIp == nil {
+		*ipIndex++
+		ip := fmt.Sprintf("203.0.113.%d", *ipIndex%254+1)
+		vm.PublicIp = &ip
+	}
+}
+
+// bill charges every VM that is not terminated for each epoch that ended
+// since it was last billed. The price of an epoch is taken from the reserved
+// balance first. Must be called with a.mu held.
+func (a *API) bill() {
+	now := a.clock.Now()
+	for _, vm := range a.vms {
+		if vm.Status == fluenceapi.VmStatusTerminated {
+			continue
+		}
+
+		for !now.Before(vm.nextBillingAt) {
+			vm.totalSpent += vm.price
+			vm.reserved -= vm.price
+			if vm.reserved < 0 {
+				vm.reserved = 0
+			}
+			vm.nextBillingAt = vm.nextBillingAt.Add(a.epoch)
+		}
+
+		vm.TotalSpent = formatAmount(vm.totalSpent)
+		vm.ReservedBalance = formatAmount(vm.reserved)
+		vm.NextBillingAt = vm.nextBillingAt.Format(time.RFC3339)
+	}
+}
+
+func (a *API) listVms(w http.ResponseWriter) {
+	now := a.clock.Now()
+
+	// Terminated VMs are listed once, then disappear
+	kept := a.vms[:0]
+	for _, vm := range a.vms {
+		if !vm.listedTerminated {
+			kept = append(kept, vm)
+		}
+	}
+	a.vms = kept
+
+	vms := []fluenceapi.RunningInstanceV3{}
+	for _, vm := range a.vms {
+		if vm.hiddenFor > 0 {
+			vm.hiddenFor--
+			continue
+		}
+
+		vm.advance(now, &a.ipIndex)
+		if vm.Status == fluenceapi.VmStatusTerminated {
+			vm.listedTerminated = true
+		}
+		vms = append(vms, vm.RunningInstanceV3)
+	}
+
+	writeJSON(w, http.StatusOK, vms)
+}
+
+func (a *API) createVms(w http.ResponseWriter, body []byte) {
+	var req fluenceapi.CreateVmV3
+	if !decodeJSON(w, body, &req) {
+		return
+	}
+
+	if req.Instances < 1 {
+		writeError(w, http.StatusBadRequest, "instances must be at least 1")
+		return
+	}
+
+	// A repeated request ID returns the VMs created by the first submission
+	if req.RequestId != nil && *req.RequestId != "" {
+		created := []fluenceapi.CreatedVm{}
+		for _, vm := range a.vms {
+			if vm.requestId == *req.RequestId {
+				created = append(created, fluenceapi.CreatedVm{VmId: vm.Id, VmName: *vm.VmName})
+			}
+		}
+		if len(created) > 0 {
+			writeJSON(w, http.StatusCreated, created)
+			return
+		}
+	}
+
+	offers := a.matchingOffers(req.Constraints, req.Instances)
+	if len(offers) == 0 {
+		writeError(w, http.StatusUnprocessableEntity, "no offers match the given constraints")
+		return
+	}
+	o := offers[0]
+
+	now := a.clock.Now()
+	ports := []fluenceapi.PortSpec{}
+	for _, p := range req.VmConfiguration.OpenPorts {
+		ports = append(ports, fluenceapi.PortSpec{Port: p.Port, Protocol: p.Protocol})
+	}
+
+	created := []fluenceapi.CreatedVm{}
+	for i := 0; i < req.Instances; i++ {
+		name := req.VmConfiguration.Name
+		osImage := req.VmConfiguration.OsImage
+		vmPorts := append([]fluenceapi.PortSpec{}, ports...)
+
+		vm := &vmRecord{
+			RunningInstanceV3: fluenceapi.RunningInstanceV3{
+				Id:              newId(),
+				Status:          fluenceapi.VmStatusNew,
+				PricePerEpoch:   o.config.Price,
+				Resources:       []fluenceapi.VmResource{},
+				CreatedAt:       now.Format(time.RFC3339),
+				NextBillingAt:   now.Add(a.epoch).Format(time.RFC3339),
+				ReservedBalance: formatAmount(o.price * depositEpochs),
+				TotalSpent:      "0",
+				StatusChangedAt: now.Format(time.RFC3339),
+				Datacenter: &fluenceapi.Datacenter{
+					CountryCode:    o.datacenter.CountryCode,
+					CityCode:       o.datacenter.CityCode,
+					CityIndex:      uint32(o.datacenter.Index),
+					Tier:           uint32(o.datacenter.Tier),
+					Certifications: o.datacenter.Certifications,
+				},
+				OsImage: &osImage,
+				Ports:   &vmPorts,
+				VmName:  &name,
+			},
+			datacenter:    o.datacenter.Slug,
+			configuration: o.config.Slug,
+			price:         o.price,
+			reserved:      o.price * depositEpochs,
+			nextBillingAt: now.Add(a.epoch),
+			transitions:   append([]string{}, a.transitions...),
+			hiddenFor:     a.listLag,
+		}
+		if req.RequestId != nil {
+			vm.requestId = *req.RequestId
+		}
+
+		a.vms = append(a.vms, vm)
+		created = append(created, fluenceapi.CreatedVm{VmId: vm.Id, VmName: name})
+	}
+
+	writeJSON(w, http.StatusCreated, created)
+}
+
+func (a *API) updateVms(w http.ResponseWriter, body []byte) {
+	var req fluenceapi.UpdateVms
+	if !decodeJSON(w, body, &req) {
+		return
+	}
+
+	// Validate every update before applying any of them
+	records := make([]*vmRecord, len(req.Updates))
+	for i, update := range req.Updates {
+		records[i] = a.findVm(update.Id)
+		if records[i] == nil {
+			writeError(w, http.StatusNotFound, fmt.Sprintf("VM %s not found", update.Id))
+			return
+		}
+	}
+
+	for i, update := range req.Updates {
+		vm := records[i]
+		if update.VmName != nil {
+			name := *update.VmName
+			vm.VmName = &name
+		}
+		if update.OpenPorts != nil {
+			ports := []fluenceapi.PortSpec{}
+			for _, p := range *update.OpenPorts {
+				ports = append(ports, fluenceapi.PortSpec{Port: p.Port, Protocol: p.Protocol})
+			}
+			vm.Ports = &ports
+		}
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
+
+func (a *API) removeVms(w http.ResponseWriter, body []byte) {
+	var req fluenceapi.RemoveVms
+	if !decodeJSON(w, body, &req) {
+		return
+	}
+
+	now := a.clock.Now()
+	removed := fluenceapi.VmsRemoved{
+		RemovedIds:   []string{},
+		Transactions: []string{},
+	}
+	for _, id := range req.VmIds {
+		vm := a.findVm(id)
+		if vm == nil {
+			writeError(w, http.StatusNotFound, fmt.Sprintf("VM %s not found", id))
+			return
+		}
+
+		vm.Status = fluenceapi.VmStatusTerminated
+		vm.StatusChangedAt = now.Format(time.RFC3339)
+		vm.TerminatedAt = now.Format(time.RFC3339)
+		vm.transitions = nil
+
+		removed.RemovedIds = append(removed.RemovedIds, id)
+		removed.Transactions = append(removed.Transactions, "0x"+strings.ReplaceAll(newId(), "-", ""))
+	}
+
+	writeJSON(w, http.StatusOK, removed)
+}
+
+func (a *API) vmStatuses(w http.ResponseWriter, ids string) {
+	statuses := []fluenceapi.VmStatusInfoDTO{}
+	for _, id := range strings.Split(ids, ",") {
+		vm := a.findVm(strings.TrimSpace(id))
+		if vm == nil {
+			continue
+		}
+		statuses = append(statuses, fluenceapi.VmStatusInfoDTO{
+			Id:              fluenceapi.VmId(vm.Id),
+			Status:          vm.Status,
+			StatusChangedAt: vm.StatusChangedAt,
+			PublicIp:        vm.PublicIp,
+		})
+	}
+
+	writeJSON(w, http.StatusOK, statuses)
+}
+
+// findVm returns the VM with the given ID, or nil. Must be called with a.mu held.
+func (a *API) findVm(id string) *vmRecord {
+	for _, vm := range a.vms {
+		if vm.Id == id {
+			return vm
+		}
+	}
+	return nil
+}
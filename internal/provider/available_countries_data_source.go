@@ -79,7 +79,7 @@ func (d *availableCountriesDataSource) Read(ctx context.Context, req datasource.
 	tflog.Debug(ctx, "Fetching available countries from marketplace")
 
 	// Call the API
-	countries, err := d.client.GetAvailableCountries()
+	countries, err := withContext(ctx, d.client).GetAvailableCountries()
 	if err != nil {
 		resp.Diagnostics.AddError("Client Error", fmt.Sprintf("Unable to read available countries, got error: %s", err))
 		return
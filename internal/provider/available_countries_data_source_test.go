@@ -0,0 +1,47 @@
+package provider
+
+import (
+	"net/http"
+	"regexp"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-testing/helper/resource"
+)
+
+func TestAccAvailableCountriesDataSource(t *testing.T) {
+	srv := testAccServer(t)
+
+	resource.Test(t, resource.TestCase{
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		Steps: []resource.TestStep{
+			// A server error is retried
+			{
+				PreConfig: func() {
+					srv.InjectFault(http.MethodGet, "/marketplace/countries", http.StatusInternalServerError, 1, 0)
+				},
+				Config: testAccProviderConfig(srv) + `data "fluence_available_countries" "test" {}`,
+				Check: resource.ComposeAggregateTestCheckFunc(
+					resource.TestCheckResourceAttr("data.fluence_available_countries.test", "countries.#", "3"),
+					resource.TestCheckTypeSetElemAttr("data.fluence_available_countries.test", "countries.*", "DE"),
+					resource.TestCheckTypeSetElemAttr("data.fluence_available_countries.test", "countries.*", "US"),
+					resource.TestCheckTypeSetElemAttr("data.fluence_available_countries.test", "countries.*", "CA"),
+				),
+			},
+		},
+	})
+}
+
+func TestAccAvailableCountriesDataSource_retriesExhausted(t *testing.T) {
+	srv := testAccServer(t)
+	srv.InjectFault(http.MethodGet, "/marketplace/countries", http.StatusServiceUnavailable, 10, 0)
+
+	resource.Test(t, resource.TestCase{
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		Steps: []resource.TestStep{
+			{
+				Config:      testAccProviderConfig(srv, "retry_max_attempts = 2") + `data "fluence_available_countries" "test" {}`,
+				ExpectError: regexp.MustCompile(`Unable to read available countries`),
+			},
+		},
+	})
+}
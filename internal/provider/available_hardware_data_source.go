@@ -137,7 +137,7 @@ func (d *availableHardwareDataSource) Read(ctx context.Context, req datasource.R
 	tflog.Debug(ctx, "Fetching available hardware from marketplace")
 
 	// Call the API
-	hardware, err := d.client.GetAvailableHardware()
+	hardware, err := withContext(ctx, d.client).GetAvailableHardware()
 	if err != nil {
 		resp.Diagnostics.AddError("Client Error", fmt.Sprintf("Unable to read available hardware, got error: %s", err))
 		return
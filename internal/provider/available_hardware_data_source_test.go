@@ -0,0 +1,36 @@
+package provider
+
+import (
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-testing/helper/resource"
+)
+
+func TestAccAvailableHardwareDataSource(t *testing.T) {
+	srv := testAccServer(t)
+
+	resource.Test(t, resource.TestCase{
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		Steps: []resource.TestStep{
+			{
+				Config: testAccProviderConfig(srv) + `data "fluence_available_hardware" "test" {}`,
+				Check: resource.ComposeAggregateTestCheckFunc(
+					resource.TestCheckResourceAttr("data.fluence_available_hardware.test", "cpu.#", "3"),
+					resource.TestCheckTypeSetElemNestedAttrs("data.fluence_available_hardware.test", "cpu.*", map[string]string{
+						"architecture": "arm64",
+						"manufacturer": "Ampere",
+					}),
+					resource.TestCheckResourceAttr("data.fluence_available_hardware.test", "memory.#", "2"),
+					resource.TestCheckTypeSetElemNestedAttrs("data.fluence_available_hardware.test", "memory.*", map[string]string{
+						"type":       "DDR5",
+						"generation": "5",
+					}),
+					resource.TestCheckResourceAttr("data.fluence_available_hardware.test", "storage.#", "3"),
+					resource.TestCheckTypeSetElemNestedAttrs("data.fluence_available_hardware.test", "storage.*", map[string]string{
+						"type": "NVMe",
+					}),
+				),
+			},
+		},
+	})
+}
@@ -0,0 +1,159 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	fluenceapi "github.com/decentralized-infrastructure/fluence-api-client-go"
+	"github.com/hashicorp/terraform-plugin-framework-validators/int64validator"
+	"github.com/hashicorp/terraform-plugin-framework/datasource"
+	"github.com/hashicorp/terraform-plugin-framework/datasource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/schema/validator"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+)
+
+// Ensure provider defined types fully satisfy framework interfaces.
+var _ datasource.DataSource = &basicConfigurationDataSource{}
+
+func NewBasicConfigurationDataSource() datasource.DataSource {
+	return &basicConfigurationDataSource{}
+}
+
+// basicConfigurationDataSource picks the smallest basic configuration that
+// satisfies minimum resource sizes.
+type basicConfigurationDataSource struct {
+	client *fluenceapi.Client
+}
+
+// BasicConfigurationDataSourceModel describes the data source data model.
+type BasicConfigurationDataSourceModel struct {
+	MinVcpu       types.Int64 `tfsdk:"min_vcpu"`
+	MinRamMib     types.Int64 `tfsdk:"min_ram_mib"`
+	MinStorageGib types.Int64 `tfsdk:"min_storage_gib"`
+
+	BasicConfigurationModel
+}
+
+func (d *basicConfigurationDataSource) Metadata(_ context.Context, req datasource.MetadataRequest, resp *datasource.MetadataResponse) {
+	resp.TypeName = req.ProviderTypeName + "_basic_configuration"
+}
+
+func (d *basicConfigurationDataSource) Schema(_ context.Context, _ datasource.SchemaRequest, resp *datasource.SchemaResponse) {
+	attributes := basicConfigurationAttributes()
+	attributes["min_vcpu"] = schema.Int64Attribute{
+		MarkdownDescription: "Minimum number of vCPUs",
+		Optional:            true,
+		Validators: []validator.Int64{
+			int64validator.AtLeast(1),
+		},
+	}
+	attributes["min_ram_mib"] = schema.Int64Attribute{
+		MarkdownDescription: "Minimum RAM in MiB (e.g., 8192 for 8 GB)",
+		Optional:            true,
+		Validators: []validator.Int64{
+			int64validator.AtLeast(1),
+		},
+	}
+	attributes["min_storage_gib"] = schema.Int64Attribute{
+		MarkdownDescription: "Minimum storage in GiB",
+		Optional:            true,
+		Validators: []validator.Int64{
+			int64validator.AtLeast(1),
+		},
+	}
+
+	resp.Schema = schema.Schema{
+		MarkdownDescription: "Find the smallest available basic VM configuration with at least the given resources. Configurations are ordered by vCPU count, then RAM, then storage",
+
+		Attributes: attributes,
+	}
+}
+
+func (d *basicConfigurationDataSource) Configure(ctx context.Context, req datasource.ConfigureRequest, resp *datasource.ConfigureResponse) {
+	// Prevent panic if the provider has not been configured.
+	if req.ProviderData == nil {
+		return
+	}
+
+	client, ok := req.ProviderData.(*fluenceapi.Client)
+
+	if !ok {
+		resp.Diagnostics.AddError(
+			"Unexpected Data Source Configure Type",
+			fmt.Sprintf("Expected *fluenceapi.Client, got: %T. Please report this issue to the provider developers.", req.ProviderData),
+		)
+
+		return
+	}
+
+	d.client = client
+}
+
+func (d *basicConfigurationDataSource) Read(ctx context.Context, req datasource.ReadRequest, resp *datasource.ReadResponse) {
+	var data BasicConfigurationDataSourceModel
+
+	// Read Terraform configuration data into the model
+	resp.Diagnostics.Append(req.Config.Get(ctx, &data)...)
+
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	configs, err := withContext(ctx, d.client).GetBasicConfigurations()
+	if err != nil {
+		resp.Diagnostics.AddError("Client Error", fmt.Sprintf("Unable to read basic configurations, got error: %s", err))
+		return
+	}
+
+	candidates := []*basicConfiguration{}
+	for _, config := range configs {
+		parsed, err := parseBasicConfiguration(config)
+		if err != nil {
+			tflog.Warn(ctx, "Skipping basic configuration", map[string]interface{}{
+				"error": err.Error(),
+			})
+			continue
+		}
+
+		if parsed.Vcpu < data.MinVcpu.ValueInt64() ||
+			parsed.RamMib < data.MinRamMib.ValueInt64() ||
+			parsed.StorageMib < data.MinStorageGib.ValueInt64()*1024 {
+			continue
+		}
+		candidates = append(candidates, parsed)
+	}
+
+	if len(candidates) == 0 {
+		resp.Diagnostics.AddError(
+			"No Matching Basic Configuration",
+			fmt.Sprintf("None of the available basic configurations has at least %d vCPU(s), %d MiB of RAM and %d GiB of storage. Available configurations: %s.",
+				data.MinVcpu.ValueInt64(), data.MinRamMib.ValueInt64(), data.MinStorageGib.ValueInt64(), strings.Join(configs, ", ")),
+		)
+		return
+	}
+
+	sort.SliceStable(candidates, func(i, j int) bool {
+		a, b := candidates[i], candidates[j]
+		if a.Vcpu != b.Vcpu {
+			return a.Vcpu < b.Vcpu
+		}
+		if a.RamMib != b.RamMib {
+			return a.RamMib < b.RamMib
+		}
+		return a.StorageMib < b.StorageMib
+	})
+
+	smallest := candidates[0]
+	tflog.Debug(ctx, "Selected basic configuration", map[string]interface{}{
+		"slug":       smallest.Slug,
+		"candidates": len(candidates),
+	})
+
+	data.BasicConfigurationModel = smallest.model()
+
+	// Save data into Terraform state
+	resp.Diagnostics.Append(resp.State.Set(ctx, &data)...)
+}
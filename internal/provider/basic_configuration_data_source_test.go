@@ -0,0 +1,69 @@
+package provider
+
+import (
+	"fmt"
+	"regexp"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-testing/helper/resource"
+
+	"terraform-provider-fluence/internal/fakeapi"
+)
+
+func TestAccBasicConfigurationDataSource(t *testing.T) {
+	fixtures := fakeapi.DefaultFixtures()
+	fixtures.Configurations = append(fixtures.Configurations,
+		fakeapi.Configuration{Slug: "cpu-2-ram-4gb-storage-512mb", Price: "1"},
+		fakeapi.Configuration{Slug: "gpu-a100", Price: "20"},
+	)
+	srv := testAccServer(t, fakeapi.WithFixtures(fixtures))
+
+	resource.Test(t, resource.TestCase{
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		Steps: []resource.TestStep{
+			// Without minimums the smallest configuration is selected, and
+			// storage below a GiB counts as less than a whole GiB
+			{
+				Config: testAccBasicConfigurationConfig(srv, ""),
+				Check: resource.ComposeAggregateTestCheckFunc(
+					resource.TestCheckResourceAttr("data.fluence_basic_configuration.test", "slug", "cpu-2-ram-4gb-storage-512mb"),
+					resource.TestCheckResourceAttr("data.fluence_basic_configuration.test", "storage_gib", "1"),
+				),
+			},
+			{
+				Config: testAccBasicConfigurationConfig(srv, "min_storage_gib = 1"),
+				Check:  resource.TestCheckResourceAttr("data.fluence_basic_configuration.test", "slug", "cpu-2-ram-4gb-storage-25gb"),
+			},
+			{
+				Config: testAccBasicConfigurationConfig(srv, "min_vcpu = 3"),
+				Check: resource.ComposeAggregateTestCheckFunc(
+					resource.TestCheckResourceAttr("data.fluence_basic_configuration.test", "slug", "cpu-4-ram-8gb-storage-25gb"),
+					resource.TestCheckResourceAttr("data.fluence_basic_configuration.test", "vcpu", "4"),
+					resource.TestCheckResourceAttr("data.fluence_basic_configuration.test", "ram_mib", "8192"),
+					resource.TestCheckResourceAttr("data.fluence_basic_configuration.test", "storage_gib", "25"),
+				),
+			},
+			// Among configurations with as many vCPUs, the least RAM wins
+			{
+				Config: testAccBasicConfigurationConfig(srv, "min_vcpu = 8"),
+				Check:  resource.TestCheckResourceAttr("data.fluence_basic_configuration.test", "slug", "cpu-8-ram-16gb-storage-50gb"),
+			},
+			{
+				Config: testAccBasicConfigurationConfig(srv, "min_ram_mib = 20000\nmin_storage_gib = 60"),
+				Check:  resource.TestCheckResourceAttr("data.fluence_basic_configuration.test", "slug", "cpu-8-ram-32gb-storage-100gb"),
+			},
+			{
+				Config:      testAccBasicConfigurationConfig(srv, "min_vcpu = 16"),
+				ExpectError: regexp.MustCompile(`No Matching Basic Configuration`),
+			},
+		},
+	})
+}
+
+func testAccBasicConfigurationConfig(srv *fakeapi.Server, arguments string) string {
+	return testAccProviderConfig(srv) + fmt.Sprintf(`
+data "fluence_basic_configuration" "test" {
+%s
+}
+`, arguments)
+}
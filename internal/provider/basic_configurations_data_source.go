@@ -3,6 +3,9 @@ package provider
 import (
 	"context"
 	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
 
 	fluenceapi "github.com/decentralized-infrastructure/fluence-api-client-go"
 	"github.com/hashicorp/terraform-plugin-framework/datasource"
@@ -25,7 +28,96 @@ type basicConfigurationsDataSource struct {
 
 // BasicConfigurationsDataSourceModel describes the data source data model.
 type BasicConfigurationsDataSourceModel struct {
-	Configurations []types.String `tfsdk:"configurations"`
+	Configurations []types.String            `tfsdk:"configurations"`
+	Details        []BasicConfigurationModel `tfsdk:"details"`
+}
+
+// BasicConfigurationModel describes the resources of a basic configuration.
+type BasicConfigurationModel struct {
+	Slug       types.String `tfsdk:"slug"`
+	Vcpu       types.Int64  `tfsdk:"vcpu"`
+	RamMib     types.Int64  `tfsdk:"ram_mib"`
+	StorageGib types.Int64  `tfsdk:"storage_gib"`
+}
+
+// basicConfigurationPattern matches slugs such as cpu-8-ram-16gb-storage-50gb.
+var basicConfigurationPattern = regexp.MustCompile(`^cpu-([0-9]+)-ram-([0-9]+)(mb|gb|tb)-storage-([0-9]+)(mb|gb|tb)$`)
+
+// basicConfiguration holds the sizes parsed from a basic configuration slug.
+type basicConfiguration struct {
+	Slug       string
+	Vcpu       int64
+	RamMib     int64
+	StorageMib int64
+	StorageGib int64
+}
+
+// parseBasicConfiguration parses a slug such as cpu-8-ram-16gb-storage-50gb.
+// Sizes in the slugs are binary: 1gb is 1024 MiB.
+func parseBasicConfiguration(slug string) (*basicConfiguration, error) {
+	m := basicConfigurationPattern.FindStringSubmatch(strings.ToLower(slug))
+	if m == nil {
+		return nil, fmt.Errorf("basic configuration %q does not match cpu-<count>-ram-<size>-storage-<size>", slug)
+	}
+
+	// The pattern only matches digits, so the numbers only fail to parse
+	// when they overflow
+	vcpu, err := strconv.ParseInt(m[1], 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("basic configuration %q: invalid cpu count: %w", slug, err)
+	}
+	ram, err := strconv.ParseInt(m[2], 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("basic configuration %q: invalid ram size: %w", slug, err)
+	}
+	storage, err := strconv.ParseInt(m[4], 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("basic configuration %q: invalid storage size: %w", slug, err)
+	}
+
+	// Storage is reported rounded up to a whole GiB, so that storage-512mb
+	// is not reported as no storage at all
+	mib := map[string]int64{"mb": 1, "gb": 1024, "tb": 1024 * 1024}
+	storageMib := storage * mib[m[5]]
+	return &basicConfiguration{
+		Slug:       slug,
+		Vcpu:       vcpu,
+		RamMib:     ram * mib[m[3]],
+		StorageMib: storageMib,
+		StorageGib: (storageMib + 1023) / 1024,
+	}, nil
+}
+
+// model maps the configuration to BasicConfigurationModel.
+func (c *basicConfiguration) model() BasicConfigurationModel {
+	return BasicConfigurationModel{
+		Slug:       types.StringValue(c.Slug),
+		Vcpu:       types.Int64Value(c.Vcpu),
+		RamMib:     types.Int64Value(c.RamMib),
+		StorageGib: types.Int64Value(c.StorageGib),
+	}
+}
+
+// basicConfigurationAttributes returns the schema of BasicConfigurationModel.
+func basicConfigurationAttributes() map[string]schema.Attribute {
+	return map[string]schema.Attribute{
+		"slug": schema.StringAttribute{
+			MarkdownDescription: "Basic configuration, as used in `basic_configuration` constraints",
+			Computed:            true,
+		},
+		"vcpu": schema.Int64Attribute{
+			MarkdownDescription: "Number of vCPUs",
+			Computed:            true,
+		},
+		"ram_mib": schema.Int64Attribute{
+			MarkdownDescription: "RAM in MiB",
+			Computed:            true,
+		},
+		"storage_gib": schema.Int64Attribute{
+			MarkdownDescription: "Storage in GiB, rounded up",
+			Computed:            true,
+		},
+	}
 }
 
 func (d *basicConfigurationsDataSource) Metadata(_ context.Context, req datasource.MetadataRequest, resp *datasource.MetadataResponse) {
@@ -42,6 +134,13 @@ func (d *basicConfigurationsDataSource) Schema(_ context.Context, _ datasource.S
 				ElementType:         types.StringType,
 				Computed:            true,
 			},
+			"details": schema.ListNestedAttribute{
+				MarkdownDescription: "Resources of each configuration in `configurations`, parsed from its name. Configurations whose name cannot be parsed are left out",
+				Computed:            true,
+				NestedObject: schema.NestedAttributeObject{
+					Attributes: basicConfigurationAttributes(),
+				},
+			},
 		},
 	}
 }
@@ -79,7 +178,7 @@ func (d *basicConfigurationsDataSource) Read(ctx context.Context, req datasource
 	tflog.Debug(ctx, "Fetching basic configurations from marketplace")
 
 	// Call the API
-	configs, err := d.client.GetBasicConfigurations()
+	configs, err := withContext(ctx, d.client).GetBasicConfigurations()
 	if err != nil {
 		resp.Diagnostics.AddError("Client Error", fmt.Sprintf("Unable to read basic configurations, got error: %s", err))
 		return
@@ -92,8 +191,18 @@ func (d *basicConfigurationsDataSource) Read(ctx context.Context, req datasource
 
 	// Map response to the model
 	data.Configurations = make([]types.String, len(configs))
+	data.Details = []BasicConfigurationModel{}
 	for i, config := range configs {
 		data.Configurations[i] = types.StringValue(config)
+
+		parsed, err := parseBasicConfiguration(config)
+		if err != nil {
+			tflog.Warn(ctx, "Skipping basic configuration", map[string]interface{}{
+				"error": err.Error(),
+			})
+			continue
+		}
+		data.Details = append(data.Details, parsed.model())
 	}
 
 	// Save data into Terraform state
@@ -0,0 +1,98 @@
+package provider
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-testing/helper/resource"
+
+	"terraform-provider-fluence/internal/fakeapi"
+)
+
+func TestAccBasicConfigurationsDataSource(t *testing.T) {
+	fixtures := fakeapi.DefaultFixtures()
+	fixtures.Configurations = append(fixtures.Configurations,
+		fakeapi.Configuration{Slug: "cpu-1-ram-512mb-storage-512mb", Price: "0.5"},
+		fakeapi.Configuration{Slug: "gpu-a100", Price: "20"},
+	)
+	srv := testAccServer(t, fakeapi.WithFixtures(fixtures))
+
+	resource.Test(t, resource.TestCase{
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		Steps: []resource.TestStep{
+			{
+				Config: testAccProviderConfig(srv) + `data "fluence_basic_configurations" "test" {}`,
+				Check: resource.ComposeAggregateTestCheckFunc(
+					// Every slug is listed, but only the parsable ones are detailed
+					resource.TestCheckResourceAttr("data.fluence_basic_configurations.test", "configurations.#", "6"),
+					resource.TestCheckTypeSetElemAttr("data.fluence_basic_configurations.test", "configurations.*", "gpu-a100"),
+					resource.TestCheckResourceAttr("data.fluence_basic_configurations.test", "details.#", "5"),
+					resource.TestCheckTypeSetElemNestedAttrs("data.fluence_basic_configurations.test", "details.*", map[string]string{
+						"slug":        "cpu-8-ram-16gb-storage-50gb",
+						"vcpu":        "8",
+						"ram_mib":     "16384",
+						"storage_gib": "50",
+					}),
+					resource.TestCheckTypeSetElemNestedAttrs("data.fluence_basic_configurations.test", "details.*", map[string]string{
+						"slug":        "cpu-1-ram-512mb-storage-512mb",
+						"vcpu":        "1",
+						"ram_mib":     "512",
+						"storage_gib": "1",
+					}),
+				),
+			},
+		},
+	})
+}
+
+func TestParseBasicConfiguration(t *testing.T) {
+	tests := []struct {
+		slug     string
+		expected *basicConfiguration
+	}{
+		{
+			slug:     "cpu-8-ram-16gb-storage-50gb",
+			expected: &basicConfiguration{Slug: "cpu-8-ram-16gb-storage-50gb", Vcpu: 8, RamMib: 16384, StorageMib: 51200, StorageGib: 50},
+		},
+		{
+			slug:     "cpu-2-ram-512mb-storage-1tb",
+			expected: &basicConfiguration{Slug: "cpu-2-ram-512mb-storage-1tb", Vcpu: 2, RamMib: 512, StorageMib: 1048576, StorageGib: 1024},
+		},
+		// Storage below a GiB is rounded up rather than reported as none
+		{
+			slug:     "cpu-1-ram-1gb-storage-512mb",
+			expected: &basicConfiguration{Slug: "cpu-1-ram-1gb-storage-512mb", Vcpu: 1, RamMib: 1024, StorageMib: 512, StorageGib: 1},
+		},
+		{
+			slug:     "cpu-1-ram-1gb-storage-1025mb",
+			expected: &basicConfiguration{Slug: "cpu-1-ram-1gb-storage-1025mb", Vcpu: 1, RamMib: 1024, StorageMib: 1025, StorageGib: 2},
+		},
+		// The slug is kept as returned by the API
+		{
+			slug:     "CPU-4-RAM-8GB-STORAGE-25GB",
+			expected: &basicConfiguration{Slug: "CPU-4-RAM-8GB-STORAGE-25GB", Vcpu: 4, RamMib: 8192, StorageMib: 25600, StorageGib: 25},
+		},
+		{slug: "gpu-a100"},
+		{slug: "cpu-8-ram-16gb"},
+		{slug: "cpu-8-ram-16pb-storage-50gb"},
+		{slug: "cpu-99999999999999999999-ram-16gb-storage-50gb"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.slug, func(t *testing.T) {
+			parsed, err := parseBasicConfiguration(test.slug)
+			if test.expected == nil {
+				if err == nil {
+					t.Fatalf("expected an error, got %+v", parsed)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			if !reflect.DeepEqual(parsed, test.expected) {
+				t.Errorf("parseBasicConfiguration(%q) = %+v, want %+v", test.slug, parsed, test.expected)
+			}
+		})
+	}
+}
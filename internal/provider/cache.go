@@ -0,0 +1,100 @@
+package provider
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	fluenceapi "github.com/decentralized-infrastructure/fluence-api-client-go"
+)
+
+// apiCacheTTL is how long a listing stays valid. It only needs to cover the
+// burst of concurrent reads Terraform performs during one plan or refresh.
+const apiCacheTTL = 10 * time.Second
+
+// apiCache shares short-lived snapshots of the VM and SSH key lists between
+// all resources of one provider instance, so refreshing N resources does not
+// download the same list N times. Operations that change VMs or SSH keys must
+// invalidate the corresponding list.
+type apiCache struct {
+	vms     *cachedList[fluenceapi.RunningInstanceV3]
+	sshKeys *cachedList[fluenceapi.SshKey]
+}
+
+// newApiCache creates an empty cache backed by client.
+func newApiCache(client *fluenceapi.Client, ttl time.Duration) *apiCache {
+	return &apiCache{
+		vms: &cachedList[fluenceapi.RunningInstanceV3]{
+			ttl: ttl,
+			fetch: func(ctx context.Context) ([]fluenceapi.RunningInstanceV3, error) {
+				return withContext(ctx, client).ListVmsV3()
+			},
+		},
+		sshKeys: &cachedList[fluenceapi.SshKey]{
+			ttl: ttl,
+			fetch: func(ctx context.Context) ([]fluenceapi.SshKey, error) {
+				return withContext(ctx, client).ListSshKeys()
+			},
+		},
+	}
+}
+
+// ListVms returns the cached VM list, fetching it if it is missing or expired.
+func (c *apiCache) ListVms(ctx context.Context) ([]fluenceapi.RunningInstanceV3, error) {
+	return c.vms.get(ctx)
+}
+
+// InvalidateVms drops the cached VM list.
+func (c *apiCache) InvalidateVms() {
+	c.vms.invalidate()
+}
+
+// ListSshKeys returns the cached SSH key list, fetching it if it is missing or expired.
+func (c *apiCache) ListSshKeys(ctx context.Context) ([]fluenceapi.SshKey, error) {
+	return c.sshKeys.get(ctx)
+}
+
+// InvalidateSshKeys drops the cached SSH key list.
+func (c *apiCache) InvalidateSshKeys() {
+	c.sshKeys.invalidate()
+}
+
+// cachedList is a concurrency-safe snapshot of one API listing. Concurrent
+// callers that find the snapshot expired wait for a single fetch instead of
+// each issuing their own, made with the context of the caller that started
+// it. Errors are not cached.
+type cachedList[T any] struct {
+	mu        sync.Mutex
+	ttl       time.Duration
+	fetch     func(ctx context.Context) ([]T, error)
+	items     []T
+	fetchedAt time.Time
+	valid     bool
+}
+
+func (l *cachedList[T]) get(ctx context.Context) ([]T, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.valid && time.Since(l.fetchedAt) < l.ttl {
+		return l.items, nil
+	}
+
+	items, err := l.fetch(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	l.items = items
+	l.fetchedAt = time.Now()
+	l.valid = true
+	return items, nil
+}
+
+func (l *cachedList[T]) invalidate() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.items = nil
+	l.valid = false
+}
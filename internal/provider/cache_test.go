@@ -0,0 +1,126 @@
+package provider
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	fluenceapi "github.com/decentralized-infrastructure/fluence-api-client-go"
+)
+
+func TestCachedList(t *testing.T) {
+	var fetches atomic.Int32
+	list := &cachedList[string]{
+		ttl: time.Hour,
+		fetch: func(context.Context) ([]string, error) {
+			fetches.Add(1)
+			return []string{"a"}, nil
+		},
+	}
+
+	// Concurrent readers share a single fetch
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			items, err := list.get(context.Background())
+			if err != nil || len(items) != 1 {
+				t.Errorf("get() = %v, %v", items, err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if n := fetches.Load(); n != 1 {
+		t.Errorf("fetches = %d, want 1", n)
+	}
+
+	list.invalidate()
+	if _, err := list.get(context.Background()); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if n := fetches.Load(); n != 2 {
+		t.Errorf("fetches after invalidate = %d, want 2", n)
+	}
+}
+
+func TestCachedList_expired(t *testing.T) {
+	fetches := 0
+	list := &cachedList[string]{
+		ttl: time.Millisecond,
+		fetch: func(context.Context) ([]string, error) {
+			fetches++
+			return nil, nil
+		},
+	}
+
+	_, _ = list.get(context.Background())
+	time.Sleep(5 * time.Millisecond)
+	_, _ = list.get(context.Background())
+
+	if fetches != 2 {
+		t.Errorf("fetches = %d, want 2", fetches)
+	}
+}
+
+func TestCachedList_errorsNotCached(t *testing.T) {
+	fetches := 0
+	list := &cachedList[string]{
+		ttl: time.Hour,
+		fetch: func(context.Context) ([]string, error) {
+			fetches++
+			if fetches == 1 {
+				return nil, errors.New("unavailable")
+			}
+			return []string{"a"}, nil
+		},
+	}
+
+	if _, err := list.get(context.Background()); err == nil {
+		t.Fatal("expected an error")
+	}
+	items, err := list.get(context.Background())
+	if err != nil || len(items) != 1 {
+		t.Errorf("get() = %v, %v, want the fetched items", items, err)
+	}
+}
+
+func TestApiCache(t *testing.T) {
+	srv := testAccServer(t)
+	apiKey := testAccApiKey
+	client, err := fluenceapi.NewClient(&srv.URL, &apiKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cache := newApiCache(client, time.Hour)
+	ctx := context.Background()
+
+	for i := 0; i < 3; i++ {
+		if _, err := cache.ListVms(ctx); err != nil {
+			t.Fatalf("ListVms() error: %s", err)
+		}
+		if _, err := cache.ListSshKeys(ctx); err != nil {
+			t.Fatalf("ListSshKeys() error: %s", err)
+		}
+	}
+	cache.InvalidateVms()
+	if _, err := cache.ListVms(ctx); err != nil {
+		t.Fatalf("ListVms() error: %s", err)
+	}
+	cache.InvalidateSshKeys()
+	if _, err := cache.ListSshKeys(ctx); err != nil {
+		t.Fatalf("ListSshKeys() error: %s", err)
+	}
+
+	counts := map[string]int{}
+	for _, req := range srv.Requests() {
+		counts[req.Method+" "+req.Path]++
+	}
+	if counts["GET /vms/v3"] != 2 || counts["GET /ssh_keys"] != 2 {
+		t.Errorf("requests = %v, want 2 VM and 2 SSH key listings", counts)
+	}
+}
@@ -0,0 +1,488 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+
+	fluenceapi "github.com/decentralized-infrastructure/fluence-api-client-go"
+	"github.com/hashicorp/terraform-plugin-framework-validators/int64validator"
+	"github.com/hashicorp/terraform-plugin-framework-validators/listvalidator"
+	"github.com/hashicorp/terraform-plugin-framework-validators/stringvalidator"
+	datasourceschema "github.com/hashicorp/terraform-plugin-framework/datasource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/planmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/schema/validator"
+	"github.com/hashicorp/terraform-plugin-framework/tfsdk"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-go/tftypes"
+)
+
+// Storage types and units accepted by the Fluence API
+var (
+	storageTypes = []string{fluenceapi.StorageTypeHDD, fluenceapi.StorageTypeSSD, fluenceapi.StorageTypeNVMe}
+	storageUnits = []string{"MiB", "GiB", "TiB", "MB", "GB", "TB"}
+)
+
+// ConstraintsModel describes the marketplace constraints shared by the VM
+// resources and the deposit estimate, so that an estimate covers the same
+// offers as the VMs it is made for.
+type ConstraintsModel struct {
+	BasicConfiguration       types.String              `tfsdk:"basic_configuration"`
+	MaxTotalPricePerEpochUsd types.String              `tfsdk:"max_total_price_per_epoch_usd"`
+	Countries                []types.String            `tfsdk:"datacenter_countries"`
+	HardwareConstraints      []HardwareConstraintModel `tfsdk:"hardware_constraints"`
+	AdditionalResources      []AdditionalResourceModel `tfsdk:"additional_resources"`
+}
+
+// HardwareConstraintModel represents hardware constraints, either as nested
+// objects or as flat lists of accepted values
+type HardwareConstraintModel struct {
+	Cpu     []CpuHardwareModel     `tfsdk:"cpu"`
+	Memory  []MemoryHardwareModel  `tfsdk:"memory"`
+	Storage []StorageHardwareModel `tfsdk:"storage"`
+
+	CpuArchitecture  []types.String `tfsdk:"cpu_architecture"`
+	CpuManufacturer  []types.String `tfsdk:"cpu_manufacturer"`
+	MemoryType       []types.String `tfsdk:"memory_type"`
+	MemoryGeneration []types.String `tfsdk:"memory_generation"`
+	StorageType      []types.String `tfsdk:"storage_type"`
+}
+
+// AdditionalResourceModel represents additional resources
+type AdditionalResourceModel struct {
+	Storage []AdditionalStorageModel `tfsdk:"storage"`
+}
+
+// AdditionalStorageModel represents additional storage resources
+type AdditionalStorageModel struct {
+	Supply types.Int64  `tfsdk:"supply"`
+	Units  types.String `tfsdk:"units"`
+	Type   types.String `tfsdk:"type"`
+}
+
+// constraintAttributes is the single definition of the placement constraint
+// attributes. vmConstraintAttributes adds the replacement behavior of the VM
+// resources and constraintDataSourceAttributes converts them for the data
+// sources, so that the same constraints are accepted everywhere.
+func constraintAttributes() map[string]schema.Attribute {
+	return map[string]schema.Attribute{
+		"basic_configuration": schema.StringAttribute{
+			MarkdownDescription: "Basic configuration constraint",
+			Optional:            true,
+		},
+		"max_total_price_per_epoch_usd": schema.StringAttribute{
+			MarkdownDescription: "Maximum total price per epoch in USD",
+			Optional:            true,
+			Validators: []validator.String{
+				stringvalidator.RegexMatches(decimalPattern, "must be a decimal amount such as \"1.5\""),
+			},
+		},
+		"datacenter_countries": schema.ListAttribute{
+			MarkdownDescription: "List of allowed datacenter countries as ISO 3166-1 alpha-2 codes (e.g., US, DE)",
+			ElementType:         types.StringType,
+			Optional:            true,
+			Validators: []validator.List{
+				listvalidator.ValueStringsAre(countryCodeValidator{}),
+			},
+		},
+		"hardware_constraints": schema.ListNestedAttribute{
+			MarkdownDescription: "Hardware constraints for VM placement. Each entry accepts nested `cpu`, `memory` and `storage` objects and flat lists of accepted values, which are all combined into one set of accepted hardware",
+			Optional:            true,
+			NestedObject: schema.NestedAttributeObject{
+				Attributes: map[string]schema.Attribute{
+					"cpu": schema.ListNestedAttribute{
+						MarkdownDescription: "CPU hardware constraints",
+						Optional:            true,
+						NestedObject: schema.NestedAttributeObject{
+							Attributes: map[string]schema.Attribute{
+								"architecture": schema.StringAttribute{
+									MarkdownDescription: "CPU architecture (e.g., x86_64, arm64). Any architecture matches when omitted",
+									Optional:            true,
+									Validators: []validator.String{
+										stringvalidator.AtLeastOneOf(path.MatchRelative().AtParent().AtName("manufacturer")),
+									},
+								},
+								"manufacturer": schema.StringAttribute{
+									MarkdownDescription: "CPU manufacturer (e.g., Intel, AMD). Any manufacturer matches when omitted",
+									Optional:            true,
+								},
+							},
+						},
+					},
+					"memory": schema.ListNestedAttribute{
+						MarkdownDescription: "Memory hardware constraints",
+						Optional:            true,
+						NestedObject: schema.NestedAttributeObject{
+							Attributes: map[string]schema.Attribute{
+								"type": schema.StringAttribute{
+									MarkdownDescription: "Memory type (e.g., DDR4, DDR5). Any type matches when omitted",
+									Optional:            true,
+									Validators: []validator.String{
+										stringvalidator.AtLeastOneOf(path.MatchRelative().AtParent().AtName("generation")),
+									},
+								},
+								"generation": schema.StringAttribute{
+									MarkdownDescription: "Memory generation. Any generation matches when omitted",
+									Optional:            true,
+								},
+							},
+						},
+					},
+					"storage": schema.ListNestedAttribute{
+						MarkdownDescription: "Storage hardware constraints",
+						Optional:            true,
+						NestedObject: schema.NestedAttributeObject{
+							Attributes: map[string]schema.Attribute{
+								"type": schema.StringAttribute{
+									MarkdownDescription: "Storage type (HDD, SSD, NVMe)",
+									Required:            true,
+									Validators: []validator.String{
+										stringvalidator.OneOf(storageTypes...),
+									},
+								},
+							},
+						},
+					},
+					"cpu_architecture": schema.ListAttribute{
+						MarkdownDescription: "CPU architectures to accept. Combined with every `cpu_manufacturer` when both are set",
+						ElementType:         types.StringType,
+						Optional:            true,
+					},
+					"cpu_manufacturer": schema.ListAttribute{
+						MarkdownDescription: "CPU manufacturers to accept. Combined with every `cpu_architecture` when both are set",
+						ElementType:         types.StringType,
+						Optional:            true,
+					},
+					"memory_type": schema.ListAttribute{
+						MarkdownDescription: "Memory types to accept. Combined with every `memory_generation` when both are set",
+						ElementType:         types.StringType,
+						Optional:            true,
+					},
+					"memory_generation": schema.ListAttribute{
+						MarkdownDescription: "Memory generations to accept. Combined with every `memory_type` when both are set",
+						ElementType:         types.StringType,
+						Optional:            true,
+					},
+					"storage_type": schema.ListAttribute{
+						MarkdownDescription: "Storage types to accept (HDD, SSD, NVMe)",
+						ElementType:         types.StringType,
+						Optional:            true,
+						Validators: []validator.List{
+							listvalidator.ValueStringsAre(stringvalidator.OneOf(storageTypes...)),
+						},
+					},
+				},
+			},
+		},
+		"additional_resources": schema.ListNestedAttribute{
+			MarkdownDescription: "Additional resources to be allocated",
+			Optional:            true,
+			NestedObject: schema.NestedAttributeObject{
+				Attributes: map[string]schema.Attribute{
+					"storage": schema.ListNestedAttribute{
+						MarkdownDescription: "Additional storage resources",
+						Optional:            true,
+						NestedObject: schema.NestedAttributeObject{
+							Attributes: map[string]schema.Attribute{
+								"supply": schema.Int64Attribute{
+									MarkdownDescription: "Amount of storage to allocate",
+									Required:            true,
+									Validators: []validator.Int64{
+										int64validator.AtLeast(1),
+									},
+								},
+								"units": schema.StringAttribute{
+									MarkdownDescription: "Storage units (MiB, GiB, TiB, MB, GB, TB)",
+									Required:            true,
+									Validators: []validator.String{
+										stringvalidator.OneOf(storageUnits...),
+									},
+								},
+								"type": schema.StringAttribute{
+									MarkdownDescription: "Storage type (HDD, SSD, NVMe)",
+									Required:            true,
+									Validators: []validator.String{
+										stringvalidator.OneOf(storageTypes...),
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+// replacingConstraints lists the constraints whose change replaces the VMs.
+var replacingConstraints = []string{
+	"basic_configuration",
+	"datacenter_countries",
+	"hardware_constraints",
+	"additional_resources",
+}
+
+// vmConstraintAttributes returns the constraint attributes of the VM
+// resources. Apart from the price cap, changing a constraint replaces the VMs.
+func vmConstraintAttributes() map[string]schema.Attribute {
+	attributes := constraintAttributes()
+
+	price := attributes["max_total_price_per_epoch_usd"].(schema.StringAttribute)
+	price.MarkdownDescription += ". Updated in place: the new value only applies to instances created by later scale-ups"
+	attributes["max_total_price_per_epoch_usd"] = price
+
+	for _, name := range replacingConstraints {
+		switch attribute := attributes[name].(type) {
+		case schema.StringAttribute:
+			attribute.MarkdownDescription += ". Changing this forces a new resource to be created"
+			attribute.PlanModifiers = []planmodifier.String{stringRequiresReplaceUnlessImported()}
+			attributes[name] = attribute
+		case schema.ListAttribute:
+			attribute.MarkdownDescription += ". Changing this forces a new resource to be created"
+			attribute.PlanModifiers = []planmodifier.List{listRequiresReplaceUnlessImported()}
+			attributes[name] = attribute
+		case schema.ListNestedAttribute:
+			attribute.MarkdownDescription += ". Changing this forces a new resource to be created"
+			attribute.PlanModifiers = []planmodifier.List{listRequiresReplaceUnlessImported()}
+			attributes[name] = attribute
+		}
+	}
+
+	return attributes
+}
+
+// constraintDataSourceAttributes returns the constraint attributes for data
+// sources.
+func constraintDataSourceAttributes() map[string]datasourceschema.Attribute {
+	return dataSourceAttributes(constraintAttributes())
+}
+
+// dataSourceAttributes converts resource schema attributes without plan
+// modifiers or defaults to data source attributes. Only the attribute kinds
+// used by the constraints are supported.
+func dataSourceAttributes(attributes map[string]schema.Attribute) map[string]datasourceschema.Attribute {
+	converted := make(map[string]datasourceschema.Attribute, len(attributes))
+	for name, attribute := range attributes {
+		switch attribute := attribute.(type) {
+		case schema.StringAttribute:
+			converted[name] = datasourceschema.StringAttribute{
+				MarkdownDescription: attribute.MarkdownDescription,
+				Required:            attribute.Required,
+				Optional:            attribute.Optional,
+				Computed:            attribute.Computed,
+				Validators:          attribute.Validators,
+			}
+		case schema.Int64Attribute:
+			converted[name] = datasourceschema.Int64Attribute{
+				MarkdownDescription: attribute.MarkdownDescription,
+				Required:            attribute.Required,
+				Optional:            attribute.Optional,
+				Computed:            attribute.Computed,
+				Validators:          attribute.Validators,
+			}
+		case schema.ListAttribute:
+			converted[name] = datasourceschema.ListAttribute{
+				MarkdownDescription: attribute.MarkdownDescription,
+				ElementType:         attribute.ElementType,
+				Required:            attribute.Required,
+				Optional:            attribute.Optional,
+				Computed:            attribute.Computed,
+				Validators:          attribute.Validators,
+			}
+		case schema.ListNestedAttribute:
+			converted[name] = datasourceschema.ListNestedAttribute{
+				MarkdownDescription: attribute.MarkdownDescription,
+				Required:            attribute.Required,
+				Optional:            attribute.Optional,
+				Computed:            attribute.Computed,
+				Validators:          attribute.Validators,
+				NestedObject: datasourceschema.NestedAttributeObject{
+					Attributes: dataSourceAttributes(attribute.NestedObject.Attributes),
+				},
+			}
+		default:
+			panic(fmt.Sprintf("attribute %q of type %T cannot be converted to a data source attribute", name, attribute))
+		}
+	}
+	return converted
+}
+
+// plannedConstraints reads the constraints of a resource plan, or returns nil
+// when one of them is not known yet.
+func plannedConstraints(ctx context.Context, plan tfsdk.Plan) (*ConstraintsModel, diag.Diagnostics) {
+	var diags diag.Diagnostics
+
+	var planned map[string]tftypes.Value
+	if err := plan.Raw.As(&planned); err != nil {
+		diags.AddError("Plan Error", fmt.Sprintf("Unable to read the planned constraints, got error: %s", err))
+		return nil, diags
+	}
+	for name := range constraintAttributes() {
+		if !planned[name].IsFullyKnown() {
+			return nil, diags
+		}
+	}
+
+	var constraints ConstraintsModel
+	diags.Append(plan.GetAttribute(ctx, path.Root("basic_configuration"), &constraints.BasicConfiguration)...)
+	diags.Append(plan.GetAttribute(ctx, path.Root("max_total_price_per_epoch_usd"), &constraints.MaxTotalPricePerEpochUsd)...)
+	diags.Append(plan.GetAttribute(ctx, path.Root("datacenter_countries"), &constraints.Countries)...)
+	diags.Append(plan.GetAttribute(ctx, path.Root("hardware_constraints"), &constraints.HardwareConstraints)...)
+	diags.Append(plan.GetAttribute(ctx, path.Root("additional_resources"), &constraints.AdditionalResources)...)
+	if diags.HasError() {
+		return nil, diags
+	}
+
+	return &constraints, diags
+}
+
+// offerConstraints builds the marketplace constraints of the model, or nil
+// when none are set.
+func (m *ConstraintsModel) offerConstraints() *fluenceapi.OfferConstraints {
+	var constraints *fluenceapi.OfferConstraints
+	if !m.BasicConfiguration.IsNull() || !m.MaxTotalPricePerEpochUsd.IsNull() || len(m.Countries) > 0 || len(m.HardwareConstraints) > 0 || len(m.AdditionalResources) > 0 {
+		constraints = &fluenceapi.OfferConstraints{}
+
+		if !m.BasicConfiguration.IsNull() {
+			basicConfig := m.BasicConfiguration.ValueString()
+			constraints.BasicConfiguration = &basicConfig
+		}
+
+		if !m.MaxTotalPricePerEpochUsd.IsNull() {
+			maxPrice := m.MaxTotalPricePerEpochUsd.ValueString()
+			constraints.MaxTotalPricePerEpochUsd = &maxPrice
+		}
+
+		if len(m.Countries) > 0 {
+			countries := []string{}
+			for _, country := range m.Countries {
+				countries = append(countries, country.ValueString())
+			}
+			constraints.Datacenter = &fluenceapi.DatacenterConstraint{
+				Countries: countries,
+			}
+		}
+
+		// Build hardware constraints
+		if len(m.HardwareConstraints) > 0 {
+			hwConstraint := &fluenceapi.HardwareConstraints{}
+
+			for _, hwc := range m.HardwareConstraints {
+				// Convert CPU constraints
+				for _, cpu := range hwc.Cpu {
+					hwConstraint.Cpu = append(hwConstraint.Cpu, fluenceapi.CpuHardware{
+						Architecture: cpu.Architecture.ValueString(),
+						Manufacturer: cpu.Manufacturer.ValueString(),
+					})
+				}
+
+				// Convert memory constraints
+				for _, mem := range hwc.Memory {
+					hwConstraint.Memory = append(hwConstraint.Memory, fluenceapi.MemoryHardware{
+						Type:       mem.Type.ValueString(),
+						Generation: mem.Generation.ValueString(),
+					})
+				}
+
+				// Convert storage constraints
+				for _, storage := range hwc.Storage {
+					hwConstraint.Storage = append(hwConstraint.Storage, fluenceapi.StorageHardware{
+						Type: storage.Type.ValueString(),
+					})
+				}
+
+				// Convert flat lists
+				hwConstraint.Cpu = append(hwConstraint.Cpu, flatCpuHardware(hwc.CpuArchitecture, hwc.CpuManufacturer)...)
+				hwConstraint.Memory = append(hwConstraint.Memory, flatMemoryHardware(hwc.MemoryType, hwc.MemoryGeneration)...)
+				for _, storageType := range hwc.StorageType {
+					hwConstraint.Storage = append(hwConstraint.Storage, fluenceapi.StorageHardware{
+						Type: storageType.ValueString(),
+					})
+				}
+			}
+
+			constraints.Hardware = hwConstraint
+		}
+
+		// Build additional resources
+		if len(m.AdditionalResources) > 0 {
+			addlResources := &fluenceapi.AdditionalResources{}
+
+			for _, ar := range m.AdditionalResources {
+				for _, storage := range ar.Storage {
+					addlResources.Storage = append(addlResources.Storage, fluenceapi.AdditionalStorage{
+						Supply: uint64(storage.Supply.ValueInt64()),
+						Units:  storage.Units.ValueString(),
+						Type:   storage.Type.ValueString(),
+					})
+				}
+			}
+
+			constraints.AdditionalResources = addlResources
+		}
+	}
+
+	return constraints
+}
+
+// flatCpuHardware combines every architecture with every manufacturer. When
+// only one of the lists is set, the other field is left empty.
+func flatCpuHardware(architectures, manufacturers []types.String) []fluenceapi.CpuHardware {
+	var cpuHardware []fluenceapi.CpuHardware
+	switch {
+	case len(architectures) > 0 && len(manufacturers) > 0:
+		for _, arch := range architectures {
+			for _, mfr := range manufacturers {
+				cpuHardware = append(cpuHardware, fluenceapi.CpuHardware{
+					Architecture: arch.ValueString(),
+					Manufacturer: mfr.ValueString(),
+				})
+			}
+		}
+	case len(architectures) > 0:
+		for _, arch := range architectures {
+			cpuHardware = append(cpuHardware, fluenceapi.CpuHardware{
+				Architecture: arch.ValueString(),
+			})
+		}
+	default:
+		for _, mfr := range manufacturers {
+			cpuHardware = append(cpuHardware, fluenceapi.CpuHardware{
+				Manufacturer: mfr.ValueString(),
+			})
+		}
+	}
+	return cpuHardware
+}
+
+// flatMemoryHardware combines every memory type with every generation. When
+// only one of the lists is set, the other field is left empty.
+func flatMemoryHardware(memoryTypes, generations []types.String) []fluenceapi.MemoryHardware {
+	var memoryHardware []fluenceapi.MemoryHardware
+	switch {
+	case len(memoryTypes) > 0 && len(generations) > 0:
+		for _, memType := range memoryTypes {
+			for _, gen := range generations {
+				memoryHardware = append(memoryHardware, fluenceapi.MemoryHardware{
+					Type:       memType.ValueString(),
+					Generation: gen.ValueString(),
+				})
+			}
+		}
+	case len(memoryTypes) > 0:
+		for _, memType := range memoryTypes {
+			memoryHardware = append(memoryHardware, fluenceapi.MemoryHardware{
+				Type: memType.ValueString(),
+			})
+		}
+	default:
+		for _, gen := range generations {
+			memoryHardware = append(memoryHardware, fluenceapi.MemoryHardware{
+				Generation: gen.ValueString(),
+			})
+		}
+	}
+	return memoryHardware
+}
@@ -0,0 +1,95 @@
+package provider
+
+import (
+	"reflect"
+	"testing"
+
+	fluenceapi "github.com/decentralized-infrastructure/fluence-api-client-go"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+func TestOfferConstraints(t *testing.T) {
+	stringPtr := func(s string) *string {
+		return &s
+	}
+
+	tests := map[string]struct {
+		model ConstraintsModel
+		want  *fluenceapi.OfferConstraints
+	}{
+		"none": {
+			model: ConstraintsModel{BasicConfiguration: types.StringNull(), MaxTotalPricePerEpochUsd: types.StringNull()},
+			want:  nil,
+		},
+		"placement and price": {
+			model: ConstraintsModel{
+				BasicConfiguration:       types.StringValue("cpu-2-ram-4gb-storage-25gb"),
+				MaxTotalPricePerEpochUsd: types.StringValue("2.5"),
+				Countries:                []types.String{types.StringValue("DE"), types.StringValue("US")},
+			},
+			want: &fluenceapi.OfferConstraints{
+				BasicConfiguration:       stringPtr("cpu-2-ram-4gb-storage-25gb"),
+				MaxTotalPricePerEpochUsd: stringPtr("2.5"),
+				Datacenter:               &fluenceapi.DatacenterConstraint{Countries: []string{"DE", "US"}},
+			},
+		},
+		"hardware and storage": {
+			model: ConstraintsModel{
+				BasicConfiguration:       types.StringNull(),
+				MaxTotalPricePerEpochUsd: types.StringNull(),
+				HardwareConstraints: []HardwareConstraintModel{{
+					Cpu:     []CpuHardwareModel{{Architecture: types.StringValue("x86_64"), Manufacturer: types.StringValue("AMD")}},
+					Memory:  []MemoryHardwareModel{{Type: types.StringValue("DDR"), Generation: types.StringValue("5")}},
+					Storage: []StorageHardwareModel{{Type: types.StringValue("NVMe")}},
+				}},
+				AdditionalResources: []AdditionalResourceModel{{
+					Storage: []AdditionalStorageModel{{Supply: types.Int64Value(100), Units: types.StringValue("GiB"), Type: types.StringValue("SSD")}},
+				}},
+			},
+			want: &fluenceapi.OfferConstraints{
+				Hardware: &fluenceapi.HardwareConstraints{
+					Cpu:     []fluenceapi.CpuHardware{{Architecture: "x86_64", Manufacturer: "AMD"}},
+					Memory:  []fluenceapi.MemoryHardware{{Type: "DDR", Generation: "5"}},
+					Storage: []fluenceapi.StorageHardware{{Type: "NVMe"}},
+				},
+				AdditionalResources: &fluenceapi.AdditionalResources{
+					Storage: []fluenceapi.AdditionalStorage{{Supply: 100, Units: "GiB", Type: "SSD"}},
+				},
+			},
+		},
+		// Partial nested objects leave the other field empty, and the flat
+		// lists are combined with each other
+		"partial and flat hardware": {
+			model: ConstraintsModel{
+				BasicConfiguration:       types.StringNull(),
+				MaxTotalPricePerEpochUsd: types.StringNull(),
+				HardwareConstraints: []HardwareConstraintModel{{
+					Cpu:              []CpuHardwareModel{{Architecture: types.StringValue("arm64"), Manufacturer: types.StringNull()}},
+					CpuArchitecture:  []types.String{types.StringValue("x86_64")},
+					CpuManufacturer:  []types.String{types.StringValue("AMD"), types.StringValue("Intel")},
+					MemoryGeneration: []types.String{types.StringValue("5")},
+					StorageType:      []types.String{types.StringValue("SSD")},
+				}},
+			},
+			want: &fluenceapi.OfferConstraints{
+				Hardware: &fluenceapi.HardwareConstraints{
+					Cpu: []fluenceapi.CpuHardware{
+						{Architecture: "arm64"},
+						{Architecture: "x86_64", Manufacturer: "AMD"},
+						{Architecture: "x86_64", Manufacturer: "Intel"},
+					},
+					Memory:  []fluenceapi.MemoryHardware{{Generation: "5"}},
+					Storage: []fluenceapi.StorageHardware{{Type: "SSD"}},
+				},
+			},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			if got := test.model.offerConstraints(); !reflect.DeepEqual(got, test.want) {
+				t.Errorf("offerConstraints() = %+v, want %+v", got, test.want)
+			}
+		})
+	}
+}
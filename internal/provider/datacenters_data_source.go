@@ -120,7 +120,7 @@ func (d *datacentersDataSource) Read(ctx context.Context, req datasource.ReadReq
 	}
 
 	// Fetch datacenters from the API
-	datacenters, err := d.client.GetDatacenters()
+	datacenters, err := withContext(ctx, d.client).GetDatacenters()
 	if err != nil {
 		resp.Diagnostics.AddError("Client Error", fmt.Sprintf("Unable to read datacenters, got error: %s", err))
 		return
@@ -0,0 +1,38 @@
+package provider
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-testing/helper/resource"
+)
+
+func TestAccDatacentersDataSource(t *testing.T) {
+	srv := testAccServer(t)
+
+	resource.Test(t, resource.TestCase{
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		Steps: []resource.TestStep{
+			// A rate-limited request is retried
+			{
+				PreConfig: func() {
+					srv.InjectFault(http.MethodGet, "/v1/marketplace/datacenters", http.StatusTooManyRequests, 2, 0)
+				},
+				Config: testAccProviderConfig(srv) + `data "fluence_datacenters" "test" {}`,
+				Check: resource.ComposeAggregateTestCheckFunc(
+					resource.TestCheckResourceAttr("data.fluence_datacenters.test", "datacenters.#", "3"),
+					resource.TestCheckTypeSetElemNestedAttrs("data.fluence_datacenters.test", "datacenters.*", map[string]string{
+						"id":               "dc-de-fra-1",
+						"country_code":     "DE",
+						"city_code":        "FRA",
+						"index":            "1",
+						"tier":             "4",
+						"slug":             "de-fra-1",
+						"certifications.#": "1",
+						"certifications.0": "ISO 27001",
+					}),
+				),
+			},
+		},
+	})
+}
@@ -124,7 +124,7 @@ func (d *defaultImagesDataSource) Read(ctx context.Context, req datasource.ReadR
 	}
 
 	// Fetch default images from the API
-	images, err := d.client.GetDefaultImages()
+	images, err := withContext(ctx, d.client).GetDefaultImages()
 	if err != nil {
 		resp.Diagnostics.AddError("Client Error", fmt.Sprintf("Unable to read default images, got error: %s", err))
 		return
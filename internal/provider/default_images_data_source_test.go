@@ -0,0 +1,33 @@
+package provider
+
+import (
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-testing/helper/resource"
+)
+
+func TestAccDefaultImagesDataSource(t *testing.T) {
+	srv := testAccServer(t)
+
+	resource.Test(t, resource.TestCase{
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		Steps: []resource.TestStep{
+			{
+				Config: testAccProviderConfig(srv) + `data "fluence_default_images" "test" {}`,
+				Check: resource.ComposeAggregateTestCheckFunc(
+					resource.TestCheckResourceAttr("data.fluence_default_images.test", "images.#", "3"),
+					resource.TestCheckTypeSetElemNestedAttrs("data.fluence_default_images.test", "images.*", map[string]string{
+						"id":           "img-ubuntu-2404",
+						"name":         "Ubuntu 24.04 LTS",
+						"distribution": "Ubuntu",
+						"slug":         "ubuntu-24-04",
+						"download_url": testAccOsImage,
+						"username":     "ubuntu",
+						"created_at":   "2025-01-01T00:00:00Z",
+						"updated_at":   "2025-01-01T00:00:00Z",
+					}),
+				),
+			},
+		},
+	})
+}
@@ -5,8 +5,11 @@ import (
 	"fmt"
 
 	fluenceapi "github.com/decentralized-infrastructure/fluence-api-client-go"
+	"github.com/hashicorp/terraform-plugin-framework-validators/objectvalidator"
 	"github.com/hashicorp/terraform-plugin-framework/datasource"
 	"github.com/hashicorp/terraform-plugin-framework/datasource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/schema/validator"
 	"github.com/hashicorp/terraform-plugin-framework/types"
 	"github.com/hashicorp/terraform-plugin-log/tflog"
 )
@@ -25,8 +28,11 @@ type estimateDepositDataSource struct {
 
 // EstimateDepositDataSourceModel describes the data source data model.
 type EstimateDepositDataSourceModel struct {
-	// Input parameters
-	Instances   types.Int64                      `tfsdk:"instances"`
+	// Input parameters, with the same constraints as fluence_vm
+	Instances types.Int64 `tfsdk:"instances"`
+	ConstraintsModel
+
+	// Deprecated nested constraints
 	Constraints *EstimateDepositConstraintsModel `tfsdk:"constraints"`
 
 	// Output results
@@ -36,13 +42,13 @@ type EstimateDepositDataSourceModel struct {
 	MaxPricePerEpoch   types.String `tfsdk:"max_price_per_epoch"`
 }
 
-// EstimateDepositConstraintsModel represents the constraints for deposit estimation
+// EstimateDepositConstraintsModel represents the deprecated constraints
+// block. It accepts the constraints of fluence_vm, plus the deprecated flat
+// hardware lists.
 type EstimateDepositConstraintsModel struct {
-	BasicConfiguration       types.String   `tfsdk:"basic_configuration"`
-	MaxTotalPricePerEpochUsd types.String   `tfsdk:"max_total_price_per_epoch_usd"`
-	DatacenterCountries      []types.String `tfsdk:"datacenter_countries"`
+	ConstraintsModel
 
-	// Hardware constraints (optional)
+	// Deprecated flat hardware constraints
 	CpuArchitecture  []types.String `tfsdk:"cpu_architecture"`
 	CpuManufacturer  []types.String `tfsdk:"cpu_manufacturer"`
 	MemoryType       []types.String `tfsdk:"memory_type"`
@@ -55,78 +61,72 @@ func (d *estimateDepositDataSource) Metadata(_ context.Context, req datasource.M
 }
 
 func (d *estimateDepositDataSource) Schema(_ context.Context, _ datasource.SchemaRequest, resp *datasource.SchemaResponse) {
-	resp.Schema = schema.Schema{
-		MarkdownDescription: "Estimate the deposit required for creating VMs with given configuration and constraints",
-
-		Attributes: map[string]schema.Attribute{
-			"instances": schema.Int64Attribute{
-				MarkdownDescription: "Number of VM instances to estimate for",
-				Required:            true,
-			},
-			"constraints": schema.SingleNestedAttribute{
-				MarkdownDescription: "Constraints for the VM estimation",
-				Optional:            true,
-				Attributes: map[string]schema.Attribute{
-					"basic_configuration": schema.StringAttribute{
-						MarkdownDescription: "Basic configuration constraint (e.g., 'small', 'medium', 'large')",
-						Optional:            true,
-					},
-					"max_total_price_per_epoch_usd": schema.StringAttribute{
-						MarkdownDescription: "Maximum total price per epoch in USD",
-						Optional:            true,
-					},
-					"datacenter_countries": schema.ListAttribute{
-						MarkdownDescription: "List of allowed datacenter countries",
-						ElementType:         types.StringType,
-						Optional:            true,
-					},
-					"cpu_architecture": schema.ListAttribute{
-						MarkdownDescription: "List of allowed CPU architectures",
-						ElementType:         types.StringType,
-						Optional:            true,
-					},
-					"cpu_manufacturer": schema.ListAttribute{
-						MarkdownDescription: "List of allowed CPU manufacturers",
-						ElementType:         types.StringType,
-						Optional:            true,
-					},
-					"memory_type": schema.ListAttribute{
-						MarkdownDescription: "List of allowed memory types",
-						ElementType:         types.StringType,
-						Optional:            true,
-					},
-					"memory_generation": schema.ListAttribute{
-						MarkdownDescription: "List of allowed memory generations",
-						ElementType:         types.StringType,
-						Optional:            true,
-					},
-					"storage_type": schema.ListAttribute{
-						MarkdownDescription: "List of allowed storage types",
-						ElementType:         types.StringType,
-						Optional:            true,
-					},
-				},
-			},
-
-			// Output attributes
-			"deposit_amount_usdc": schema.StringAttribute{
-				MarkdownDescription: "Required deposit amount in USDC",
-				Computed:            true,
-			},
-			"deposit_epochs": schema.Int64Attribute{
-				MarkdownDescription: "Number of epochs the deposit covers",
-				Computed:            true,
-			},
-			"total_price_per_epoch": schema.StringAttribute{
-				MarkdownDescription: "Total price per epoch for all instances",
-				Computed:            true,
-			},
-			"max_price_per_epoch": schema.StringAttribute{
-				MarkdownDescription: "Maximum price per epoch for all instances",
-				Computed:            true,
-			},
+	nestedConstraintAttributes := constraintDataSourceAttributes()
+	for name, description := range map[string]string{
+		"cpu_architecture":  "List of allowed CPU architectures, combined with every `cpu_manufacturer`",
+		"cpu_manufacturer":  "List of allowed CPU manufacturers, combined with every `cpu_architecture`",
+		"memory_type":       "List of allowed memory types, combined with every `memory_generation`",
+		"memory_generation": "List of allowed memory generations, combined with every `memory_type`",
+		"storage_type":      "List of allowed storage types",
+	} {
+		nestedConstraintAttributes[name] = schema.ListAttribute{
+			MarkdownDescription: description,
+			ElementType:         types.StringType,
+			Optional:            true,
+			DeprecationMessage:  "Set the same list inside hardware_constraints instead, as in fluence_vm.",
+		}
+	}
+
+	// The constraints are set at the top level, exactly as in fluence_vm, and
+	// conflict with the deprecated constraints block
+	attributes := constraintDataSourceAttributes()
+	conflicts := []path.Expression{}
+	for name := range attributes {
+		conflicts = append(conflicts, path.MatchRoot(name))
+	}
+
+	attributes["instances"] = schema.Int64Attribute{
+		MarkdownDescription: "Number of VM instances to estimate for",
+		Required:            true,
+	}
+	attributes["constraints"] = schema.SingleNestedAttribute{
+		MarkdownDescription: "Constraints for the VM estimation, plus the deprecated flat hardware lists",
+		Optional:            true,
+		Attributes:          nestedConstraintAttributes,
+		DeprecationMessage:  "Set the constraints at the top level of the data source instead, as in fluence_vm.",
+		Validators: []validator.Object{
+			objectvalidator.ConflictsWith(conflicts...),
 		},
 	}
+
+	// Output attributes
+	for name, attribute := range map[string]schema.Attribute{
+		"deposit_amount_usdc": schema.StringAttribute{
+			MarkdownDescription: "Required deposit amount in USDC",
+			Computed:            true,
+		},
+		"deposit_epochs": schema.Int64Attribute{
+			MarkdownDescription: "Number of epochs the deposit covers",
+			Computed:            true,
+		},
+		"total_price_per_epoch": schema.StringAttribute{
+			MarkdownDescription: "Total price per epoch for all instances",
+			Computed:            true,
+		},
+		"max_price_per_epoch": schema.StringAttribute{
+			MarkdownDescription: "Maximum price per epoch for all instances",
+			Computed:            true,
+		},
+	} {
+		attributes[name] = attribute
+	}
+
+	resp.Schema = schema.Schema{
+		MarkdownDescription: "Estimate the deposit required for creating VMs with given configuration and constraints. " +
+			"Accepts the same constraint attributes as `fluence_vm`, so one set of constraints can be used for both",
+
+		Attributes: attributes,
+	}
 }
 
 func (d *estimateDepositDataSource) Configure(ctx context.Context, req datasource.ConfigureRequest, resp *datasource.ConfigureResponse) {
@@ -161,121 +161,13 @@ func (d *estimateDepositDataSource) Read(ctx context.Context, req datasource.Rea
 
 	// Build the estimation request
 	estimateRequest := fluenceapi.EstimateDepositRequestV3{
-		Instances: int(data.Instances.ValueInt64()),
+		Instances:   int(data.Instances.ValueInt64()),
+		Constraints: data.offerConstraints(),
 	}
 
-	// Build constraints if provided
+	// The deprecated constraints block conflicts with the top-level constraints
 	if data.Constraints != nil {
-		constraints := &fluenceapi.OfferConstraints{}
-
-		// Basic configuration
-		if !data.Constraints.BasicConfiguration.IsNull() {
-			basicConfig := data.Constraints.BasicConfiguration.ValueString()
-			constraints.BasicConfiguration = &basicConfig
-		}
-
-		// Max price per epoch
-		if !data.Constraints.MaxTotalPricePerEpochUsd.IsNull() {
-			maxPrice := data.Constraints.MaxTotalPricePerEpochUsd.ValueString()
-			constraints.MaxTotalPricePerEpochUsd = &maxPrice
-		}
-
-		// Datacenter countries
-		if len(data.Constraints.DatacenterCountries) > 0 {
-			countries := make([]string, len(data.Constraints.DatacenterCountries))
-			for i, country := range data.Constraints.DatacenterCountries {
-				countries[i] = country.ValueString()
-			}
-			constraints.Datacenter = &fluenceapi.DatacenterConstraint{
-				Countries: countries,
-			}
-		}
-
-		// Hardware constraints
-		hasHardwareConstraints := false
-		hardwareConstraints := &fluenceapi.HardwareConstraints{}
-
-		// CPU constraints
-		if len(data.Constraints.CpuArchitecture) > 0 || len(data.Constraints.CpuManufacturer) > 0 {
-			cpuHardware := []fluenceapi.CpuHardware{}
-
-			// If both are specified, create combinations
-			if len(data.Constraints.CpuArchitecture) > 0 && len(data.Constraints.CpuManufacturer) > 0 {
-				for _, arch := range data.Constraints.CpuArchitecture {
-					for _, mfr := range data.Constraints.CpuManufacturer {
-						cpuHardware = append(cpuHardware, fluenceapi.CpuHardware{
-							Architecture: arch.ValueString(),
-							Manufacturer: mfr.ValueString(),
-						})
-					}
-				}
-			} else if len(data.Constraints.CpuArchitecture) > 0 {
-				for _, arch := range data.Constraints.CpuArchitecture {
-					cpuHardware = append(cpuHardware, fluenceapi.CpuHardware{
-						Architecture: arch.ValueString(),
-					})
-				}
-			} else {
-				for _, mfr := range data.Constraints.CpuManufacturer {
-					cpuHardware = append(cpuHardware, fluenceapi.CpuHardware{
-						Manufacturer: mfr.ValueString(),
-					})
-				}
-			}
-
-			hardwareConstraints.Cpu = cpuHardware
-			hasHardwareConstraints = true
-		}
-
-		// Memory constraints
-		if len(data.Constraints.MemoryType) > 0 || len(data.Constraints.MemoryGeneration) > 0 {
-			memoryHardware := []fluenceapi.MemoryHardware{}
-
-			// If both are specified, create combinations
-			if len(data.Constraints.MemoryType) > 0 && len(data.Constraints.MemoryGeneration) > 0 {
-				for _, memType := range data.Constraints.MemoryType {
-					for _, gen := range data.Constraints.MemoryGeneration {
-						memoryHardware = append(memoryHardware, fluenceapi.MemoryHardware{
-							Type:       memType.ValueString(),
-							Generation: gen.ValueString(),
-						})
-					}
-				}
-			} else if len(data.Constraints.MemoryType) > 0 {
-				for _, memType := range data.Constraints.MemoryType {
-					memoryHardware = append(memoryHardware, fluenceapi.MemoryHardware{
-						Type: memType.ValueString(),
-					})
-				}
-			} else {
-				for _, gen := range data.Constraints.MemoryGeneration {
-					memoryHardware = append(memoryHardware, fluenceapi.MemoryHardware{
-						Generation: gen.ValueString(),
-					})
-				}
-			}
-
-			hardwareConstraints.Memory = memoryHardware
-			hasHardwareConstraints = true
-		}
-
-		// Storage constraints
-		if len(data.Constraints.StorageType) > 0 {
-			storageHardware := []fluenceapi.StorageHardware{}
-			for _, storageType := range data.Constraints.StorageType {
-				storageHardware = append(storageHardware, fluenceapi.StorageHardware{
-					Type: storageType.ValueString(),
-				})
-			}
-			hardwareConstraints.Storage = storageHardware
-			hasHardwareConstraints = true
-		}
-
-		if hasHardwareConstraints {
-			constraints.Hardware = hardwareConstraints
-		}
-
-		estimateRequest.Constraints = constraints
+		estimateRequest.Constraints = data.Constraints.offerConstraints()
 	}
 
 	tflog.Debug(ctx, "Estimating VM deposit", map[string]interface{}{
@@ -284,7 +176,7 @@ func (d *estimateDepositDataSource) Read(ctx context.Context, req datasource.Rea
 	})
 
 	// Call the API
-	estimate, err := d.client.EstimateDeposit(estimateRequest)
+	estimate, err := withContext(ctx, d.client).EstimateDeposit(estimateRequest)
 	if err != nil {
 		resp.Diagnostics.AddError("Client Error", fmt.Sprintf("Unable to estimate deposit, got error: %s", err))
 		return
@@ -306,3 +198,21 @@ func (d *estimateDepositDataSource) Read(ctx context.Context, req datasource.Rea
 	// Save data into Terraform state
 	resp.Diagnostics.Append(resp.State.Set(ctx, &data)...)
 }
+
+// offerConstraints builds the marketplace constraints shared with fluence_vm.
+// The deprecated flat hardware lists are added as one more
+// hardware_constraints entry.
+func (m *EstimateDepositConstraintsModel) offerConstraints() *fluenceapi.OfferConstraints {
+	constraints := m.ConstraintsModel
+	if len(m.CpuArchitecture) > 0 || len(m.CpuManufacturer) > 0 || len(m.MemoryType) > 0 || len(m.MemoryGeneration) > 0 || len(m.StorageType) > 0 {
+		constraints.HardwareConstraints = append(append([]HardwareConstraintModel{}, m.HardwareConstraints...), HardwareConstraintModel{
+			CpuArchitecture:  m.CpuArchitecture,
+			CpuManufacturer:  m.CpuManufacturer,
+			MemoryType:       m.MemoryType,
+			MemoryGeneration: m.MemoryGeneration,
+			StorageType:      m.StorageType,
+		})
+	}
+
+	return constraints.offerConstraints()
+}
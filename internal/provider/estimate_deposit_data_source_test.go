@@ -0,0 +1,114 @@
+package provider
+
+import (
+	"fmt"
+	"net/http"
+	"regexp"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-testing/helper/resource"
+
+	"terraform-provider-fluence/internal/fakeapi"
+)
+
+func TestAccEstimateDepositDataSource(t *testing.T) {
+	srv := testAccServer(t)
+
+	resource.Test(t, resource.TestCase{
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		Steps: []resource.TestStep{
+			// Without constraints the deposit covers the most expensive
+			// offer, and a server error is retried
+			{
+				PreConfig: func() {
+					srv.InjectFault(http.MethodPost, "/vms/v3/estimate", http.StatusServiceUnavailable, 1, 0)
+				},
+				Config: testAccEstimateDepositConfig(srv, "instances = 1"),
+				Check: resource.ComposeAggregateTestCheckFunc(
+					resource.TestCheckResourceAttr("data.fluence_vm_estimate_deposit.test", "total_price_per_epoch", "1.5"),
+					resource.TestCheckResourceAttr("data.fluence_vm_estimate_deposit.test", "max_price_per_epoch", "9.5"),
+					resource.TestCheckResourceAttr("data.fluence_vm_estimate_deposit.test", "deposit_amount_usdc", "19"),
+					resource.TestCheckResourceAttr("data.fluence_vm_estimate_deposit.test", "deposit_epochs", "2"),
+				),
+			},
+			{
+				Config: testAccEstimateDepositConfig(srv, `
+  instances            = 2
+  basic_configuration  = "cpu-2-ram-4gb-storage-25gb"
+  datacenter_countries = ["DE"]
+  hardware_constraints = [{
+    cpu = [{ architecture = "x86_64" }]
+  }]
+`),
+				Check: resource.ComposeAggregateTestCheckFunc(
+					resource.TestCheckResourceAttr("data.fluence_vm_estimate_deposit.test", "total_price_per_epoch", "3"),
+					resource.TestCheckResourceAttr("data.fluence_vm_estimate_deposit.test", "max_price_per_epoch", "3"),
+					resource.TestCheckResourceAttr("data.fluence_vm_estimate_deposit.test", "deposit_amount_usdc", "6"),
+				),
+			},
+			// No offer has the requested hardware
+			{
+				Config: testAccEstimateDepositConfig(srv, `
+  instances = 1
+  hardware_constraints = [{
+    cpu = [{ manufacturer = "Apple" }]
+  }]
+`),
+				ExpectError: regexp.MustCompile(`Unable to estimate deposit`),
+			},
+			{
+				Config: testAccEstimateDepositConfig(srv, `
+  instances           = 1
+  basic_configuration = "cpu-2-ram-4gb-storage-25gb"
+  constraints = {
+    basic_configuration = "cpu-4-ram-8gb-storage-25gb"
+  }
+`),
+				ExpectError: regexp.MustCompile(`Invalid Attribute Combination`),
+			},
+			// The deprecated constraints block and its flat hardware lists
+			// are still honored
+			{
+				Config: testAccEstimateDepositConfig(srv, `
+  instances = 1
+  constraints = {
+    basic_configuration = "cpu-4-ram-8gb-storage-25gb"
+    cpu_architecture    = ["arm64"]
+    storage_type        = ["NVMe"]
+  }
+`),
+				Check: resource.ComposeAggregateTestCheckFunc(
+					resource.TestCheckResourceAttr("data.fluence_vm_estimate_deposit.test", "total_price_per_epoch", "3"),
+					resource.TestCheckResourceAttr("data.fluence_vm_estimate_deposit.test", "deposit_amount_usdc", "6"),
+				),
+			},
+		},
+	})
+}
+
+func TestAccEstimateDepositDataSource_deprecatedHardwareUnavailable(t *testing.T) {
+	srv := testAccServer(t)
+
+	resource.Test(t, resource.TestCase{
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		Steps: []resource.TestStep{
+			{
+				Config: testAccEstimateDepositConfig(srv, `
+  instances = 1
+  constraints = {
+    memory_type = ["DDR3"]
+  }
+`),
+				ExpectError: regexp.MustCompile(`Unable to estimate deposit`),
+			},
+		},
+	})
+}
+
+func testAccEstimateDepositConfig(srv *fakeapi.Server, arguments string) string {
+	return testAccProviderConfig(srv) + fmt.Sprintf(`
+data "fluence_vm_estimate_deposit" "test" {
+%s
+}
+`, arguments)
+}
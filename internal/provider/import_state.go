@@ -0,0 +1,69 @@
+package provider
+
+import (
+	"context"
+
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/listplanmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/planmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/stringplanmodifier"
+)
+
+// importedPrivateStateKey marks, in the private state, VM resources adopted
+// through import. The API does not return the create-only arguments of a VM,
+// such as ssh_keys, hostname or the placement constraints, so they stay null
+// until the first apply after the import records them from the configuration.
+const importedPrivateStateKey = "imported"
+
+// privateState is the part of the framework private state used here.
+type privateState interface {
+	GetKey(ctx context.Context, key string) ([]byte, diag.Diagnostics)
+	SetKey(ctx context.Context, key string, value []byte) diag.Diagnostics
+}
+
+// markImported records that the resource was just imported.
+func markImported(ctx context.Context, private privateState) diag.Diagnostics {
+	return private.SetKey(ctx, importedPrivateStateKey, []byte(`true`))
+}
+
+// clearImported records that the create-only arguments of an imported
+// resource have been adopted from the configuration.
+func clearImported(ctx context.Context, private privateState) diag.Diagnostics {
+	return private.SetKey(ctx, importedPrivateStateKey, nil)
+}
+
+// isImported reports whether the create-only arguments of the resource were
+// never recorded since it was imported.
+func isImported(ctx context.Context, private privateState) (bool, diag.Diagnostics) {
+	value, diags := private.GetKey(ctx, importedPrivateStateKey)
+	return len(value) > 0, diags
+}
+
+// stringRequiresReplaceUnlessImported is stringplanmodifier.RequiresReplace,
+// except that a value missing from the state of an imported resource is
+// adopted in place instead of replacing the VMs.
+func stringRequiresReplaceUnlessImported() planmodifier.String {
+	return stringplanmodifier.RequiresReplaceIf(
+		func(ctx context.Context, req planmodifier.StringRequest, resp *stringplanmodifier.RequiresReplaceIfFuncResponse) {
+			imported, diags := isImported(ctx, req.Private)
+			resp.Diagnostics.Append(diags...)
+			resp.RequiresReplace = !(imported && req.StateValue.IsNull())
+		},
+		"Changing this value replaces the resource, unless it was unknown since the resource was imported.",
+		"Changing this value replaces the resource, unless it was unknown since the resource was imported.",
+	)
+}
+
+// listRequiresReplaceUnlessImported is the list variant of
+// stringRequiresReplaceUnlessImported.
+func listRequiresReplaceUnlessImported() planmodifier.List {
+	return listplanmodifier.RequiresReplaceIf(
+		func(ctx context.Context, req planmodifier.ListRequest, resp *listplanmodifier.RequiresReplaceIfFuncResponse) {
+			imported, diags := isImported(ctx, req.Private)
+			resp.Diagnostics.Append(diags...)
+			resp.RequiresReplace = !(imported && req.StateValue.IsNull())
+		},
+		"Changing this value replaces the resource, unless it was unknown since the resource was imported.",
+		"Changing this value replaces the resource, unless it was unknown since the resource was imported.",
+	)
+}
@@ -0,0 +1,255 @@
+package provider
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"sync"
+
+	fluenceapi "github.com/decentralized-infrastructure/fluence-api-client-go"
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+)
+
+// errSpendingLimitExceeded is returned when creating VMs would break one of
+// the provider-level spending limits.
+var errSpendingLimitExceeded = errors.New("spending limit exceeded")
+
+// spendingLimits holds the account-wide limits set in the provider
+// configuration. Zero values mean no limit.
+type spendingLimits struct {
+	MaxTotalPricePerEpoch float64
+	MaxVmCount            int
+}
+
+// enabled reports whether any limit is set.
+func (l spendingLimits) enabled() bool {
+	return l.MaxTotalPricePerEpoch > 0 || l.MaxVmCount > 0
+}
+
+// spendingGuard enforces the spending limits before VMs are created. Checks
+// and creations are serialized, so resources created in parallel cannot all
+// pass the check against the same listing.
+type spendingGuard struct {
+	mu     sync.Mutex
+	client *fluenceapi.Client
+	limits spendingLimits
+
+	// recent holds the price per epoch of VMs created by this provider
+	// instance, which the VM listing may not show yet
+	recent map[string]float64
+}
+
+// newSpendingGuard creates a guard enforcing limits with client.
+func newSpendingGuard(client *fluenceapi.Client, limits spendingLimits) *spendingGuard {
+	return &spendingGuard{
+		client: client,
+		limits: limits,
+		recent: map[string]float64{},
+	}
+}
+
+// createVms checks that request fits within the spending limits, then
+// submits it. The returned error wraps errSpendingLimitExceeded when the
+// request was refused.
+func (g *spendingGuard) createVms(ctx context.Context, request fluenceapi.CreateVmV3) ([]fluenceapi.CreatedVm, error) {
+	if !g.limits.enabled() {
+		return withContext(ctx, g.client).CreateVmV3(request)
+	}
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	count, total, err := g.usage(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := g.checkVmCount(count, request.Instances); err != nil {
+		return nil, err
+	}
+
+	newPrice := 0.0
+	if g.limits.MaxTotalPricePerEpoch > 0 {
+		newPrice, err = g.estimatePrice(ctx, request.Constraints, request.Instances)
+		if err != nil {
+			return nil, err
+		}
+
+		if err := g.checkPrice(total, request.Instances, newPrice); err != nil {
+			return nil, err
+		}
+	}
+
+	tflog.Debug(ctx, "VM creation fits within the spending limits", map[string]interface{}{
+		"vm_count":        count,
+		"price_per_epoch": total,
+		"instances":       request.Instances,
+		"new_price":       newPrice,
+	})
+
+	createdVms, err := withContext(ctx, g.client).CreateVmV3(request)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, createdVm := range createdVms {
+		g.recent[createdVm.VmId] = newPrice / float64(len(createdVms))
+	}
+	return createdVms, nil
+}
+
+// estimatePrice asks the API for the price per epoch of instances VMs
+// matching constraints, as checked against max_total_price_per_epoch_usd.
+func (g *spendingGuard) estimatePrice(ctx context.Context, constraints *fluenceapi.OfferConstraints, instances int) (float64, error) {
+	estimate, err := withContext(ctx, g.client).EstimateDeposit(fluenceapi.EstimateDepositRequestV3{
+		Constraints: constraints,
+		Instances:   instances,
+	})
+	if err != nil {
+		return 0, fmt.Errorf("unable to estimate the price of %d VM(s) to check the spending limits: %w", instances, err)
+	}
+	return limitedPrice(estimate)
+}
+
+// limitedPrice returns the price per epoch of an estimate that the spending
+// limits are checked against. The highest price the VMs may be placed at is
+// used, so the limit holds whatever offers they land on.
+func limitedPrice(estimate *fluenceapi.EstimatedDepositV3DTO) (float64, error) {
+	priceString := estimate.MaxPricePerEpoch
+	if priceString == "" {
+		priceString = estimate.TotalPricePerEpoch
+	}
+
+	price, err := strconv.ParseFloat(priceString, 64)
+	if err != nil {
+		return 0, fmt.Errorf("unable to parse the estimated price per epoch %q: %w", priceString, err)
+	}
+	return price, nil
+}
+
+// checkPlan checks at plan time that adding instances VMs fits within the
+// limits of the account as it is now, so that an apply is not refused
+// halfway through. newPrice is the estimated price per epoch of the new VMs,
+// and is only checked when priced is set. The check is repeated by createVms
+// at apply time, which also accounts for the other resources of the run.
+func (g *spendingGuard) checkPlan(ctx context.Context, instances int, newPrice float64, priced bool) error {
+	if !g.limits.enabled() || instances <= 0 {
+		return nil
+	}
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	count, total, err := g.usage(ctx)
+	if err != nil {
+		return err
+	}
+
+	if err := g.checkVmCount(count, instances); err != nil {
+		return err
+	}
+	if priced && g.limits.MaxTotalPricePerEpoch > 0 {
+		return g.checkPrice(total, instances, newPrice)
+	}
+	return nil
+}
+
+// checkPlannedVms runs checkPlan for the VMs a plan adds and reports the
+// result as plan diagnostics. A check that cannot be made is only a warning,
+// since createVms checks the limits again during apply.
+func (g *spendingGuard) checkPlannedVms(ctx context.Context, added int, newPrice float64, priced bool) diag.Diagnostics {
+	var diags diag.Diagnostics
+
+	err := g.checkPlan(ctx, added, newPrice, priced)
+	if errors.Is(err, errSpendingLimitExceeded) {
+		diags.AddAttributeError(path.Root("instances"), "Spending Limit Exceeded", fmt.Sprintf("Unable to plan %d additional VM(s): %s", added, err))
+		return diags
+	}
+	if err != nil {
+		diags.AddWarning("Spending Limit Check Error", fmt.Sprintf("Unable to check the spending limits at plan time, they are checked again during apply: %s", err))
+	}
+
+	return diags
+}
+
+// usage returns the number of live VMs in the account and their total price
+// per epoch, including the VMs created by this provider instance that are not
+// listed yet. A VM whose price cannot be parsed is counted without its price,
+// so that it does not block every creation in the account. The caller must
+// hold g.mu.
+func (g *spendingGuard) usage(ctx context.Context) (int, float64, error) {
+	vms, err := withContext(ctx, g.client).ListVmsV3()
+	if err != nil {
+		return 0, 0, fmt.Errorf("unable to list VMs to check the spending limits: %w", err)
+	}
+
+	// VMs created earlier in this run no longer need to be tracked once the
+	// listing shows them
+	for _, vm := range vms {
+		delete(g.recent, vm.Id)
+	}
+
+	count := 0
+	total := 0.0
+	for _, vm := range liveVms(vms) {
+		count++
+
+		price, err := strconv.ParseFloat(vm.PricePerEpoch, 64)
+		if err != nil {
+			tflog.Warn(ctx, "Ignoring the price of a VM in the spending limits", map[string]interface{}{
+				"vm_id":           vm.Id,
+				"price_per_epoch": vm.PricePerEpoch,
+				"error":           err.Error(),
+			})
+			continue
+		}
+		total += price
+	}
+
+	// Count the VMs created earlier in this run that are not listed yet
+	for _, price := range g.recent {
+		count++
+		total += price
+	}
+
+	return count, total, nil
+}
+
+// checkVmCount returns an error wrapping errSpendingLimitExceeded when adding
+// instances VMs to count breaks max_vm_count.
+func (g *spendingGuard) checkVmCount(count int, instances int) error {
+	if g.limits.MaxVmCount > 0 && count+instances > g.limits.MaxVmCount {
+		return fmt.Errorf("%w: creating %d VM(s) would bring the account to %d VMs, more than max_vm_count (%d)",
+			errSpendingLimitExceeded, instances, count+instances, g.limits.MaxVmCount)
+	}
+	return nil
+}
+
+// checkPrice returns an error wrapping errSpendingLimitExceeded when adding
+// instances VMs costing newPrice per epoch to total breaks
+// max_total_price_per_epoch_usd.
+func (g *spendingGuard) checkPrice(total float64, instances int, newPrice float64) error {
+	if total+newPrice > g.limits.MaxTotalPricePerEpoch {
+		return fmt.Errorf("%w: creating %d VM(s) costing up to %s USD per epoch would bring the account to %s USD per epoch, more than max_total_price_per_epoch_usd (%s)",
+			errSpendingLimitExceeded, instances, formatPrice(newPrice), formatPrice(total+newPrice), formatPrice(g.limits.MaxTotalPricePerEpoch))
+	}
+	return nil
+}
+
+// removeVms removes VMs and stops counting them against the limits.
+func (g *spendingGuard) removeVms(ctx context.Context, vmIds []string) (*fluenceapi.VmsRemoved, error) {
+	g.mu.Lock()
+	for _, id := range vmIds {
+		delete(g.recent, id)
+	}
+	g.mu.Unlock()
+
+	return withContext(ctx, g.client).RemoveVms(vmIds)
+}
+
+func formatPrice(v float64) string {
+	return strconv.FormatFloat(v, 'f', -1, 64)
+}
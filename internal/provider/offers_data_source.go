@@ -0,0 +1,264 @@
+package provider
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	fluenceapi "github.com/decentralized-infrastructure/fluence-api-client-go"
+	"github.com/hashicorp/terraform-plugin-framework/datasource"
+	"github.com/hashicorp/terraform-plugin-framework/datasource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+)
+
+// Ensure provider defined types fully satisfy framework interfaces.
+var _ datasource.DataSource = &offersDataSource{}
+
+func NewOffersDataSource() datasource.DataSource {
+	return &offersDataSource{}
+}
+
+// offersDataSource lists the marketplace offers matching constraints.
+type offersDataSource struct {
+	client *fluenceapi.Client
+}
+
+// OffersDataSourceModel describes the data source data model.
+type OffersDataSourceModel struct {
+	// Input parameters, with the same constraints as fluence_vm
+	ConstraintsModel
+
+	// Output results
+	Offers []OfferModel `tfsdk:"offers"`
+}
+
+// OfferModel describes one marketplace offer.
+type OfferModel struct {
+	BasicConfiguration  types.String            `tfsdk:"basic_configuration"`
+	PricePerEpoch       types.String            `tfsdk:"price_per_epoch"`
+	Datacenter          *vmDatacenterModel      `tfsdk:"datacenter"`
+	Resources           []OfferResourceModel    `tfsdk:"resources"`
+	AvailableInstances  types.Int64             `tfsdk:"available_instances"`
+	Servers             types.Int64             `tfsdk:"servers"`
+	MaxAdditionalSupply []OfferAdditionalSupply `tfsdk:"max_additional_supply"`
+}
+
+// OfferResourceModel describes a hardware resource of an offer.
+type OfferResourceModel struct {
+	Type     types.String            `tfsdk:"type"`
+	Price    types.String            `tfsdk:"price"`
+	Metadata map[string]types.String `tfsdk:"metadata"`
+}
+
+// OfferAdditionalSupply describes additional resources that can be added to
+// the VMs of an offer.
+type OfferAdditionalSupply struct {
+	Type       types.String `tfsdk:"type"`
+	Supply     types.Int64  `tfsdk:"supply"`
+	Units      types.String `tfsdk:"units"`
+	Price      types.String `tfsdk:"price"`
+	PerVmLimit types.Int64  `tfsdk:"per_vm_limit"`
+}
+
+func (d *offersDataSource) Metadata(_ context.Context, req datasource.MetadataRequest, resp *datasource.MetadataResponse) {
+	resp.TypeName = req.ProviderTypeName + "_offers"
+}
+
+func (d *offersDataSource) Schema(_ context.Context, _ datasource.SchemaRequest, resp *datasource.SchemaResponse) {
+	// The constraints are set at the top level, exactly as in fluence_vm
+	attributes := constraintDataSourceAttributes()
+	attributes["offers"] = schema.ListNestedAttribute{
+		MarkdownDescription: "Matching offers, in the order returned by the API",
+		Computed:            true,
+		NestedObject: schema.NestedAttributeObject{
+			Attributes: map[string]schema.Attribute{
+				"basic_configuration": schema.StringAttribute{
+					MarkdownDescription: "Basic configuration offered",
+					Computed:            true,
+				},
+				"price_per_epoch": schema.StringAttribute{
+					MarkdownDescription: "Price per epoch in USD of one VM with the basic configuration",
+					Computed:            true,
+				},
+				"datacenter": schema.SingleNestedAttribute{
+					MarkdownDescription: "Datacenter of the offer",
+					Computed:            true,
+					Attributes:          datacenterAttributes(),
+				},
+				"resources": schema.ListNestedAttribute{
+					MarkdownDescription: "Hardware resources of the offer",
+					Computed:            true,
+					NestedObject: schema.NestedAttributeObject{
+						Attributes: map[string]schema.Attribute{
+							"type": schema.StringAttribute{
+								MarkdownDescription: "Resource type",
+								Computed:            true,
+							},
+							"price": schema.StringAttribute{
+								MarkdownDescription: "Resource price",
+								Computed:            true,
+							},
+							"metadata": schema.MapAttribute{
+								MarkdownDescription: "Resource details, such as the CPU architecture or storage type. Values that are not strings are JSON encoded",
+								ElementType:         types.StringType,
+								Computed:            true,
+							},
+						},
+					},
+				},
+				"available_instances": schema.Int64Attribute{
+					MarkdownDescription: "Number of VMs with the basic configuration that can still be created, summed over the servers of the offer",
+					Computed:            true,
+				},
+				"servers": schema.Int64Attribute{
+					MarkdownDescription: "Number of servers in the offer",
+					Computed:            true,
+				},
+				"max_additional_supply": schema.ListNestedAttribute{
+					MarkdownDescription: "Additional resources that can be added to the VMs of the offer",
+					Computed:            true,
+					NestedObject: schema.NestedAttributeObject{
+						Attributes: map[string]schema.Attribute{
+							"type": schema.StringAttribute{
+								MarkdownDescription: "Resource type",
+								Computed:            true,
+							},
+							"supply": schema.Int64Attribute{
+								MarkdownDescription: "Amount available",
+								Computed:            true,
+							},
+							"units": schema.StringAttribute{
+								MarkdownDescription: "Units of `supply`",
+								Computed:            true,
+							},
+							"price": schema.StringAttribute{
+								MarkdownDescription: "Resource price",
+								Computed:            true,
+							},
+							"per_vm_limit": schema.Int64Attribute{
+								MarkdownDescription: "Maximum amount a single VM can get, if limited",
+								Computed:            true,
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+
+	resp.Schema = schema.Schema{
+		MarkdownDescription: "List the marketplace offers matching the given constraints, with their price and available capacity. The constraints are the same as those of `fluence_vm`, and an empty list means a `fluence_vm` with the same constraints cannot be created",
+		Attributes:          attributes,
+	}
+}
+
+func (d *offersDataSource) Configure(ctx context.Context, req datasource.ConfigureRequest, resp *datasource.ConfigureResponse) {
+	// Prevent panic if the provider has not been configured.
+	if req.ProviderData == nil {
+		return
+	}
+
+	client, ok := req.ProviderData.(*fluenceapi.Client)
+
+	if !ok {
+		resp.Diagnostics.AddError(
+			"Unexpected Data Source Configure Type",
+			fmt.Sprintf("Expected *fluenceapi.Client, got: %T. Please report this issue to the provider developers.", req.ProviderData),
+		)
+
+		return
+	}
+
+	d.client = client
+}
+
+func (d *offersDataSource) Read(ctx context.Context, req datasource.ReadRequest, resp *datasource.ReadResponse) {
+	var data OffersDataSourceModel
+
+	// Read Terraform configuration data into the model
+	resp.Diagnostics.Append(req.Config.Get(ctx, &data)...)
+
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	constraints := data.offerConstraints()
+
+	tflog.Debug(ctx, "Fetching marketplace offers", map[string]interface{}{
+		"constraints": constraints,
+	})
+
+	offerings, err := withContext(ctx, d.client).GetMarketplaceOffers(constraints)
+	if err != nil {
+		resp.Diagnostics.AddError("Client Error", fmt.Sprintf("Unable to read marketplace offers, got error: %s", err))
+		return
+	}
+
+	tflog.Debug(ctx, "Retrieved marketplace offers", map[string]interface{}{
+		"count": len(offerings),
+	})
+
+	data.Offers = []OfferModel{}
+	for _, offering := range offerings {
+		offer := OfferModel{
+			BasicConfiguration:  types.StringValue(offering.Configuration.Slug),
+			PricePerEpoch:       types.StringValue(offering.Configuration.Price),
+			Datacenter:          newVmDatacenterModel(offering.Datacenter),
+			Resources:           []OfferResourceModel{},
+			Servers:             types.Int64Value(int64(len(offering.Servers))),
+			MaxAdditionalSupply: []OfferAdditionalSupply{},
+		}
+
+		var available uint64
+		for _, server := range offering.Servers {
+			available += server.AvailableBasicInstances
+		}
+		offer.AvailableInstances = types.Int64Value(int64(available))
+
+		for _, resource := range offering.Resources {
+			offer.Resources = append(offer.Resources, OfferResourceModel{
+				Type:     types.StringValue(resource.Type),
+				Price:    types.StringValue(resource.Price),
+				Metadata: resourceMetadata(resource.Metadata),
+			})
+		}
+
+		for _, supply := range offering.MaxAdditionalSupply {
+			additional := OfferAdditionalSupply{
+				Type:       types.StringValue(supply.Type),
+				Supply:     types.Int64Value(int64(supply.Supply.Supply)),
+				Units:      types.StringValue(supply.Units),
+				Price:      types.StringValue(supply.Price),
+				PerVmLimit: types.Int64Null(),
+			}
+			if supply.PerVmLimit != nil {
+				additional.PerVmLimit = types.Int64Value(int64(*supply.PerVmLimit))
+			}
+			offer.MaxAdditionalSupply = append(offer.MaxAdditionalSupply, additional)
+		}
+
+		data.Offers = append(data.Offers, offer)
+	}
+
+	// Save data into Terraform state
+	resp.Diagnostics.Append(resp.State.Set(ctx, &data)...)
+}
+
+// resourceMetadata converts the free-form metadata of a resource to strings.
+func resourceMetadata(metadata fluenceapi.ResourceMetadata) map[string]types.String {
+	values := map[string]types.String{}
+	for key, value := range metadata {
+		if s, ok := value.(string); ok {
+			values[key] = types.StringValue(s)
+			continue
+		}
+
+		encoded, err := json.Marshal(value)
+		if err != nil {
+			encoded = []byte(fmt.Sprint(value))
+		}
+		values[key] = types.StringValue(string(encoded))
+	}
+	return values
+}
@@ -0,0 +1,70 @@
+package provider
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-testing/helper/resource"
+
+	"terraform-provider-fluence/internal/fakeapi"
+)
+
+func TestAccOffersDataSource(t *testing.T) {
+	srv := testAccServer(t)
+
+	resource.Test(t, resource.TestCase{
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		Steps: []resource.TestStep{
+			{
+				Config: testAccOffersConfig(srv, ""),
+				Check:  resource.TestCheckResourceAttr("data.fluence_offers.test", "offers.#", "12"),
+			},
+			{
+				Config: testAccOffersConfig(srv, `
+  datacenter_countries          = ["DE"]
+  max_total_price_per_epoch_usd = "3"
+`),
+				Check: resource.ComposeAggregateTestCheckFunc(
+					resource.TestCheckResourceAttr("data.fluence_offers.test", "offers.#", "2"),
+					resource.TestCheckResourceAttr("data.fluence_offers.test", "offers.0.basic_configuration", "cpu-2-ram-4gb-storage-25gb"),
+					resource.TestCheckResourceAttr("data.fluence_offers.test", "offers.0.price_per_epoch", "1.5"),
+					resource.TestCheckResourceAttr("data.fluence_offers.test", "offers.0.datacenter.country_code", "DE"),
+					resource.TestCheckResourceAttr("data.fluence_offers.test", "offers.0.datacenter.city_code", "FRA"),
+					resource.TestCheckResourceAttr("data.fluence_offers.test", "offers.0.available_instances", "10"),
+					resource.TestCheckResourceAttr("data.fluence_offers.test", "offers.0.servers", "1"),
+					resource.TestCheckResourceAttr("data.fluence_offers.test", "offers.1.basic_configuration", "cpu-4-ram-8gb-storage-25gb"),
+				),
+			},
+			// Running VMs use up the capacity of their offer
+			{
+				PreConfig: func() {
+					testAccCreateVms(t, srv, "acc-vm", "cpu-2-ram-4gb-storage-25gb", "DE", testAccOsImage, 3)
+				},
+				Config: testAccOffersConfig(srv, `
+  basic_configuration  = "cpu-2-ram-4gb-storage-25gb"
+  datacenter_countries = ["DE"]
+`),
+				Check: resource.ComposeAggregateTestCheckFunc(
+					resource.TestCheckResourceAttr("data.fluence_offers.test", "offers.#", "1"),
+					resource.TestCheckResourceAttr("data.fluence_offers.test", "offers.0.available_instances", "7"),
+				),
+			},
+			{
+				Config: testAccOffersConfig(srv, `
+  hardware_constraints = [{
+    memory = [{ type = "DDR3" }]
+  }]
+`),
+				Check: resource.TestCheckResourceAttr("data.fluence_offers.test", "offers.#", "0"),
+			},
+		},
+	})
+}
+
+func testAccOffersConfig(srv *fakeapi.Server, arguments string) string {
+	return testAccProviderConfig(srv) + fmt.Sprintf(`
+data "fluence_offers" "test" {
+%s
+}
+`, arguments)
+}
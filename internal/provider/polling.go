@@ -0,0 +1,98 @@
+package provider
+
+import (
+	"context"
+	"math/rand/v2"
+	"time"
+)
+
+// Default polling settings used when the provider configuration does not set them.
+const (
+	defaultPollInterval    = 2 * time.Second
+	defaultMaxPollInterval = 30 * time.Second
+)
+
+// pollConfig controls how often the provider polls the Fluence API while
+// waiting for VM state changes.
+type pollConfig struct {
+	// Interval is the delay before the second poll. Each subsequent delay
+	// doubles until it reaches MaxInterval.
+	Interval time.Duration
+
+	// MaxInterval caps the delay between two polls.
+	MaxInterval time.Duration
+}
+
+// defaultPollConfig returns the polling settings used when none are configured.
+func defaultPollConfig() pollConfig {
+	return pollConfig{
+		Interval:    defaultPollInterval,
+		MaxInterval: defaultMaxPollInterval,
+	}
+}
+
+// newPoller starts a new polling loop.
+func (c pollConfig) newPoller() *poller {
+	return &poller{
+		config: c,
+		start:  time.Now(),
+	}
+}
+
+// poller spaces out the iterations of one polling loop using exponential
+// backoff with jitter.
+type poller struct {
+	config  pollConfig
+	attempt int
+	start   time.Time
+}
+
+// Attempt returns the number of the current poll, starting at 1.
+func (p *poller) Attempt() int {
+	return p.attempt + 1
+}
+
+// Elapsed returns the wall-clock time spent since the loop started.
+func (p *poller) Elapsed() time.Duration {
+	return time.Since(p.start)
+}
+
+// Wait sleeps until the next poll is due. It returns the context error as
+// soon as ctx is cancelled or its deadline passes.
+func (p *poller) Wait(ctx context.Context) error {
+	timer := time.NewTimer(p.nextDelay())
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		p.attempt++
+		return nil
+	}
+}
+
+// nextDelay returns the backoff delay for the current attempt. Half of the
+// delay is fixed and the other half is random so that concurrent resources
+// do not poll the API in lockstep.
+func (p *poller) nextDelay() time.Duration {
+	delay := p.config.Interval
+	if delay <= 0 {
+		delay = defaultPollInterval
+	}
+
+	maxDelay := p.config.MaxInterval
+	if maxDelay < delay {
+		maxDelay = delay
+	}
+
+	for i := 0; i < p.attempt && delay < maxDelay; i++ {
+		delay *= 2
+	}
+	if delay > maxDelay {
+		delay = maxDelay
+	}
+
+	half := delay / 2
+	return half + rand.N(delay-half+1)
+}
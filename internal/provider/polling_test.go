@@ -0,0 +1,64 @@
+package provider
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestPollerNextDelay(t *testing.T) {
+	p := pollConfig{Interval: time.Second, MaxInterval: 5 * time.Second}.newPoller()
+
+	for attempt, want := range []time.Duration{time.Second, 2 * time.Second, 4 * time.Second, 5 * time.Second, 5 * time.Second} {
+		p.attempt = attempt
+		delay := p.nextDelay()
+		if delay < want/2 || delay > want {
+			t.Errorf("attempt %d: nextDelay() = %s, want between %s and %s", attempt+1, delay, want/2, want)
+		}
+	}
+}
+
+func TestPollerNextDelay_defaults(t *testing.T) {
+	// A missing interval falls back to the default, and a maximum below the
+	// interval is raised to it
+	p := pollConfig{MaxInterval: time.Millisecond}.newPoller()
+	p.attempt = 3
+
+	if delay := p.nextDelay(); delay < defaultPollInterval/2 || delay > defaultPollInterval {
+		t.Errorf("nextDelay() = %s, want between %s and %s", delay, defaultPollInterval/2, defaultPollInterval)
+	}
+}
+
+func TestPollerWait(t *testing.T) {
+	p := pollConfig{Interval: time.Millisecond, MaxInterval: 2 * time.Millisecond}.newPoller()
+
+	if p.Attempt() != 1 {
+		t.Fatalf("Attempt() = %d, want 1", p.Attempt())
+	}
+	for i := 0; i < 3; i++ {
+		if err := p.Wait(context.Background()); err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+	}
+	if p.Attempt() != 4 {
+		t.Errorf("Attempt() = %d, want 4", p.Attempt())
+	}
+	if p.Elapsed() <= 0 {
+		t.Errorf("Elapsed() = %s, want a positive duration", p.Elapsed())
+	}
+}
+
+func TestPollerWait_cancelled(t *testing.T) {
+	p := pollConfig{Interval: time.Hour, MaxInterval: time.Hour}.newPoller()
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond)
+	defer cancel()
+
+	if err := p.Wait(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Wait() = %v, want %v", err, context.DeadlineExceeded)
+	}
+	if p.Attempt() != 1 {
+		t.Errorf("Attempt() = %d, want 1 after a cancelled wait", p.Attempt())
+	}
+}
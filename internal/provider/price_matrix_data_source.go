@@ -0,0 +1,353 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	fluenceapi "github.com/decentralized-infrastructure/fluence-api-client-go"
+	"github.com/hashicorp/terraform-plugin-framework-validators/int64validator"
+	"github.com/hashicorp/terraform-plugin-framework-validators/listvalidator"
+	"github.com/hashicorp/terraform-plugin-framework-validators/stringvalidator"
+	"github.com/hashicorp/terraform-plugin-framework/datasource"
+	"github.com/hashicorp/terraform-plugin-framework/datasource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/schema/validator"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+)
+
+// Defaults of the optional price matrix inputs.
+const (
+	defaultPriceMatrixInstances   = 1
+	defaultPriceMatrixParallelism = 4
+	maxPriceMatrixParallelism     = 16
+)
+
+// Ensure provider defined types fully satisfy framework interfaces.
+var _ datasource.DataSource = &priceMatrixDataSource{}
+
+func NewPriceMatrixDataSource() datasource.DataSource {
+	return &priceMatrixDataSource{}
+}
+
+// priceMatrixDataSource estimates the deposit of every combination of basic
+// configuration and country.
+type priceMatrixDataSource struct {
+	client *fluenceapi.Client
+}
+
+// PriceMatrixDataSourceModel describes the data source data model.
+type PriceMatrixDataSourceModel struct {
+	BasicConfigurations []types.String `tfsdk:"basic_configurations"`
+	Countries           []types.String `tfsdk:"countries"`
+	Instances           types.Int64    `tfsdk:"instances"`
+	Parallelism         types.Int64    `tfsdk:"parallelism"`
+
+	Entries  []PriceMatrixEntryModel `tfsdk:"entries"`
+	Cheapest *PriceMatrixEntryModel  `tfsdk:"cheapest"`
+}
+
+// PriceMatrixEntryModel describes the estimate of one combination.
+type PriceMatrixEntryModel struct {
+	BasicConfiguration types.String `tfsdk:"basic_configuration"`
+	Country            types.String `tfsdk:"country"`
+	PricePerEpoch      types.String `tfsdk:"price_per_epoch"`
+	MaxPricePerEpoch   types.String `tfsdk:"max_price_per_epoch"`
+	DepositAmountUsdc  types.String `tfsdk:"deposit_amount_usdc"`
+	DepositEpochs      types.Int64  `tfsdk:"deposit_epochs"`
+	Error              types.String `tfsdk:"error"`
+}
+
+// priceMatrixEntry is the outcome of one estimate.
+type priceMatrixEntry struct {
+	configuration string
+	country       string
+	estimate      *fluenceapi.EstimatedDepositV3DTO
+	price         float64
+	err           error
+}
+
+func (d *priceMatrixDataSource) Metadata(_ context.Context, req datasource.MetadataRequest, resp *datasource.MetadataResponse) {
+	resp.TypeName = req.ProviderTypeName + "_price_matrix"
+}
+
+func (d *priceMatrixDataSource) Schema(_ context.Context, _ datasource.SchemaRequest, resp *datasource.SchemaResponse) {
+	entryAttributes := map[string]schema.Attribute{
+		"basic_configuration": schema.StringAttribute{
+			MarkdownDescription: "Basic configuration of the combination",
+			Computed:            true,
+		},
+		"country": schema.StringAttribute{
+			MarkdownDescription: "Country of the combination. Null when `countries` is not set",
+			Computed:            true,
+		},
+		"price_per_epoch": schema.StringAttribute{
+			MarkdownDescription: "Total price per epoch in USD for `instances` VMs",
+			Computed:            true,
+		},
+		"max_price_per_epoch": schema.StringAttribute{
+			MarkdownDescription: "Maximum total price per epoch in USD for `instances` VMs",
+			Computed:            true,
+		},
+		"deposit_amount_usdc": schema.StringAttribute{
+			MarkdownDescription: "Required deposit amount in USDC",
+			Computed:            true,
+		},
+		"deposit_epochs": schema.Int64Attribute{
+			MarkdownDescription: "Number of epochs the deposit covers",
+			Computed:            true,
+		},
+		"error": schema.StringAttribute{
+			MarkdownDescription: "Why the combination could not be estimated, for example because no offer matches it. Null on success",
+			Computed:            true,
+		},
+	}
+
+	resp.Schema = schema.Schema{
+		MarkdownDescription: "Estimate the price and deposit of every combination of basic configuration and country, to compare them in a single plan. Estimates run concurrently",
+
+		Attributes: map[string]schema.Attribute{
+			"basic_configurations": schema.ListAttribute{
+				MarkdownDescription: "Basic configurations to compare",
+				ElementType:         types.StringType,
+				Required:            true,
+				Validators: []validator.List{
+					listvalidator.SizeAtLeast(1),
+					listvalidator.UniqueValues(),
+					listvalidator.ValueStringsAre(stringvalidator.LengthAtLeast(1)),
+				},
+			},
+			"countries": schema.ListAttribute{
+				MarkdownDescription: "Datacenter countries to compare, as ISO 3166-1 alpha-2 codes. When omitted, each configuration is estimated without a country constraint",
+				ElementType:         types.StringType,
+				Optional:            true,
+				Validators: []validator.List{
+					listvalidator.UniqueValues(),
+					listvalidator.ValueStringsAre(countryCodeValidator{}),
+				},
+			},
+			"instances": schema.Int64Attribute{
+				MarkdownDescription: fmt.Sprintf("Number of VM instances to estimate for. Defaults to %d", defaultPriceMatrixInstances),
+				Optional:            true,
+				Validators: []validator.Int64{
+					int64validator.AtLeast(1),
+				},
+			},
+			"parallelism": schema.Int64Attribute{
+				MarkdownDescription: fmt.Sprintf("Maximum number of estimates running at the same time, from 1 to %d. Defaults to %d", maxPriceMatrixParallelism, defaultPriceMatrixParallelism),
+				Optional:            true,
+				Validators: []validator.Int64{
+					int64validator.Between(1, maxPriceMatrixParallelism),
+				},
+			},
+			"entries": schema.ListNestedAttribute{
+				MarkdownDescription: "One entry per combination, cheapest first. Combinations that could not be estimated come last",
+				Computed:            true,
+				NestedObject: schema.NestedAttributeObject{
+					Attributes: entryAttributes,
+				},
+			},
+			"cheapest": schema.SingleNestedAttribute{
+				MarkdownDescription: "The cheapest combination, the first of `entries`",
+				Computed:            true,
+				Attributes:          entryAttributes,
+			},
+		},
+	}
+}
+
+func (d *priceMatrixDataSource) Configure(ctx context.Context, req datasource.ConfigureRequest, resp *datasource.ConfigureResponse) {
+	// Prevent panic if the provider has not been configured.
+	if req.ProviderData == nil {
+		return
+	}
+
+	client, ok := req.ProviderData.(*fluenceapi.Client)
+
+	if !ok {
+		resp.Diagnostics.AddError(
+			"Unexpected Data Source Configure Type",
+			fmt.Sprintf("Expected *fluenceapi.Client, got: %T. Please report this issue to the provider developers.", req.ProviderData),
+		)
+
+		return
+	}
+
+	d.client = client
+}
+
+func (d *priceMatrixDataSource) Read(ctx context.Context, req datasource.ReadRequest, resp *datasource.ReadResponse) {
+	var data PriceMatrixDataSourceModel
+
+	// Read Terraform configuration data into the model
+	resp.Diagnostics.Append(req.Config.Get(ctx, &data)...)
+
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	instances := int64(defaultPriceMatrixInstances)
+	if !data.Instances.IsNull() {
+		instances = data.Instances.ValueInt64()
+	}
+	parallelism := int64(defaultPriceMatrixParallelism)
+	if !data.Parallelism.IsNull() {
+		parallelism = data.Parallelism.ValueInt64()
+	}
+
+	// An empty country stands for no country constraint
+	countries := []string{""}
+	if len(data.Countries) > 0 {
+		countries = []string{}
+		for _, country := range data.Countries {
+			countries = append(countries, country.ValueString())
+		}
+	}
+
+	entries := []*priceMatrixEntry{}
+	for _, configuration := range data.BasicConfigurations {
+		for _, country := range countries {
+			entries = append(entries, &priceMatrixEntry{
+				configuration: configuration.ValueString(),
+				country:       country,
+			})
+		}
+	}
+
+	tflog.Debug(ctx, "Estimating price matrix", map[string]interface{}{
+		"combinations": len(entries),
+		"instances":    instances,
+		"parallelism":  parallelism,
+	})
+
+	d.estimate(ctx, entries, int(instances), int(parallelism))
+	if err := ctx.Err(); err != nil {
+		resp.Diagnostics.AddError(
+			"Price Matrix Interrupted",
+			fmt.Sprintf("The price matrix was interrupted before every combination was estimated: %s", err),
+		)
+		return
+	}
+
+	sort.SliceStable(entries, func(i, j int) bool {
+		a, b := entries[i], entries[j]
+		if (a.err == nil) != (b.err == nil) {
+			return a.err == nil
+		}
+		if a.err == nil && a.price != b.price {
+			return a.price < b.price
+		}
+		if a.configuration != b.configuration {
+			return a.configuration < b.configuration
+		}
+		return a.country < b.country
+	})
+
+	if entries[0].err != nil {
+		resp.Diagnostics.AddError(
+			"Client Error",
+			fmt.Sprintf("Unable to estimate any of the %d combinations, got error: %s", len(entries), entries[0].err),
+		)
+		return
+	}
+
+	data.Entries = []PriceMatrixEntryModel{}
+	for _, entry := range entries {
+		data.Entries = append(data.Entries, entry.model())
+	}
+	data.Cheapest = &data.Entries[0]
+
+	// Save data into Terraform state
+	resp.Diagnostics.Append(resp.State.Set(ctx, &data)...)
+}
+
+// estimate fills in the estimate of every entry, running at most
+// parallelism requests at the same time. Once ctx is done, no new request is
+// started and the remaining entries get the context error.
+func (d *priceMatrixDataSource) estimate(ctx context.Context, entries []*priceMatrixEntry, instances int, parallelism int) {
+	var wg sync.WaitGroup
+	slots := make(chan struct{}, parallelism)
+
+	for i, entry := range entries {
+		select {
+		case slots <- struct{}{}:
+		case <-ctx.Done():
+			for _, skipped := range entries[i:] {
+				skipped.err = ctx.Err()
+			}
+			wg.Wait()
+			return
+		}
+		wg.Add(1)
+
+		go func(entry *priceMatrixEntry) {
+			defer wg.Done()
+			defer func() { <-slots }()
+
+			// The client calls cannot be interrupted, so the context is
+			// checked before each of them
+			if err := ctx.Err(); err != nil {
+				entry.err = err
+				return
+			}
+
+			constraints := ConstraintsModel{
+				BasicConfiguration:       types.StringValue(entry.configuration),
+				MaxTotalPricePerEpochUsd: types.StringNull(),
+			}
+			if entry.country != "" {
+				constraints.Countries = []types.String{types.StringValue(entry.country)}
+			}
+
+			estimate, err := withContext(ctx, d.client).EstimateDeposit(fluenceapi.EstimateDepositRequestV3{
+				Constraints: constraints.offerConstraints(),
+				Instances:   instances,
+			})
+			if err != nil {
+				entry.err = err
+				return
+			}
+
+			price, err := strconv.ParseFloat(estimate.TotalPricePerEpoch, 64)
+			if err != nil {
+				entry.err = fmt.Errorf("unable to parse the estimated price per epoch %q: %w", estimate.TotalPricePerEpoch, err)
+				return
+			}
+
+			entry.estimate = estimate
+			entry.price = price
+		}(entry)
+	}
+
+	wg.Wait()
+}
+
+// model maps the entry to PriceMatrixEntryModel.
+func (e *priceMatrixEntry) model() PriceMatrixEntryModel {
+	model := PriceMatrixEntryModel{
+		BasicConfiguration: types.StringValue(e.configuration),
+		Country:            types.StringNull(),
+		PricePerEpoch:      types.StringNull(),
+		MaxPricePerEpoch:   types.StringNull(),
+		DepositAmountUsdc:  types.StringNull(),
+		DepositEpochs:      types.Int64Null(),
+		Error:              types.StringNull(),
+	}
+	if e.country != "" {
+		model.Country = types.StringValue(e.country)
+	}
+
+	if e.err != nil {
+		model.Error = types.StringValue(strings.TrimSpace(e.err.Error()))
+		return model
+	}
+
+	model.PricePerEpoch = types.StringValue(e.estimate.TotalPricePerEpoch)
+	model.MaxPricePerEpoch = types.StringValue(e.estimate.MaxPricePerEpoch)
+	model.DepositAmountUsdc = types.StringValue(e.estimate.DepositAmountUsdc)
+	model.DepositEpochs = types.Int64Value(int64(e.estimate.DepositEpochs))
+	return model
+}
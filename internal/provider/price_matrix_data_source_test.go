@@ -0,0 +1,81 @@
+package provider
+
+import (
+	"fmt"
+	"net/http"
+	"regexp"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-testing/helper/resource"
+
+	"terraform-provider-fluence/internal/fakeapi"
+)
+
+func TestAccPriceMatrixDataSource(t *testing.T) {
+	srv := testAccServer(t)
+
+	resource.Test(t, resource.TestCase{
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		Steps: []resource.TestStep{
+			// Failed estimates are retried, and combinations without offers
+			// come last with their error
+			{
+				PreConfig: func() {
+					srv.InjectFault(http.MethodPost, "/vms/v3/estimate", http.StatusServiceUnavailable, 2, 0)
+				},
+				Config: testAccPriceMatrixConfig(srv, `
+  basic_configurations = ["cpu-4-ram-8gb-storage-25gb", "cpu-2-ram-4gb-storage-25gb", "cpu-64-ram-1tb-storage-1tb"]
+  countries            = ["US", "DE"]
+  instances            = 2
+  parallelism          = 2
+`),
+				Check: resource.ComposeAggregateTestCheckFunc(
+					resource.TestCheckResourceAttr("data.fluence_price_matrix.test", "entries.#", "6"),
+					resource.TestCheckResourceAttr("data.fluence_price_matrix.test", "entries.0.basic_configuration", "cpu-2-ram-4gb-storage-25gb"),
+					resource.TestCheckResourceAttr("data.fluence_price_matrix.test", "entries.0.country", "DE"),
+					resource.TestCheckResourceAttr("data.fluence_price_matrix.test", "entries.1.country", "US"),
+					resource.TestCheckResourceAttr("data.fluence_price_matrix.test", "entries.2.basic_configuration", "cpu-4-ram-8gb-storage-25gb"),
+					resource.TestCheckResourceAttr("data.fluence_price_matrix.test", "entries.2.price_per_epoch", "6"),
+					resource.TestCheckResourceAttr("data.fluence_price_matrix.test", "entries.4.basic_configuration", "cpu-64-ram-1tb-storage-1tb"),
+					resource.TestCheckResourceAttrSet("data.fluence_price_matrix.test", "entries.4.error"),
+					resource.TestCheckNoResourceAttr("data.fluence_price_matrix.test", "entries.4.price_per_epoch"),
+					resource.TestCheckResourceAttr("data.fluence_price_matrix.test", "cheapest.basic_configuration", "cpu-2-ram-4gb-storage-25gb"),
+					resource.TestCheckResourceAttr("data.fluence_price_matrix.test", "cheapest.country", "DE"),
+					resource.TestCheckResourceAttr("data.fluence_price_matrix.test", "cheapest.price_per_epoch", "3"),
+					resource.TestCheckResourceAttr("data.fluence_price_matrix.test", "cheapest.max_price_per_epoch", "3"),
+					resource.TestCheckResourceAttr("data.fluence_price_matrix.test", "cheapest.deposit_amount_usdc", "6"),
+					resource.TestCheckResourceAttr("data.fluence_price_matrix.test", "cheapest.deposit_epochs", "2"),
+					resource.TestCheckNoResourceAttr("data.fluence_price_matrix.test", "cheapest.error"),
+				),
+			},
+			{
+				Config:      testAccPriceMatrixConfig(srv, `basic_configurations = ["cpu-64-ram-1tb-storage-1tb"]`),
+				ExpectError: regexp.MustCompile(`Unable to estimate any of the 1 combinations`),
+			},
+			{
+				Config: testAccPriceMatrixConfig(srv, `
+  basic_configurations = ["cpu-2-ram-4gb-storage-25gb"]
+  countries            = ["Germany"]
+`),
+				ExpectError: regexp.MustCompile(`Invalid Country Code`),
+			},
+			// Without countries, each configuration is estimated anywhere
+			{
+				Config: testAccPriceMatrixConfig(srv, `basic_configurations = ["cpu-8-ram-16gb-storage-50gb"]`),
+				Check: resource.ComposeAggregateTestCheckFunc(
+					resource.TestCheckResourceAttr("data.fluence_price_matrix.test", "entries.#", "1"),
+					resource.TestCheckNoResourceAttr("data.fluence_price_matrix.test", "cheapest.country"),
+					resource.TestCheckResourceAttr("data.fluence_price_matrix.test", "cheapest.price_per_epoch", "6"),
+				),
+			},
+		},
+	})
+}
+
+func testAccPriceMatrixConfig(srv *fakeapi.Server, arguments string) string {
+	return testAccProviderConfig(srv) + fmt.Sprintf(`
+data "fluence_price_matrix" "test" {
+%s
+}
+`, arguments)
+}
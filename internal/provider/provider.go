@@ -2,10 +2,15 @@ package provider
 
 import (
 	"context"
+	"fmt"
+	"net/http"
 	"os"
+	"strconv"
+	"time"
 
 	fluenceapi "github.com/decentralized-infrastructure/fluence-api-client-go"
 	"github.com/hashicorp/terraform-plugin-framework/datasource"
+	"github.com/hashicorp/terraform-plugin-framework/diag"
 	"github.com/hashicorp/terraform-plugin-framework/path"
 	"github.com/hashicorp/terraform-plugin-framework/provider"
 	"github.com/hashicorp/terraform-plugin-framework/provider/schema"
@@ -54,14 +59,54 @@ func (p *fluenceProvider) Schema(_ context.Context, _ provider.SchemaRequest, re
 				Sensitive:   true,
 				Description: "The Fluence API key. Can also be set via the FLUENCE_API_KEY environment variable.",
 			},
+			"poll_interval": schema.StringAttribute{
+				Optional:    true,
+				Description: "Initial delay between API polls while waiting for VM state changes, as a duration string such as \"2s\". The delay doubles after each poll up to max_poll_interval. Defaults to \"2s\".",
+			},
+			"max_poll_interval": schema.StringAttribute{
+				Optional:    true,
+				Description: "Maximum delay between API polls while waiting for VM state changes, as a duration string such as \"30s\". Defaults to \"30s\".",
+			},
+			"retry_max_attempts": schema.Int64Attribute{
+				Optional:    true,
+				Description: "Maximum number of attempts for each Fluence API call that fails with 429 Too Many Requests or a transient 5xx error. Set to 1 to disable retries. Calls that create VMs or SSH keys are only retried after a 429 response. Defaults to 4.",
+			},
+			"retry_max_backoff": schema.StringAttribute{
+				Optional:    true,
+				Description: "Maximum delay between two attempts of a Fluence API call, as a duration string such as \"30s\". Also caps delays requested by the API through the Retry-After header. Each call, retries included, must complete within the 10 second API client timeout, so no attempt is made that would start after it. Defaults to \"30s\".",
+			},
+			"max_total_price_per_epoch_usd": schema.StringAttribute{
+				Optional:    true,
+				Description: "Account-wide limit on the total price per epoch in USD. Before VMs are created, the prices of the existing VMs are added to the highest estimated price of the new ones, and the creation is refused if the sum exceeds this limit. `fluence_vm` and `fluence_vm_group` plans that add VMs are also checked against the same highest estimated price, so an apply is not refused halfway through.",
+			},
+			"max_vm_count": schema.Int64Attribute{
+				Optional:    true,
+				Description: "Account-wide limit on the number of VMs. Creations that would bring the account above this count are refused, and `fluence_vm` and `fluence_vm_group` plans that would are rejected.",
+			},
 		},
 	}
 }
 
 // fluenceProviderModel maps provider schema data to a Go type.
 type fluenceProviderModel struct {
-	Host   types.String `tfsdk:"host"`
-	ApiKey types.String `tfsdk:"api_key"`
+	Host             types.String `tfsdk:"host"`
+	ApiKey           types.String `tfsdk:"api_key"`
+	PollInterval     types.String `tfsdk:"poll_interval"`
+	MaxPollInterval  types.String `tfsdk:"max_poll_interval"`
+	RetryMaxAttempts types.Int64  `tfsdk:"retry_max_attempts"`
+	RetryMaxBackoff  types.String `tfsdk:"retry_max_backoff"`
+
+	MaxTotalPricePerEpochUsd types.String `tfsdk:"max_total_price_per_epoch_usd"`
+	MaxVmCount               types.Int64  `tfsdk:"max_vm_count"`
+}
+
+// fluenceProviderData is handed to resources during Configure. It carries
+// the API client together with the provider-level settings.
+type fluenceProviderData struct {
+	client  *fluenceapi.Client
+	cache   *apiCache
+	polling pollConfig
+	guard   *spendingGuard
 }
 
 // Configure prepares a Fluence API client for data sources and resources.
@@ -141,6 +186,59 @@ func (p *fluenceProvider) Configure(ctx context.Context, req provider.ConfigureR
 		)
 	}
 
+	// Parse the polling settings
+	polling := defaultPollConfig()
+	polling.Interval = parseDuration(config.PollInterval, path.Root("poll_interval"), polling.Interval, &resp.Diagnostics)
+	polling.MaxInterval = parseDuration(config.MaxPollInterval, path.Root("max_poll_interval"), polling.MaxInterval, &resp.Diagnostics)
+
+	if !resp.Diagnostics.HasError() && polling.MaxInterval < polling.Interval {
+		resp.Diagnostics.AddAttributeError(
+			path.Root("max_poll_interval"),
+			"Invalid Poll Interval",
+			fmt.Sprintf("max_poll_interval (%s) must not be shorter than poll_interval (%s).", polling.MaxInterval, polling.Interval),
+		)
+	}
+
+	// Parse the retry settings
+	retry := defaultRetryConfig()
+	retry.MaxBackoff = parseDuration(config.RetryMaxBackoff, path.Root("retry_max_backoff"), retry.MaxBackoff, &resp.Diagnostics)
+
+	if !config.RetryMaxAttempts.IsNull() && !config.RetryMaxAttempts.IsUnknown() {
+		if config.RetryMaxAttempts.ValueInt64() < 1 {
+			resp.Diagnostics.AddAttributeError(
+				path.Root("retry_max_attempts"),
+				"Invalid Retry Attempts",
+				fmt.Sprintf("retry_max_attempts must be at least 1, got: %d.", config.RetryMaxAttempts.ValueInt64()),
+			)
+		}
+		retry.MaxAttempts = int(config.RetryMaxAttempts.ValueInt64())
+	}
+
+	// Parse the spending limits
+	var limits spendingLimits
+	if !config.MaxTotalPricePerEpochUsd.IsNull() && !config.MaxTotalPricePerEpochUsd.IsUnknown() {
+		maxPrice, err := strconv.ParseFloat(config.MaxTotalPricePerEpochUsd.ValueString(), 64)
+		if err != nil || maxPrice <= 0 {
+			resp.Diagnostics.AddAttributeError(
+				path.Root("max_total_price_per_epoch_usd"),
+				"Invalid Spending Limit",
+				fmt.Sprintf("max_total_price_per_epoch_usd must be a positive decimal amount, got: %q.", config.MaxTotalPricePerEpochUsd.ValueString()),
+			)
+		}
+		limits.MaxTotalPricePerEpoch = maxPrice
+	}
+
+	if !config.MaxVmCount.IsNull() && !config.MaxVmCount.IsUnknown() {
+		if config.MaxVmCount.ValueInt64() < 1 {
+			resp.Diagnostics.AddAttributeError(
+				path.Root("max_vm_count"),
+				"Invalid Spending Limit",
+				fmt.Sprintf("max_vm_count must be at least 1, got: %d.", config.MaxVmCount.ValueInt64()),
+			)
+		}
+		limits.MaxVmCount = int(config.MaxVmCount.ValueInt64())
+	}
+
 	if resp.Diagnostics.HasError() {
 		return
 	}
@@ -157,10 +255,43 @@ func (p *fluenceProvider) Configure(ctx context.Context, req provider.ConfigureR
 		return
 	}
 
+	// Retry rate-limited and transient failures of every API call. The
+	// client timeout still bounds each call, retries included.
+	client.HTTPClient = &http.Client{
+		Transport: newRetryTransport(http.DefaultTransport, retry),
+		Timeout:   client.HTTPClient.Timeout,
+	}
+
 	// Make the Fluence client available during DataSource and Resource
 	// type Configure methods.
 	resp.DataSourceData = client
-	resp.ResourceData = client
+	resp.ResourceData = &fluenceProviderData{
+		client:  client,
+		cache:   newApiCache(client, apiCacheTTL),
+		polling: polling,
+		guard:   newSpendingGuard(client, limits),
+	}
+}
+
+// parseDuration parses an optional duration attribute, returning fallback
+// when it is not set. Invalid or non-positive values are reported as
+// attribute errors.
+func parseDuration(value types.String, attrPath path.Path, fallback time.Duration, diags *diag.Diagnostics) time.Duration {
+	if value.IsNull() || value.IsUnknown() {
+		return fallback
+	}
+
+	duration, err := time.ParseDuration(value.ValueString())
+	if err != nil || duration <= 0 {
+		diags.AddAttributeError(
+			attrPath,
+			"Invalid Duration",
+			fmt.Sprintf("The value %q is not a valid positive duration. Use a duration string such as \"5s\" or \"1m\".", value.ValueString()),
+		)
+		return fallback
+	}
+
+	return duration
 }
 
 // DataSources defines the data sources implemented in the provider.
@@ -168,10 +299,14 @@ func (p *fluenceProvider) DataSources(_ context.Context) []func() datasource.Dat
 	return []func() datasource.DataSource{
 		NewSshDataSource,
 		NewVmsDataSource,
+		NewVmDataSource,
 		NewBasicConfigurationsDataSource,
+		NewBasicConfigurationDataSource,
 		NewAvailableCountriesDataSource,
 		NewAvailableHardwareDataSource,
 		NewEstimateDepositDataSource,
+		NewOffersDataSource,
+		NewPriceMatrixDataSource,
 		NewDatacentersDataSource,
 		NewDefaultImagesDataSource,
 	}
@@ -182,5 +317,6 @@ func (p *fluenceProvider) Resources(_ context.Context) []func() resource.Resourc
 	return []func() resource.Resource{
 		NewSshKeyResource,
 		NewVmResource,
+		NewVmGroupResource,
 	}
 }
@@ -0,0 +1,89 @@
+package provider
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"fmt"
+	"strings"
+	"testing"
+
+	fluenceapi "github.com/decentralized-infrastructure/fluence-api-client-go"
+	"github.com/hashicorp/terraform-plugin-framework/providerserver"
+	"github.com/hashicorp/terraform-plugin-go/tfprotov6"
+	"golang.org/x/crypto/ssh"
+
+	"terraform-provider-fluence/internal/fakeapi"
+)
+
+// testAccApiKey is the API key the fake API expects from the provider.
+const testAccApiKey = "test-api-key"
+
+// testAccOsImage is the image of the VMs created by the acceptance tests.
+const testAccOsImage = "https://cloud-images.ubuntu.com/releases/24.04/release/ubuntu-24.04-server-cloudimg-amd64.img"
+
+// testAccProtoV6ProviderFactories are used to instantiate a provider during
+// acceptance testing. The factory function will be invoked for every Terraform
+// CLI command executed to create a provider server to which the CLI can
+// reattach.
+var testAccProtoV6ProviderFactories = map[string]func() (tfprotov6.ProviderServer, error){
+	"fluence": providerserver.NewProtocol6WithError(New("test")()),
+}
+
+// testAccServer starts a fake Fluence API that is closed when the test ends.
+func testAccServer(t *testing.T, opts ...fakeapi.Option) *fakeapi.Server {
+	t.Helper()
+
+	srv := fakeapi.NewServer(append([]fakeapi.Option{fakeapi.WithApiKey(testAccApiKey)}, opts...)...)
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+// testAccClient returns a client of srv, to create objects outside of
+// Terraform.
+func testAccClient(t *testing.T, srv *fakeapi.Server) *fluenceapi.Client {
+	t.Helper()
+
+	apiKey := testAccApiKey
+	client, err := fluenceapi.NewClient(&srv.URL, &apiKey)
+	if err != nil {
+		t.Fatalf("unable to create a client: %s", err)
+	}
+	return client
+}
+
+// testAccProviderConfig configures the provider against srv. Polls and
+// retries are shortened so that waiting for the fake takes milliseconds.
+// extra is added to the provider block.
+func testAccProviderConfig(srv *fakeapi.Server, extra ...string) string {
+	return fmt.Sprintf(`
+provider "fluence" {
+  host              = %q
+  api_key           = %q
+  poll_interval     = "10ms"
+  max_poll_interval = "50ms"
+  retry_max_backoff = "10ms"
+%s
+}
+`, srv.URL, testAccApiKey, strings.Join(extra, "\n"))
+}
+
+// testAccPublicKey returns a new ed25519 public key as an authorized_keys
+// line with the given comment.
+func testAccPublicKey(t *testing.T, comment string) string {
+	t.Helper()
+
+	public, _, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatalf("unable to generate a key: %s", err)
+	}
+	key, err := ssh.NewPublicKey(public)
+	if err != nil {
+		t.Fatalf("unable to encode the key: %s", err)
+	}
+
+	line := strings.TrimSpace(string(ssh.MarshalAuthorizedKey(key)))
+	if comment != "" {
+		line += " " + comment
+	}
+	return line
+}
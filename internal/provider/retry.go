@@ -0,0 +1,265 @@
+package provider
+
+import (
+	"context"
+	"errors"
+	"io"
+	"math/rand/v2"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	fluenceapi "github.com/decentralized-infrastructure/fluence-api-client-go"
+	"github.com/hashicorp/go-uuid"
+)
+
+// Default retry settings used when the provider configuration does not set them.
+const (
+	defaultRetryMaxAttempts = 4
+	defaultRetryMaxBackoff  = 30 * time.Second
+	retryBaseBackoff        = 1 * time.Second
+)
+
+// retryConfig controls how failed Fluence API calls are retried.
+type retryConfig struct {
+	// MaxAttempts is the total number of attempts per API call, including
+	// the first one. A value of 1 disables retries.
+	MaxAttempts int
+
+	// MaxBackoff caps the delay between two attempts, including delays
+	// requested by the API through the Retry-After header.
+	MaxBackoff time.Duration
+}
+
+// defaultRetryConfig returns the retry settings used when none are configured.
+func defaultRetryConfig() retryConfig {
+	return retryConfig{
+		MaxAttempts: defaultRetryMaxAttempts,
+		MaxBackoff:  defaultRetryMaxBackoff,
+	}
+}
+
+// retryTransport is an http.RoundTripper that retries rate-limited and
+// transient failures of the Fluence API. It is installed on the API client
+// so every resource and data source benefits from it.
+//
+// Calls that create billable objects are not idempotent. They are only
+// retried when the API rejected them with 429 Too Many Requests, which
+// guarantees they were not processed.
+//
+// The API client does not pass a context with its requests, so the waits
+// between attempts also stop when ctx is done. See withContext.
+type retryTransport struct {
+	base   http.RoundTripper
+	config retryConfig
+	ctx    context.Context
+}
+
+// newRetryTransport wraps base with the retry behaviour described by config.
+func newRetryTransport(base http.RoundTripper, config retryConfig) *retryTransport {
+	return &retryTransport{
+		base:   base,
+		config: config,
+	}
+}
+
+// withContext returns a shallow copy of client whose retries stop as soon as
+// ctx is done, so that cancelling an operation or reaching its timeout does
+// not leave it waiting for the next attempt. Clients without a retry
+// transport are returned as is.
+func withContext(ctx context.Context, client *fluenceapi.Client) *fluenceapi.Client {
+	transport, ok := client.HTTPClient.Transport.(*retryTransport)
+	if !ok {
+		return client
+	}
+
+	bound := *transport
+	bound.ctx = ctx
+
+	c := *client
+	c.HTTPClient = &http.Client{
+		Transport: &bound,
+		Timeout:   client.HTTPClient.Timeout,
+	}
+	return &c
+}
+
+// RoundTrip implements http.RoundTripper.
+func (t *retryTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	idempotent := isIdempotentRequest(req)
+
+	ctx := t.ctx
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	for attempt := 1; ; attempt++ {
+		attemptReq := req
+		if attempt > 1 && req.Body != nil {
+			// Requests created with a byte buffer body can be replayed
+			if req.GetBody == nil {
+				return nil, errRequestNotReplayable
+			}
+			body, err := req.GetBody()
+			if err != nil {
+				return nil, err
+			}
+			attemptReq = req.Clone(req.Context())
+			attemptReq.Body = body
+		}
+
+		resp, err := t.base.RoundTrip(attemptReq)
+
+		// A retried delete that finds nothing was completed by an earlier
+		// attempt whose response was lost
+		if attempt > 1 && err == nil && req.Method == http.MethodDelete && resp.StatusCode == http.StatusNotFound {
+			return alreadyDeletedResponse(req, resp), nil
+		}
+
+		if attempt >= t.config.MaxAttempts || !shouldRetry(resp, err, idempotent) {
+			return resp, err
+		}
+
+		// Report the last failure rather than a timeout when the client
+		// timeout would expire before the next attempt
+		delay := t.backoff(attempt, resp)
+		if deadline, ok := req.Context().Deadline(); ok && time.Until(deadline) < delay {
+			return resp, err
+		}
+
+		// Release the connection of the failed attempt before retrying
+		if resp != nil {
+			_, _ = io.Copy(io.Discard, resp.Body)
+			resp.Body.Close()
+		}
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-req.Context().Done():
+			timer.Stop()
+			return nil, req.Context().Err()
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
+
+// alreadyDeletedResponse replaces the 404 Not Found response of a retried
+// delete with an empty success.
+func alreadyDeletedResponse(req *http.Request, resp *http.Response) *http.Response {
+	_, _ = io.Copy(io.Discard, resp.Body)
+	resp.Body.Close()
+
+	return &http.Response{
+		Status:        "200 OK",
+		StatusCode:    http.StatusOK,
+		Proto:         resp.Proto,
+		ProtoMajor:    resp.ProtoMajor,
+		ProtoMinor:    resp.ProtoMinor,
+		Header:        http.Header{"Content-Type": []string{"application/json"}},
+		Body:          io.NopCloser(strings.NewReader("{}")),
+		ContentLength: 2,
+		Request:       req,
+	}
+}
+
+// backoff returns the delay before the next attempt. A Retry-After header
+// sent by the API takes precedence over the exponential backoff. Both are
+// capped by MaxBackoff.
+func (t *retryTransport) backoff(attempt int, resp *http.Response) time.Duration {
+	maxBackoff := t.config.MaxBackoff
+	if maxBackoff <= 0 {
+		maxBackoff = defaultRetryMaxBackoff
+	}
+
+	if resp != nil {
+		if delay, ok := parseRetryAfter(resp.Header.Get("Retry-After")); ok {
+			return min(delay, maxBackoff)
+		}
+	}
+
+	delay := retryBaseBackoff
+	for i := 1; i < attempt && delay < maxBackoff; i++ {
+		delay *= 2
+	}
+	delay = min(delay, maxBackoff)
+
+	// Add jitter so that concurrent resources do not retry in lockstep
+	half := delay / 2
+	return half + rand.N(delay-half+1)
+}
+
+// errRequestNotReplayable is returned when a request must be retried but its
+// body cannot be read a second time.
+var errRequestNotReplayable = errors.New("request body cannot be replayed for a retry")
+
+// shouldRetry reports whether an attempt that produced resp or err is worth
+// retrying.
+func shouldRetry(resp *http.Response, err error, idempotent bool) bool {
+	if err != nil {
+		// The request may or may not have reached the API
+		return idempotent
+	}
+
+	switch resp.StatusCode {
+	case http.StatusTooManyRequests:
+		return true
+	case http.StatusInternalServerError, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return idempotent
+	default:
+		return false
+	}
+}
+
+// isIdempotentRequest reports whether repeating req cannot create duplicate
+// objects. Only the Fluence endpoints that create VMs or SSH keys are unsafe;
+// other POST endpoints such as estimates and offer searches are read-only.
+func isIdempotentRequest(req *http.Request) bool {
+	if req.Method != http.MethodPost {
+		return true
+	}
+
+	p := strings.TrimSuffix(req.URL.Path, "/")
+	return !strings.HasSuffix(p, "/vms/v3") && !strings.HasSuffix(p, "/ssh_keys")
+}
+
+// parseRetryAfter parses a Retry-After header given either in seconds or as
+// an HTTP date.
+func parseRetryAfter(value string) (time.Duration, bool) {
+	if value == "" {
+		return 0, false
+	}
+
+	if seconds, err := strconv.Atoi(strings.TrimSpace(value)); err == nil {
+		if seconds < 0 {
+			return 0, false
+		}
+		return time.Duration(seconds) * time.Second, true
+	}
+
+	if date, err := http.ParseTime(value); err == nil {
+		delay := time.Until(date)
+		if delay < 0 {
+			delay = 0
+		}
+		return delay, true
+	}
+
+	return 0, false
+}
+
+// newRequestId returns a unique identifier that lets the API recognise
+// repeated submissions of the same create request.
+func newRequestId() *string {
+	id, err := uuid.GenerateUUID()
+	if err != nil {
+		return nil
+	}
+	return &id
+}
@@ -0,0 +1,330 @@
+package provider
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	fluenceapi "github.com/decentralized-infrastructure/fluence-api-client-go"
+)
+
+// stubTransport replies with the given statuses in order, recording the
+// bodies it received. A zero status stands for a network error.
+type stubTransport struct {
+	statuses   []int
+	retryAfter string
+	bodies     []string
+}
+
+func (s *stubTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	body := ""
+	if req.Body != nil {
+		b, _ := io.ReadAll(req.Body)
+		body = string(b)
+	}
+	s.bodies = append(s.bodies, body)
+
+	status := s.statuses[0]
+	if len(s.statuses) > 1 {
+		s.statuses = s.statuses[1:]
+	}
+	if status == 0 {
+		return nil, errors.New("connection reset")
+	}
+
+	resp := &http.Response{
+		StatusCode: status,
+		Header:     http.Header{},
+		Body:       io.NopCloser(strings.NewReader("")),
+	}
+	if s.retryAfter != "" {
+		resp.Header.Set("Retry-After", s.retryAfter)
+	}
+	return resp, nil
+}
+
+func TestRetryTransport(t *testing.T) {
+	tests := map[string]struct {
+		method     string
+		path       string
+		statuses   []int
+		attempts   int
+		wantStatus int
+		wantErr    bool
+	}{
+		"success": {
+			method:     http.MethodGet,
+			path:       "/vms/v3",
+			statuses:   []int{200},
+			attempts:   1,
+			wantStatus: 200,
+		},
+		"rate limited list": {
+			method:     http.MethodGet,
+			path:       "/vms/v3",
+			statuses:   []int{429, 429, 200},
+			attempts:   3,
+			wantStatus: 200,
+		},
+		"transient list failure": {
+			method:     http.MethodGet,
+			path:       "/vms/v3",
+			statuses:   []int{503, 502, 200},
+			attempts:   3,
+			wantStatus: 200,
+		},
+		"network error on list": {
+			method:     http.MethodGet,
+			path:       "/ssh_keys",
+			statuses:   []int{0, 200},
+			attempts:   2,
+			wantStatus: 200,
+		},
+		"attempts exhausted": {
+			method:     http.MethodGet,
+			path:       "/vms/v3",
+			statuses:   []int{503},
+			attempts:   4,
+			wantStatus: 503,
+		},
+		"client error": {
+			method:     http.MethodGet,
+			path:       "/vms/v3",
+			statuses:   []int{404},
+			attempts:   1,
+			wantStatus: 404,
+		},
+		"rate limited create": {
+			method:     http.MethodPost,
+			path:       "/vms/v3",
+			statuses:   []int{429, 201},
+			attempts:   2,
+			wantStatus: 201,
+		},
+		"failed create": {
+			method:     http.MethodPost,
+			path:       "/vms/v3",
+			statuses:   []int{503, 201},
+			attempts:   1,
+			wantStatus: 503,
+		},
+		"network error on create": {
+			method:   http.MethodPost,
+			path:     "/ssh_keys/",
+			statuses: []int{0, 201},
+			attempts: 1,
+			wantErr:  true,
+		},
+		"retried delete already done": {
+			method:     http.MethodDelete,
+			path:       "/vms/v3",
+			statuses:   []int{502, 404},
+			attempts:   2,
+			wantStatus: 200,
+		},
+		"delete not found": {
+			method:     http.MethodDelete,
+			path:       "/vms/v3",
+			statuses:   []int{404},
+			attempts:   1,
+			wantStatus: 404,
+		},
+		"failed estimate": {
+			method:     http.MethodPost,
+			path:       "/vms/v3/estimate",
+			statuses:   []int{500, 200},
+			attempts:   2,
+			wantStatus: 200,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			stub := &stubTransport{statuses: test.statuses}
+			transport := newRetryTransport(stub, retryConfig{MaxAttempts: 4, MaxBackoff: time.Millisecond})
+
+			req, err := http.NewRequest(test.method, "https://api.fluence.dev"+test.path, bytes.NewBufferString(`{"name":"test"}`))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			resp, err := transport.RoundTrip(req)
+			if test.wantErr {
+				if err == nil {
+					t.Fatalf("expected an error, got status %d", resp.StatusCode)
+				}
+			} else {
+				if err != nil {
+					t.Fatalf("unexpected error: %s", err)
+				}
+				if resp.StatusCode != test.wantStatus {
+					t.Errorf("status = %d, want %d", resp.StatusCode, test.wantStatus)
+				}
+			}
+
+			if len(stub.bodies) != test.attempts {
+				t.Errorf("attempts = %d, want %d", len(stub.bodies), test.attempts)
+			}
+			for i, body := range stub.bodies {
+				if body != `{"name":"test"}` {
+					t.Errorf("attempt %d sent body %q", i+1, body)
+				}
+			}
+		})
+	}
+}
+
+func TestRetryTransport_disabled(t *testing.T) {
+	stub := &stubTransport{statuses: []int{429, 200}}
+	transport := newRetryTransport(stub, retryConfig{MaxAttempts: 1, MaxBackoff: time.Millisecond})
+
+	req, _ := http.NewRequest(http.MethodGet, "https://api.fluence.dev/vms/v3", nil)
+	resp, err := transport.RoundTrip(req)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if resp.StatusCode != 429 || len(stub.bodies) != 1 {
+		t.Errorf("got status %d after %d attempt(s), want 429 after 1", resp.StatusCode, len(stub.bodies))
+	}
+}
+
+func TestRetryTransport_cancelled(t *testing.T) {
+	stub := &stubTransport{statuses: []int{503}}
+	transport := newRetryTransport(stub, retryConfig{MaxAttempts: 4, MaxBackoff: time.Hour})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, "https://api.fluence.dev/vms/v3", nil)
+
+	if _, err := transport.RoundTrip(req); !errors.Is(err, context.Canceled) {
+		t.Errorf("error = %v, want %v", err, context.Canceled)
+	}
+	if len(stub.bodies) != 1 {
+		t.Errorf("attempts = %d, want 1", len(stub.bodies))
+	}
+}
+
+func TestRetryTransport_operationCancelled(t *testing.T) {
+	stub := &stubTransport{statuses: []int{503}}
+	apiKey := "test"
+	client, _ := fluenceapi.NewClient(nil, &apiKey)
+	client.HTTPClient = &http.Client{Transport: newRetryTransport(stub, retryConfig{MaxAttempts: 4, MaxBackoff: time.Hour})}
+
+	// The client requests carry no context, only the operation has one
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+
+	done := make(chan error, 1)
+	go func() {
+		_, err := withContext(ctx, client).ListVmsV3()
+		done <- err
+	}()
+
+	select {
+	case err := <-done:
+		if !errors.Is(err, context.DeadlineExceeded) {
+			t.Errorf("error = %v, want %v", err, context.DeadlineExceeded)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("the retries did not stop with the operation")
+	}
+	if len(stub.bodies) != 1 {
+		t.Errorf("attempts = %d, want 1", len(stub.bodies))
+	}
+}
+
+func TestRetryTransport_clientTimeout(t *testing.T) {
+	stub := &stubTransport{statuses: []int{429, 200}, retryAfter: "60"}
+	client := &http.Client{
+		Transport: newRetryTransport(stub, retryConfig{MaxAttempts: 4, MaxBackoff: time.Hour}),
+		Timeout:   time.Second,
+	}
+
+	// The requested delay does not fit in the client timeout, so the rate
+	// limit is reported rather than the timeout
+	resp, err := client.Get("https://api.fluence.dev/vms/v3")
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if resp.StatusCode != 429 || len(stub.bodies) != 1 {
+		t.Errorf("got status %d after %d attempt(s), want 429 after 1", resp.StatusCode, len(stub.bodies))
+	}
+}
+
+func TestWithContext(t *testing.T) {
+	apiKey := "test"
+	client, _ := fluenceapi.NewClient(nil, &apiKey)
+	if withContext(context.Background(), client) != client {
+		t.Error("a client without retries was copied")
+	}
+
+	transport := newRetryTransport(http.DefaultTransport, defaultRetryConfig())
+	client.HTTPClient = &http.Client{Transport: transport, Timeout: 10 * time.Second}
+
+	ctx := context.WithValue(context.Background(), struct{}{}, "operation")
+	bound := withContext(ctx, client)
+	if bound == client || bound.HTTPClient.Timeout != 10*time.Second || bound.HostURL != client.HostURL {
+		t.Errorf("withContext() = %+v, want a copy of %+v", bound, client)
+	}
+	if bound.HTTPClient.Transport.(*retryTransport).ctx != ctx {
+		t.Error("the copy is not bound to the context")
+	}
+	if transport.ctx != nil {
+		t.Error("the shared transport was bound to the context")
+	}
+}
+
+func TestRetryTransportBackoff(t *testing.T) {
+	transport := newRetryTransport(nil, retryConfig{MaxAttempts: 4, MaxBackoff: 10 * time.Second})
+
+	for attempt, want := range map[int]time.Duration{1: time.Second, 2: 2 * time.Second, 3: 4 * time.Second, 5: 10 * time.Second, 10: 10 * time.Second} {
+		delay := transport.backoff(attempt, nil)
+		if delay < want/2 || delay > want {
+			t.Errorf("backoff(%d) = %s, want between %s and %s", attempt, delay, want/2, want)
+		}
+	}
+
+	resp := &http.Response{Header: http.Header{"Retry-After": []string{"3"}}}
+	if delay := transport.backoff(1, resp); delay != 3*time.Second {
+		t.Errorf("backoff with Retry-After 3 = %s, want 3s", delay)
+	}
+
+	resp.Header.Set("Retry-After", "3600")
+	if delay := transport.backoff(1, resp); delay != 10*time.Second {
+		t.Errorf("backoff with Retry-After 3600 = %s, want the 10s cap", delay)
+	}
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	tests := map[string]struct {
+		value string
+		want  time.Duration
+		ok    bool
+	}{
+		"empty":    {value: "", ok: false},
+		"seconds":  {value: "120", want: 2 * time.Minute, ok: true},
+		"spaces":   {value: " 5 ", want: 5 * time.Second, ok: true},
+		"negative": {value: "-1", ok: false},
+		"past":     {value: "Mon, 02 Jan 2006 15:04:05 GMT", want: 0, ok: true},
+		"invalid":  {value: "soon", ok: false},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			got, ok := parseRetryAfter(test.value)
+			if ok != test.ok || got != test.want {
+				t.Errorf("parseRetryAfter(%q) = %s, %t, want %s, %t", test.value, got, ok, test.want, test.ok)
+			}
+		})
+	}
+
+	future := time.Now().Add(time.Minute).UTC().Format(http.TimeFormat)
+	if got, ok := parseRetryAfter(future); !ok || got <= 0 || got > time.Minute {
+		t.Errorf("parseRetryAfter(%q) = %s, %t, want up to 1m", future, got, ok)
+	}
+}
@@ -107,7 +107,7 @@ type sshKeysModel struct {
 func (d *sshDataSource) Read(ctx context.Context, req datasource.ReadRequest, resp *datasource.ReadResponse) {
 	var state sshKeysDataSourceModel
 
-	sshKeys, err := d.client.ListSshKeys()
+	sshKeys, err := withContext(ctx, d.client).ListSshKeys()
 	if err != nil {
 		resp.Diagnostics.AddError(
 			"Unable to Read Fluence SSH Keys",
@@ -0,0 +1,51 @@
+package provider
+
+import (
+	"net/http"
+	"testing"
+
+	fluenceapi "github.com/decentralized-infrastructure/fluence-api-client-go"
+	"github.com/hashicorp/terraform-plugin-testing/helper/resource"
+)
+
+func TestAccSshKeysDataSource(t *testing.T) {
+	srv := testAccServer(t)
+	client := testAccClient(t, srv)
+	publicKey := testAccPublicKey(t, "acc@example")
+	key, err := client.CreateSshKey(fluenceapi.AddSshKey{Name: "acc-key", PublicKey: publicKey})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := client.CreateSshKey(fluenceapi.AddSshKey{Name: "other-key", PublicKey: testAccPublicKey(t, "")}); err != nil {
+		t.Fatal(err)
+	}
+
+	resource.Test(t, resource.TestCase{
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		Steps: []resource.TestStep{
+			// A server error is retried
+			{
+				PreConfig: func() {
+					srv.InjectFault(http.MethodGet, "/ssh_keys", http.StatusBadGateway, 1, 0)
+				},
+				Config: testAccProviderConfig(srv) + `data "fluence_ssh_keys" "test" {}`,
+				Check: resource.ComposeAggregateTestCheckFunc(
+					resource.TestCheckResourceAttr("data.fluence_ssh_keys.test", "ssh_keys.#", "2"),
+					resource.TestCheckTypeSetElemNestedAttrs("data.fluence_ssh_keys.test", "ssh_keys.*", map[string]string{
+						"name":        "acc-key",
+						"fingerprint": key.Fingerprint,
+						"algorithm":   "ssh-ed25519",
+						"comment":     "acc@example",
+						"public_key":  publicKey,
+						"active":      "true",
+						"created_at":  key.CreatedAt,
+					}),
+					resource.TestCheckTypeSetElemNestedAttrs("data.fluence_ssh_keys.test", "ssh_keys.*", map[string]string{
+						"name":    "other-key",
+						"comment": "",
+					}),
+				),
+			},
+		},
+	})
+}
@@ -3,13 +3,16 @@ package provider
 import (
 	"context"
 	"fmt"
+	"strings"
 
 	fluenceapi "github.com/decentralized-infrastructure/fluence-api-client-go"
 	"github.com/hashicorp/terraform-plugin-framework/path"
 	"github.com/hashicorp/terraform-plugin-framework/resource"
 	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/boolplanmodifier"
 	"github.com/hashicorp/terraform-plugin-framework/resource/schema/planmodifier"
 	"github.com/hashicorp/terraform-plugin-framework/resource/schema/stringplanmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/schema/validator"
 	"github.com/hashicorp/terraform-plugin-framework/types"
 	"github.com/hashicorp/terraform-plugin-log/tflog"
 )
@@ -17,6 +20,7 @@ import (
 // Ensure provider defined types fully satisfy framework interfaces.
 var _ resource.Resource = &SshKeyResource{}
 var _ resource.ResourceWithImportState = &SshKeyResource{}
+var _ resource.ResourceWithModifyPlan = &SshKeyResource{}
 
 func NewSshKeyResource() resource.Resource {
 	return &SshKeyResource{}
@@ -25,18 +29,19 @@ func NewSshKeyResource() resource.Resource {
 // SshKeyResource defines the resource implementation.
 type SshKeyResource struct {
 	client *fluenceapi.Client
+	cache  *apiCache
 }
 
 // SshKeyResourceModel describes the resource data model.
 type SshKeyResourceModel struct {
-	ID          types.String `tfsdk:"id"`
-	Name        types.String `tfsdk:"name"`
-	PublicKey   types.String `tfsdk:"public_key"`
-	Fingerprint types.String `tfsdk:"fingerprint"`
-	Algorithm   types.String `tfsdk:"algorithm"`
-	Comment     types.String `tfsdk:"comment"`
-	Active      types.Bool   `tfsdk:"active"`
-	CreatedAt   types.String `tfsdk:"created_at"`
+	ID          types.String      `tfsdk:"id"`
+	Name        types.String      `tfsdk:"name"`
+	PublicKey   sshPublicKeyValue `tfsdk:"public_key"`
+	Fingerprint types.String      `tfsdk:"fingerprint"`
+	Algorithm   types.String      `tfsdk:"algorithm"`
+	Comment     types.String      `tfsdk:"comment"`
+	Active      types.Bool        `tfsdk:"active"`
+	CreatedAt   types.String      `tfsdk:"created_at"`
 }
 
 func (r *SshKeyResource) Metadata(ctx context.Context, req resource.MetadataRequest, resp *resource.MetadataResponse) {
@@ -45,7 +50,7 @@ func (r *SshKeyResource) Metadata(ctx context.Context, req resource.MetadataRequ
 
 func (r *SshKeyResource) Schema(ctx context.Context, req resource.SchemaRequest, resp *resource.SchemaResponse) {
 	resp.Schema = schema.Schema{
-		MarkdownDescription: "SSH Key resource",
+		MarkdownDescription: "SSH Key resource. The Fluence API cannot update SSH keys, so changing `name` or `public_key` replaces the key. A new `public_key` has a new `fingerprint`, so `create_before_destroy` can be used to add the new key before VMs referencing it are replaced. Existing keys can be imported by fingerprint or by name, with no changes planned when the configured `public_key` only differs from the API in whitespace or comment",
 
 		Attributes: map[string]schema.Attribute{
 			"id": schema.StringAttribute{
@@ -56,32 +61,58 @@ func (r *SshKeyResource) Schema(ctx context.Context, req resource.SchemaRequest,
 				},
 			},
 			"name": schema.StringAttribute{
-				MarkdownDescription: "SSH Key name (optional)",
+				MarkdownDescription: "SSH Key name (optional). Changing this forces a new resource to be created",
 				Optional:            true,
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.RequiresReplace(),
+				},
 			},
 			"fingerprint": schema.StringAttribute{
 				Computed:            true,
-				MarkdownDescription: "SSH Key fingerprint",
+				MarkdownDescription: "SSH Key fingerprint, computed from `public_key` during plan",
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.UseStateForUnknown(),
+				},
 			},
 			"algorithm": schema.StringAttribute{
 				Computed:            true,
-				MarkdownDescription: "SSH Key algorithm",
+				MarkdownDescription: "SSH Key algorithm, computed from `public_key` during plan",
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.UseStateForUnknown(),
+				},
 			},
 			"comment": schema.StringAttribute{
 				Computed:            true,
 				MarkdownDescription: "SSH Key comment",
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.UseStateForUnknown(),
+				},
 			},
 			"active": schema.BoolAttribute{
 				Computed:            true,
 				MarkdownDescription: "Whether the SSH Key is active",
+				PlanModifiers: []planmodifier.Bool{
+					boolplanmodifier.UseStateForUnknown(),
+				},
 			},
 			"created_at": schema.StringAttribute{
 				Computed:            true,
 				MarkdownDescription: "SSH Key creation time",
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.UseStateForUnknown(),
+				},
 			},
 			"public_key": schema.StringAttribute{
 				Required:            true,
-				MarkdownDescription: "SSH public key content",
+				CustomType:          sshPublicKeyType{},
+				MarkdownDescription: "SSH public key content, as a single authorized_keys line. ed25519, RSA and ECDSA keys are accepted. Changing the key forces a new resource to be created, while whitespace and comment changes are ignored",
+				Validators: []validator.String{
+					sshPublicKeyValidator{},
+				},
+				PlanModifiers: []planmodifier.String{
+					sshPublicKeyUseStateForSameKey{},
+					stringplanmodifier.RequiresReplace(),
+				},
 			},
 		},
 	}
@@ -93,18 +124,43 @@ func (r *SshKeyResource) Configure(ctx context.Context, req resource.ConfigureRe
 		return
 	}
 
-	client, ok := req.ProviderData.(*fluenceapi.Client)
+	providerData, ok := req.ProviderData.(*fluenceProviderData)
 
 	if !ok {
 		resp.Diagnostics.AddError(
 			"Unexpected Resource Configure Type",
-			fmt.Sprintf("Expected *fluenceapi.Client, got: %T. Please report this issue to the provider developers.", req.ProviderData),
+			fmt.Sprintf("Expected *fluenceProviderData, got: %T. Please report this issue to the provider developers.", req.ProviderData),
 		)
 
 		return
 	}
 
-	r.client = client
+	r.client = providerData.client
+	r.cache = providerData.cache
+}
+
+// ModifyPlan fills in fingerprint and algorithm from the configured public
+// key, so that VMs referencing a new key get a known ssh_keys value.
+func (r *SshKeyResource) ModifyPlan(ctx context.Context, req resource.ModifyPlanRequest, resp *resource.ModifyPlanResponse) {
+	// Nothing to do on destroy
+	if req.Plan.Raw.IsNull() {
+		return
+	}
+
+	var publicKey sshPublicKeyValue
+	resp.Diagnostics.Append(req.Plan.GetAttribute(ctx, path.Root("public_key"), &publicKey)...)
+	if resp.Diagnostics.HasError() || publicKey.IsNull() || publicKey.IsUnknown() {
+		return
+	}
+
+	// Invalid keys are reported by the validator
+	key, err := parseSshPublicKey(publicKey.ValueString())
+	if err != nil {
+		return
+	}
+
+	resp.Diagnostics.Append(resp.Plan.SetAttribute(ctx, path.Root("fingerprint"), key.Fingerprint)...)
+	resp.Diagnostics.Append(resp.Plan.SetAttribute(ctx, path.Root("algorithm"), key.Algorithm)...)
 }
 
 func (r *SshKeyResource) Create(ctx context.Context, req resource.CreateRequest, resp *resource.CreateResponse) {
@@ -117,9 +173,16 @@ func (r *SshKeyResource) Create(ctx context.Context, req resource.CreateRequest,
 		return
 	}
 
-	// Create SSH key using the client
+	publicKey, err := parseSshPublicKey(data.PublicKey.ValueString())
+	if err != nil {
+		resp.Diagnostics.AddAttributeError(path.Root("public_key"), "Invalid SSH Public Key", fmt.Sprintf("The public key could not be parsed: %s.", err))
+		return
+	}
+
+	// Create SSH key using the client. The normalized key is sent, so that
+	// the API stores the same key whatever the spacing in the configuration.
 	createReq := fluenceapi.AddSshKey{
-		PublicKey: data.PublicKey.ValueString(),
+		PublicKey: publicKey.String(),
 	}
 
 	// Set name if provided
@@ -128,12 +191,30 @@ func (r *SshKeyResource) Create(ctx context.Context, req resource.CreateRequest,
 		createReq.Name = name
 	}
 
-	sshKey, err := r.client.CreateSshKey(createReq)
+	sshKey, err := withContext(ctx, r.client).CreateSshKey(createReq)
+	r.cache.InvalidateSshKeys()
 	if err != nil {
 		resp.Diagnostics.AddError("Client Error", fmt.Sprintf("Unable to create SSH key, got error: %s", err))
 		return
 	}
 
+	// Dependent resources reference the fingerprint computed at plan time,
+	// so a key the API identifies differently cannot be kept
+	if sshKey.Fingerprint != publicKey.Fingerprint || sshKey.Algorithm != publicKey.Algorithm {
+		detail := fmt.Sprintf("The API returned fingerprint %s and algorithm %s for a key the provider fingerprinted as %s of type %s.", sshKey.Fingerprint, sshKey.Algorithm, publicKey.Fingerprint, publicKey.Algorithm)
+		if sshKey.PublicKey != "" && !sameSshPublicKey(sshKey.PublicKey, publicKey.String()) {
+			detail = fmt.Sprintf("The API stored public key %q instead of %q.", sshKey.PublicKey, publicKey.String())
+		}
+
+		if err := withContext(ctx, r.client).RemoveSshKey(sshKey.Fingerprint); err != nil {
+			detail += fmt.Sprintf(" The created key could not be removed and must be deleted manually, got error: %s.", err)
+		}
+		r.cache.InvalidateSshKeys()
+
+		resp.Diagnostics.AddError("Unexpected SSH Key", detail+" Please report this issue to the provider developers.")
+		return
+	}
+
 	// Map response to resource model
 	// Use fingerprint as ID since it's unique and always returned
 	data.ID = types.StringValue(sshKey.Fingerprint)
@@ -142,10 +223,9 @@ func (r *SshKeyResource) Create(ctx context.Context, req resource.CreateRequest,
 	} else {
 		data.Name = types.StringNull()
 	}
-	// Keep the original public_key from the configuration since API doesn't return it
-	// data.PublicKey is already set from the plan data above
-	data.Fingerprint = types.StringValue(sshKey.Fingerprint)
-	data.Algorithm = types.StringValue(sshKey.Algorithm)
+	// Keep the planned fingerprint and algorithm, computed from the key
+	data.Fingerprint = types.StringValue(publicKey.Fingerprint)
+	data.Algorithm = types.StringValue(publicKey.Algorithm)
 	data.Comment = types.StringValue(sshKey.Comment)
 	data.Active = types.BoolValue(sshKey.Active)
 	data.CreatedAt = types.StringValue(sshKey.CreatedAt)
@@ -167,8 +247,9 @@ func (r *SshKeyResource) Read(ctx context.Context, req resource.ReadRequest, res
 		return
 	}
 
-	// Get all SSH keys and find the one matching our ID
-	sshKeys, err := r.client.ListSshKeys()
+	// Get all SSH keys and find the one matching our ID. The listing is
+	// shared with the other resources refreshed during this run.
+	sshKeys, err := r.cache.ListSshKeys(ctx)
 	if err != nil {
 		resp.Diagnostics.AddError("Client Error", fmt.Sprintf("Unable to read SSH keys, got error: %s", err))
 		return
@@ -195,10 +276,18 @@ func (r *SshKeyResource) Read(ctx context.Context, req resource.ReadRequest, res
 	} else {
 		data.Name = types.StringNull()
 	}
-	// Preserve the public_key from state since API doesn't return it
-	// data.PublicKey remains unchanged from the current state
+	// The semantic equality of the key type keeps the configured spelling
+	// of the key while the API holds the same key
+	if foundKey.PublicKey != "" {
+		data.PublicKey = newSshPublicKeyValue(foundKey.PublicKey)
+	}
 	data.Fingerprint = types.StringValue(foundKey.Fingerprint)
-	data.Algorithm = types.StringValue(foundKey.Algorithm)
+	// The algorithm is the one computed from the key during plan
+	if key, err := parseSshPublicKey(data.PublicKey.ValueString()); err == nil {
+		data.Algorithm = types.StringValue(key.Algorithm)
+	} else {
+		data.Algorithm = types.StringValue(foundKey.Algorithm)
+	}
 	data.Comment = types.StringValue(foundKey.Comment)
 	data.Active = types.BoolValue(foundKey.Active)
 	data.CreatedAt = types.StringValue(foundKey.CreatedAt)
@@ -217,12 +306,10 @@ func (r *SshKeyResource) Update(ctx context.Context, req resource.UpdateRequest,
 		return
 	}
 
-	// For now, SSH keys don't support update operations in the API
-	// We'll need to implement this if the API supports it in the future
-	resp.Diagnostics.AddError(
-		"Update Not Supported",
-		"SSH key updates are not currently supported. Please recreate the resource.",
-	)
+	// Every argument forces a replacement and whitespace and comment
+	// changes to public_key are not planned, so there is nothing to send.
+	// Keep the planned values.
+	resp.Diagnostics.Append(resp.State.Set(ctx, &data)...)
 }
 
 func (r *SshKeyResource) Delete(ctx context.Context, req resource.DeleteRequest, resp *resource.DeleteResponse) {
@@ -236,14 +323,55 @@ func (r *SshKeyResource) Delete(ctx context.Context, req resource.DeleteRequest,
 	}
 
 	// Delete the SSH key using the fingerprint
-	err := r.client.RemoveSshKey(data.Fingerprint.ValueString())
+	err := withContext(ctx, r.client).RemoveSshKey(data.Fingerprint.ValueString())
+	r.cache.InvalidateSshKeys()
 	if err != nil {
 		resp.Diagnostics.AddError("Client Error", fmt.Sprintf("Unable to delete SSH key, got error: %s", err))
 		return
 	}
 }
 
+// ImportState accepts either the fingerprint or the name of the key. Read
+// then recovers public_key from the API. A configuration holding the same key
+// in another spelling plans no changes, since sshPublicKeyUseStateForSameKey
+// keeps the imported value.
 func (r *SshKeyResource) ImportState(ctx context.Context, req resource.ImportStateRequest, resp *resource.ImportStateResponse) {
-	// Use the ID field for import
-	resource.ImportStatePassthroughID(ctx, path.Root("id"), req, resp)
+	sshKeys, err := r.cache.ListSshKeys(ctx)
+	if err != nil {
+		resp.Diagnostics.AddError("Client Error", fmt.Sprintf("Unable to read SSH keys, got error: %s", err))
+		return
+	}
+
+	var matches []string
+	for _, key := range sshKeys {
+		if key.Fingerprint == req.ID {
+			matches = []string{key.Fingerprint}
+			break
+		}
+		if key.Name != nil && *key.Name == req.ID {
+			matches = append(matches, key.Fingerprint)
+		}
+	}
+
+	if len(matches) == 0 {
+		resp.Diagnostics.AddError(
+			"SSH Key Not Found",
+			fmt.Sprintf("No SSH key has the fingerprint or name %q.", req.ID),
+		)
+		return
+	}
+	if len(matches) > 1 {
+		resp.Diagnostics.AddError(
+			"Ambiguous SSH Key Name",
+			fmt.Sprintf("%d SSH keys are named %q: %s. Import the key by fingerprint instead.", len(matches), req.ID, strings.Join(matches, ", ")),
+		)
+		return
+	}
+
+	tflog.Debug(ctx, "Importing SSH key", map[string]interface{}{
+		"import_id":   req.ID,
+		"fingerprint": matches[0],
+	})
+
+	resp.Diagnostics.Append(resp.State.SetAttribute(ctx, path.Root("id"), matches[0])...)
 }
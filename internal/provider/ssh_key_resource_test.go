@@ -0,0 +1,224 @@
+package provider
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strings"
+	"testing"
+
+	fluenceapi "github.com/decentralized-infrastructure/fluence-api-client-go"
+	"github.com/hashicorp/terraform-plugin-testing/helper/resource"
+	"github.com/hashicorp/terraform-plugin-testing/plancheck"
+	"github.com/hashicorp/terraform-plugin-testing/terraform"
+	"golang.org/x/crypto/ssh"
+
+	"terraform-provider-fluence/internal/fakeapi"
+)
+
+func TestAccSshKeyResource(t *testing.T) {
+	srv := testAccServer(t)
+	publicKey := testAccPublicKey(t, "acc@example")
+	parsed, err := parseSshPublicKey(publicKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	respelled := strings.ReplaceAll(publicKey, " ", "   ")
+	respelled = strings.TrimSuffix(respelled, "acc@example") + "another comment"
+
+	resource.Test(t, resource.TestCase{
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		CheckDestroy:             testAccCheckSshKeysDestroyed(srv),
+		Steps: []resource.TestStep{
+			// Create, with a rate-limited first attempt
+			{
+				PreConfig: func() {
+					srv.InjectFault(http.MethodPost, "/ssh_keys", http.StatusTooManyRequests, 1, 0)
+				},
+				Config: testAccSshKeyResourceConfig(srv, "acc-key", publicKey),
+				Check: resource.ComposeAggregateTestCheckFunc(
+					resource.TestCheckResourceAttr("fluence_ssh_key.test", "id", parsed.Fingerprint),
+					resource.TestCheckResourceAttr("fluence_ssh_key.test", "fingerprint", parsed.Fingerprint),
+					resource.TestCheckResourceAttr("fluence_ssh_key.test", "algorithm", "ssh-ed25519"),
+					resource.TestCheckResourceAttr("fluence_ssh_key.test", "comment", "acc@example"),
+					resource.TestCheckResourceAttr("fluence_ssh_key.test", "active", "true"),
+					resource.TestCheckResourceAttr("fluence_ssh_key.test", "public_key", publicKey),
+				),
+			},
+			// Whitespace and comment changes plan nothing
+			{
+				Config: testAccSshKeyResourceConfig(srv, "acc-key", respelled),
+				ConfigPlanChecks: resource.ConfigPlanChecks{
+					PreApply: []plancheck.PlanCheck{
+						plancheck.ExpectEmptyPlan(),
+					},
+				},
+			},
+			// Import by fingerprint
+			{
+				ResourceName:      "fluence_ssh_key.test",
+				ImportState:       true,
+				ImportStateVerify: true,
+			},
+			// Import by name
+			{
+				ResourceName:      "fluence_ssh_key.test",
+				ImportState:       true,
+				ImportStateId:     "acc-key",
+				ImportStateVerify: true,
+			},
+			// A new name replaces the resource
+			{
+				Config: testAccSshKeyResourceConfig(srv, "acc-key-renamed", publicKey),
+				ConfigPlanChecks: resource.ConfigPlanChecks{
+					PreApply: []plancheck.PlanCheck{
+						plancheck.ExpectResourceAction("fluence_ssh_key.test", plancheck.ResourceActionDestroyBeforeCreate),
+					},
+				},
+				Check: resource.TestCheckResourceAttr("fluence_ssh_key.test", "name", "acc-key-renamed"),
+			},
+			// A new key replaces the resource
+			{
+				Config: testAccSshKeyResourceConfig(srv, "acc-key-renamed", testAccPublicKey(t, "")),
+				ConfigPlanChecks: resource.ConfigPlanChecks{
+					PreApply: []plancheck.PlanCheck{
+						plancheck.ExpectResourceAction("fluence_ssh_key.test", plancheck.ResourceActionDestroyBeforeCreate),
+					},
+				},
+				Check: resource.ComposeAggregateTestCheckFunc(
+					resource.TestCheckResourceAttr("fluence_ssh_key.test", "comment", ""),
+					testAccCheckFakeSshKeys(srv, 1),
+				),
+			},
+		},
+	})
+}
+
+func TestAccSshKeyResource_adopt(t *testing.T) {
+	srv := testAccServer(t)
+	publicKey := testAccPublicKey(t, "created@console")
+	key, err := testAccClient(t, srv).CreateSshKey(fluenceapi.AddSshKey{Name: "acc-key", PublicKey: publicKey})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The configuration spells the key differently from the API
+	respelled := strings.Replace(publicKey, "created@console", "user@laptop", 1) + "\n"
+
+	resource.Test(t, resource.TestCase{
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		CheckDestroy:             testAccCheckSshKeysDestroyed(srv),
+		Steps: []resource.TestStep{
+			{
+				Config:             testAccSshKeyResourceConfig(srv, "acc-key", respelled),
+				ResourceName:       "fluence_ssh_key.test",
+				ImportState:        true,
+				ImportStateId:      key.Fingerprint,
+				ImportStatePersist: true,
+			},
+			{
+				Config: testAccSshKeyResourceConfig(srv, "acc-key", respelled),
+				ConfigPlanChecks: resource.ConfigPlanChecks{
+					PreApply: []plancheck.PlanCheck{
+						plancheck.ExpectEmptyPlan(),
+					},
+				},
+				Check: resource.ComposeAggregateTestCheckFunc(
+					resource.TestCheckResourceAttr("fluence_ssh_key.test", "id", key.Fingerprint),
+					resource.TestCheckResourceAttr("fluence_ssh_key.test", "public_key", publicKey),
+					resource.TestCheckResourceAttr("fluence_ssh_key.test", "comment", "created@console"),
+				),
+			},
+		},
+	})
+}
+
+func TestAccSshKeyResource_importUnknown(t *testing.T) {
+	srv := testAccServer(t)
+
+	resource.Test(t, resource.TestCase{
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		Steps: []resource.TestStep{
+			{
+				Config:        testAccSshKeyResourceConfig(srv, "acc-key", testAccPublicKey(t, "")),
+				ResourceName:  "fluence_ssh_key.test",
+				ImportState:   true,
+				ImportStateId: "missing",
+				ExpectError:   regexp.MustCompile(`SSH Key Not Found`),
+			},
+		},
+	})
+}
+
+func TestAccSshKeyResource_unexpectedKey(t *testing.T) {
+	api := fakeapi.New(fakeapi.WithApiKey(testAccApiKey))
+
+	// The API reports another algorithm than the one of the created key
+	srv := &fakeapi.Server{API: api, Server: httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost || r.URL.Path != "/ssh_keys" {
+			api.ServeHTTP(w, r)
+			return
+		}
+
+		rec := httptest.NewRecorder()
+		api.ServeHTTP(rec, r)
+		var key fluenceapi.SshKey
+		if err := json.Unmarshal(rec.Body.Bytes(), &key); err != nil {
+			w.WriteHeader(rec.Code)
+			w.Write(rec.Body.Bytes())
+			return
+		}
+		key.Algorithm = ssh.KeyAlgoRSA
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(rec.Code)
+		json.NewEncoder(w).Encode(key)
+	}))}
+	t.Cleanup(srv.Close)
+
+	resource.Test(t, resource.TestCase{
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		CheckDestroy:             testAccCheckSshKeysDestroyed(srv),
+		Steps: []resource.TestStep{
+			{
+				Config:      testAccSshKeyResourceConfig(srv, "acc-key", testAccPublicKey(t, "")),
+				ExpectError: regexp.MustCompile(`Unexpected SSH Key`),
+			},
+			// The created key has been removed
+			{
+				Config: testAccProviderConfig(srv),
+				Check:  testAccCheckSshKeysDestroyed(srv),
+			},
+		},
+	})
+}
+
+func testAccSshKeyResourceConfig(srv *fakeapi.Server, name, publicKey string) string {
+	return testAccProviderConfig(srv) + fmt.Sprintf(`
+resource "fluence_ssh_key" "test" {
+  name       = %q
+  public_key = %q
+}
+`, name, publicKey)
+}
+
+// testAccCheckFakeSshKeys checks that the fake holds count SSH keys.
+func testAccCheckFakeSshKeys(srv *fakeapi.Server, count int) resource.TestCheckFunc {
+	return func(_ *terraform.State) error {
+		if keys := srv.SshKeys(); len(keys) != count {
+			return fmt.Errorf("expected %d SSH key(s), got %d", count, len(keys))
+		}
+		return nil
+	}
+}
+
+// testAccCheckSshKeysDestroyed checks that the fake holds no SSH key.
+func testAccCheckSshKeysDestroyed(srv *fakeapi.Server) resource.TestCheckFunc {
+	return func(_ *terraform.State) error {
+		if keys := srv.SshKeys(); len(keys) > 0 {
+			return fmt.Errorf("%d SSH key(s) still exist", len(keys))
+		}
+		return nil
+	}
+}
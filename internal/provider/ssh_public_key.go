@@ -0,0 +1,198 @@
+package provider
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"strings"
+
+	"github.com/hashicorp/terraform-plugin-framework/attr"
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/planmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/types/basetypes"
+	"github.com/hashicorp/terraform-plugin-go/tftypes"
+	"golang.org/x/crypto/ssh"
+)
+
+// Ensure the custom types fully satisfy framework interfaces.
+var _ basetypes.StringTypable = sshPublicKeyType{}
+var _ basetypes.StringValuableWithSemanticEquals = sshPublicKeyValue{}
+
+// sshKeyAlgorithms lists the public key algorithms accepted by the Fluence
+// API.
+var sshKeyAlgorithms = []string{
+	ssh.KeyAlgoED25519,
+	ssh.KeyAlgoRSA,
+	ssh.KeyAlgoECDSA256,
+	ssh.KeyAlgoECDSA384,
+	ssh.KeyAlgoECDSA521,
+}
+
+// sshPublicKey is an OpenSSH public key parsed from an authorized_keys line.
+type sshPublicKey struct {
+	Algorithm   string
+	Fingerprint string
+	Comment     string
+
+	// Material is the base64 encoded key, without the algorithm and comment
+	Material string
+}
+
+// parseSshPublicKey parses a single authorized_keys line. Options before the
+// key and additional lines are rejected.
+func parseSshPublicKey(value string) (*sshPublicKey, error) {
+	key, comment, options, rest, err := ssh.ParseAuthorizedKey([]byte(value))
+	if err != nil {
+		return nil, fmt.Errorf("not an OpenSSH public key: %w", err)
+	}
+	if len(options) > 0 {
+		return nil, fmt.Errorf("authorized_keys options are not supported: %s", strings.Join(options, ","))
+	}
+	if strings.TrimSpace(string(rest)) != "" {
+		return nil, fmt.Errorf("expected a single public key, got several lines")
+	}
+
+	supported := false
+	for _, algorithm := range sshKeyAlgorithms {
+		if key.Type() == algorithm {
+			supported = true
+			break
+		}
+	}
+	if !supported {
+		return nil, fmt.Errorf("unsupported key algorithm %q, expected one of: %s", key.Type(), strings.Join(sshKeyAlgorithms, ", "))
+	}
+
+	return &sshPublicKey{
+		Algorithm:   key.Type(),
+		Fingerprint: ssh.FingerprintSHA256(key),
+		Comment:     comment,
+		Material:    base64.StdEncoding.EncodeToString(key.Marshal()),
+	}, nil
+}
+
+// String returns the key as a normalized authorized_keys line: the
+// algorithm, the key and the comment separated by single spaces.
+func (k *sshPublicKey) String() string {
+	line := k.Algorithm + " " + k.Material
+	if comment := strings.Join(strings.Fields(k.Comment), " "); comment != "" {
+		line += " " + comment
+	}
+	return line
+}
+
+// sameSshPublicKey reports whether a and b hold the same key, ignoring
+// whitespace and comments. Values that cannot be parsed are compared as
+// strings.
+func sameSshPublicKey(a, b string) bool {
+	keyA, errA := parseSshPublicKey(a)
+	keyB, errB := parseSshPublicKey(b)
+	if errA != nil || errB != nil {
+		return a == b
+	}
+	return keyA.Fingerprint == keyB.Fingerprint
+}
+
+// sshPublicKeyType is a string holding an OpenSSH public key. Its values are
+// semantically equal when they hold the same key, so that the spelling
+// returned by the API does not replace the configured one.
+type sshPublicKeyType struct {
+	basetypes.StringType
+}
+
+func (t sshPublicKeyType) Equal(o attr.Type) bool {
+	other, ok := o.(sshPublicKeyType)
+	if !ok {
+		return false
+	}
+	return t.StringType.Equal(other.StringType)
+}
+
+func (t sshPublicKeyType) String() string {
+	return "sshPublicKeyType"
+}
+
+func (t sshPublicKeyType) ValueFromString(_ context.Context, in basetypes.StringValue) (basetypes.StringValuable, diag.Diagnostics) {
+	return sshPublicKeyValue{StringValue: in}, nil
+}
+
+func (t sshPublicKeyType) ValueFromTerraform(ctx context.Context, in tftypes.Value) (attr.Value, error) {
+	attrValue, err := t.StringType.ValueFromTerraform(ctx, in)
+	if err != nil {
+		return nil, err
+	}
+
+	stringValue, ok := attrValue.(basetypes.StringValue)
+	if !ok {
+		return nil, fmt.Errorf("unexpected value type of %T", attrValue)
+	}
+
+	return sshPublicKeyValue{StringValue: stringValue}, nil
+}
+
+func (t sshPublicKeyType) ValueType(_ context.Context) attr.Value {
+	return sshPublicKeyValue{}
+}
+
+// sshPublicKeyValue is a value of sshPublicKeyType.
+type sshPublicKeyValue struct {
+	basetypes.StringValue
+}
+
+// newSshPublicKeyValue returns a known sshPublicKeyValue.
+func newSshPublicKeyValue(value string) sshPublicKeyValue {
+	return sshPublicKeyValue{StringValue: basetypes.NewStringValue(value)}
+}
+
+func (v sshPublicKeyValue) Equal(o attr.Value) bool {
+	other, ok := o.(sshPublicKeyValue)
+	if !ok {
+		return false
+	}
+	return v.StringValue.Equal(other.StringValue)
+}
+
+func (v sshPublicKeyValue) Type(_ context.Context) attr.Type {
+	return sshPublicKeyType{}
+}
+
+// StringSemanticEquals reports whether both values hold the same key,
+// ignoring whitespace and comments.
+func (v sshPublicKeyValue) StringSemanticEquals(_ context.Context, newValuable basetypes.StringValuable) (bool, diag.Diagnostics) {
+	var diags diag.Diagnostics
+
+	newValue, ok := newValuable.(sshPublicKeyValue)
+	if !ok {
+		diags.AddError(
+			"Semantic Equality Check Error",
+			fmt.Sprintf("Expected value type %T, got: %T. Please report this issue to the provider developers.", v, newValuable),
+		)
+		return false, diags
+	}
+
+	return sameSshPublicKey(v.ValueString(), newValue.ValueString()), diags
+}
+
+// sshPublicKeyUseStateForSameKey plans the prior public key when the
+// configuration holds the same key, so that whitespace and comment changes
+// show no difference. Terraform accepts a planned value that differs from the
+// configuration when it is the prior value.
+type sshPublicKeyUseStateForSameKey struct{}
+
+func (m sshPublicKeyUseStateForSameKey) Description(_ context.Context) string {
+	return "Whitespace and comment changes to the public key are ignored."
+}
+
+func (m sshPublicKeyUseStateForSameKey) MarkdownDescription(ctx context.Context) string {
+	return m.Description(ctx)
+}
+
+func (m sshPublicKeyUseStateForSameKey) PlanModifyString(_ context.Context, req planmodifier.StringRequest, resp *planmodifier.StringResponse) {
+	if req.StateValue.IsNull() || req.PlanValue.IsNull() || req.PlanValue.IsUnknown() {
+		return
+	}
+
+	if sameSshPublicKey(req.StateValue.ValueString(), req.PlanValue.ValueString()) {
+		resp.PlanValue = req.StateValue
+	}
+}
@@ -0,0 +1,153 @@
+package provider
+
+import (
+	"context"
+	"crypto/dsa"
+	"crypto/rand"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/planmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"golang.org/x/crypto/ssh"
+)
+
+func TestParseSshPublicKey(t *testing.T) {
+	publicKey := testAccPublicKey(t, "user@host")
+	fields := strings.Fields(publicKey)
+
+	key, err := parseSshPublicKey("  " + fields[0] + "\t" + fields[1] + "  user@host \n")
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if key.Algorithm != ssh.KeyAlgoED25519 {
+		t.Errorf("Algorithm = %q, want %q", key.Algorithm, ssh.KeyAlgoED25519)
+	}
+	if key.Material != fields[1] {
+		t.Errorf("Material = %q, want %q", key.Material, fields[1])
+	}
+	if key.Comment != "user@host" {
+		t.Errorf("Comment = %q, want %q", key.Comment, "user@host")
+	}
+	if !strings.HasPrefix(key.Fingerprint, "SHA256:") {
+		t.Errorf("Fingerprint = %q, want a SHA256 fingerprint", key.Fingerprint)
+	}
+	if got := key.String(); got != publicKey {
+		t.Errorf("String() = %q, want %q", got, publicKey)
+	}
+
+	tests := map[string]struct {
+		value string
+		err   string
+	}{
+		"empty": {
+			value: "",
+			err:   "not an OpenSSH public key",
+		},
+		"garbage": {
+			value: "ssh-ed25519 not-base64",
+			err:   "not an OpenSSH public key",
+		},
+		"options": {
+			value: `no-pty,command="true" ` + publicKey,
+			err:   "options are not supported",
+		},
+		"several lines": {
+			value: publicKey + "\n" + testAccPublicKey(t, ""),
+			err:   "expected a single public key",
+		},
+		"unsupported algorithm": {
+			value: testDsaPublicKey(t),
+			err:   "unsupported key algorithm \"ssh-dss\"",
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			_, err := parseSshPublicKey(test.value)
+			if err == nil || !strings.Contains(err.Error(), test.err) {
+				t.Errorf("parseSshPublicKey() error = %v, want %q", err, test.err)
+			}
+		})
+	}
+}
+
+func TestSameSshPublicKey(t *testing.T) {
+	publicKey := testAccPublicKey(t, "a")
+	fields := strings.Fields(publicKey)
+
+	tests := map[string]struct {
+		a, b string
+		want bool
+	}{
+		"identical":          {a: publicKey, b: publicKey, want: true},
+		"whitespace":         {a: publicKey, b: fields[0] + "   " + fields[1] + "\ta\n", want: true},
+		"comment":            {a: publicKey, b: fields[0] + " " + fields[1] + " b", want: true},
+		"no comment":         {a: publicKey, b: fields[0] + " " + fields[1], want: true},
+		"other key":          {a: publicKey, b: testAccPublicKey(t, "a"), want: false},
+		"invalid, same":      {a: "invalid", b: "invalid", want: true},
+		"invalid, different": {a: publicKey, b: "invalid", want: false},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			if got := sameSshPublicKey(test.a, test.b); got != test.want {
+				t.Errorf("sameSshPublicKey() = %t, want %t", got, test.want)
+			}
+
+			equal, diags := newSshPublicKeyValue(test.a).StringSemanticEquals(context.Background(), newSshPublicKeyValue(test.b))
+			if diags.HasError() {
+				t.Fatalf("unexpected diagnostics: %v", diags)
+			}
+			if equal != test.want {
+				t.Errorf("StringSemanticEquals() = %t, want %t", equal, test.want)
+			}
+		})
+	}
+}
+
+func TestSshPublicKeyUseStateForSameKey(t *testing.T) {
+	publicKey := testAccPublicKey(t, "a")
+	fields := strings.Fields(publicKey)
+	otherKey := testAccPublicKey(t, "a")
+
+	tests := map[string]struct {
+		state, plan types.String
+		want        types.String
+	}{
+		"create":     {state: types.StringNull(), plan: types.StringValue(publicKey), want: types.StringValue(publicKey)},
+		"unknown":    {state: types.StringValue(publicKey), plan: types.StringUnknown(), want: types.StringUnknown()},
+		"same key":   {state: types.StringValue(publicKey), plan: types.StringValue(fields[0] + "  " + fields[1]), want: types.StringValue(publicKey)},
+		"other key":  {state: types.StringValue(publicKey), plan: types.StringValue(otherKey), want: types.StringValue(otherKey)},
+		"no changes": {state: types.StringValue(publicKey), plan: types.StringValue(publicKey), want: types.StringValue(publicKey)},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			req := planmodifier.StringRequest{StateValue: test.state, PlanValue: test.plan}
+			resp := &planmodifier.StringResponse{PlanValue: test.plan}
+			sshPublicKeyUseStateForSameKey{}.PlanModifyString(context.Background(), req, resp)
+			if !resp.PlanValue.Equal(test.want) {
+				t.Errorf("PlanValue = %s, want %s", resp.PlanValue, test.want)
+			}
+		})
+	}
+}
+
+// testDsaPublicKey returns a DSA public key, which the API does not accept.
+func testDsaPublicKey(t *testing.T) string {
+	t.Helper()
+
+	var key dsa.PrivateKey
+	if err := dsa.GenerateParameters(&key.Parameters, rand.Reader, dsa.L1024N160); err != nil {
+		t.Fatalf("unable to generate DSA parameters: %s", err)
+	}
+	if err := dsa.GenerateKey(&key, rand.Reader); err != nil {
+		t.Fatalf("unable to generate a DSA key: %s", err)
+	}
+	public, err := ssh.NewPublicKey(&key.PublicKey)
+	if err != nil {
+		t.Fatalf("unable to encode the DSA key: %s", err)
+	}
+	return string(ssh.MarshalAuthorizedKey(public))
+}
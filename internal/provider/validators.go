@@ -0,0 +1,201 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-framework/schema/validator"
+)
+
+// Ensure the validators satisfy the framework interfaces.
+var (
+	_ validator.String = countryCodeValidator{}
+	_ validator.String = sshPublicKeyValidator{}
+	_ validator.String = regexpValidator{}
+	_ validator.String = rfc3339Validator{}
+	_ validator.List   = uniqueOpenPortsValidator{}
+)
+
+// decimalPattern matches the non-negative decimal amounts used by the API
+// for prices and deposits.
+var decimalPattern = regexp.MustCompile(`^[0-9]+(\.[0-9]+)?$`)
+
+// isoCountryCodes lists the ISO 3166-1 alpha-2 country codes.
+var isoCountryCodes = map[string]bool{}
+
+func init() {
+	codes := "AD AE AF AG AI AL AM AO AQ AR AS AT AU AW AX AZ " +
+		"BA BB BD BE BF BG BH BI BJ BL BM BN BO BQ BR BS BT BV BW BY BZ " +
+		"CA CC CD CF CG CH CI CK CL CM CN CO CR CU CV CW CX CY CZ " +
+		"DE DJ DK DM DO DZ EC EE EG EH ER ES ET FI FJ FK FM FO FR " +
+		"GA GB GD GE GF GG GH GI GL GM GN GP GQ GR GS GT GU GW GY " +
+		"HK HM HN HR HT HU ID IE IL IM IN IO IQ IR IS IT JE JM JO JP " +
+		"KE KG KH KI KM KN KP KR KW KY KZ LA LB LC LI LK LR LS LT LU LV LY " +
+		"MA MC MD ME MF MG MH MK ML MM MN MO MP MQ MR MS MT MU MV MW MX MY MZ " +
+		"NA NC NE NF NG NI NL NO NP NR NU NZ OM " +
+		"PA PE PF PG PH PK PL PM PN PR PS PT PW PY QA RE RO RS RU RW " +
+		"SA SB SC SD SE SG SH SI SJ SK SL SM SN SO SR SS ST SV SX SY SZ " +
+		"TC TD TF TG TH TJ TK TL TM TN TO TR TT TV TW TZ " +
+		"UA UG UM US UY UZ VA VC VE VG VI VN VU WF WS YE YT ZA ZM ZW"
+
+	for _, code := range strings.Fields(codes) {
+		isoCountryCodes[code] = true
+	}
+}
+
+// countryCodeValidator checks that a string is an upper-case ISO 3166-1
+// alpha-2 country code, as returned by the fluence_available_countries data
+// source.
+type countryCodeValidator struct{}
+
+func (v countryCodeValidator) Description(_ context.Context) string {
+	return "value must be an ISO 3166-1 alpha-2 country code such as \"US\" or \"DE\""
+}
+
+func (v countryCodeValidator) MarkdownDescription(ctx context.Context) string {
+	return v.Description(ctx)
+}
+
+func (v countryCodeValidator) ValidateString(ctx context.Context, req validator.StringRequest, resp *validator.StringResponse) {
+	if req.ConfigValue.IsNull() || req.ConfigValue.IsUnknown() {
+		return
+	}
+
+	value := req.ConfigValue.ValueString()
+	if isoCountryCodes[value] {
+		return
+	}
+
+	detail := fmt.Sprintf("%q is not an ISO 3166-1 alpha-2 country code.", value)
+	if isoCountryCodes[strings.ToUpper(value)] {
+		detail = fmt.Sprintf("%q must be written in upper case: %q.", value, strings.ToUpper(value))
+	}
+
+	resp.Diagnostics.AddAttributeError(
+		req.Path,
+		"Invalid Country Code",
+		detail+" Use the fluence_available_countries data source to list the countries with available capacity.",
+	)
+}
+
+// uniqueOpenPortsValidator checks that an open_ports list does not contain
+// the same port and protocol twice.
+type uniqueOpenPortsValidator struct{}
+
+func (v uniqueOpenPortsValidator) Description(_ context.Context) string {
+	return "each port and protocol pair must be listed only once"
+}
+
+func (v uniqueOpenPortsValidator) MarkdownDescription(ctx context.Context) string {
+	return v.Description(ctx)
+}
+
+func (v uniqueOpenPortsValidator) ValidateList(ctx context.Context, req validator.ListRequest, resp *validator.ListResponse) {
+	if req.ConfigValue.IsNull() || req.ConfigValue.IsUnknown() {
+		return
+	}
+
+	var ports []OpenPortModel
+	resp.Diagnostics.Append(req.ConfigValue.ElementsAs(ctx, &ports, false)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	seen := map[string]int{}
+	for i, port := range ports {
+		if port.Port.IsNull() || port.Port.IsUnknown() || port.Protocol.IsNull() || port.Protocol.IsUnknown() {
+			continue
+		}
+
+		key := fmt.Sprintf("%d/%s", port.Port.ValueInt64(), port.Protocol.ValueString())
+		if first, ok := seen[key]; ok {
+			resp.Diagnostics.AddAttributeError(
+				req.Path.AtListIndex(i),
+				"Duplicate Open Port",
+				fmt.Sprintf("Port %s is already listed at index %d.", key, first),
+			)
+			continue
+		}
+		seen[key] = i
+	}
+}
+
+// sshPublicKeyValidator checks that a string is a single OpenSSH public key
+// using one of the algorithms accepted by the API.
+type sshPublicKeyValidator struct{}
+
+func (v sshPublicKeyValidator) Description(_ context.Context) string {
+	return fmt.Sprintf("value must be an OpenSSH public key of type %s", strings.Join(sshKeyAlgorithms, ", "))
+}
+
+func (v sshPublicKeyValidator) MarkdownDescription(ctx context.Context) string {
+	return v.Description(ctx)
+}
+
+func (v sshPublicKeyValidator) ValidateString(ctx context.Context, req validator.StringRequest, resp *validator.StringResponse) {
+	if req.ConfigValue.IsNull() || req.ConfigValue.IsUnknown() {
+		return
+	}
+
+	if _, err := parseSshPublicKey(req.ConfigValue.ValueString()); err != nil {
+		resp.Diagnostics.AddAttributeError(
+			req.Path,
+			"Invalid SSH Public Key",
+			fmt.Sprintf("The public key could not be parsed: %s.", err),
+		)
+	}
+}
+
+// regexpValidator checks that a string is a valid RE2 regular expression.
+type regexpValidator struct{}
+
+func (v regexpValidator) Description(_ context.Context) string {
+	return "value must be a valid regular expression"
+}
+
+func (v regexpValidator) MarkdownDescription(ctx context.Context) string {
+	return v.Description(ctx)
+}
+
+func (v regexpValidator) ValidateString(ctx context.Context, req validator.StringRequest, resp *validator.StringResponse) {
+	if req.ConfigValue.IsNull() || req.ConfigValue.IsUnknown() {
+		return
+	}
+
+	if _, err := regexp.Compile(req.ConfigValue.ValueString()); err != nil {
+		resp.Diagnostics.AddAttributeError(
+			req.Path,
+			"Invalid Regular Expression",
+			fmt.Sprintf("%q is not a valid regular expression: %s.", req.ConfigValue.ValueString(), err),
+		)
+	}
+}
+
+// rfc3339Validator checks that a string is an RFC 3339 time, the format of
+// the timestamps returned by the API.
+type rfc3339Validator struct{}
+
+func (v rfc3339Validator) Description(_ context.Context) string {
+	return "value must be an RFC 3339 time such as \"2025-01-02T15:04:05Z\""
+}
+
+func (v rfc3339Validator) MarkdownDescription(ctx context.Context) string {
+	return v.Description(ctx)
+}
+
+func (v rfc3339Validator) ValidateString(ctx context.Context, req validator.StringRequest, resp *validator.StringResponse) {
+	if req.ConfigValue.IsNull() || req.ConfigValue.IsUnknown() {
+		return
+	}
+
+	if _, err := time.Parse(time.RFC3339, req.ConfigValue.ValueString()); err != nil {
+		resp.Diagnostics.AddAttributeError(
+			req.Path,
+			"Invalid Time",
+			fmt.Sprintf("%q is not an RFC 3339 time such as \"2025-01-02T15:04:05Z\": %s.", req.ConfigValue.ValueString(), err),
+		)
+	}
+}
@@ -1,13 +1,24 @@
 package provider
 
 import (
+	"cmp"
 	"context"
 	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
 
 	fluenceapi "github.com/decentralized-infrastructure/fluence-api-client-go"
+	"github.com/hashicorp/terraform-plugin-framework-validators/int64validator"
+	"github.com/hashicorp/terraform-plugin-framework-validators/stringvalidator"
 	"github.com/hashicorp/terraform-plugin-framework/datasource"
 	"github.com/hashicorp/terraform-plugin-framework/datasource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/schema/validator"
 	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-log/tflog"
 )
 
 // Ensure the implementation satisfies the expected interfaces.
@@ -54,7 +65,23 @@ func (d *vmsDataSource) Metadata(_ context.Context, req datasource.MetadataReque
 // Schema defines the schema for the data source.
 func (d *vmsDataSource) Schema(_ context.Context, _ datasource.SchemaRequest, resp *datasource.SchemaResponse) {
 	resp.Schema = schema.Schema{
+		MarkdownDescription: "List the VMs in the account, optionally filtered, sorted and limited",
+
 		Attributes: map[string]schema.Attribute{
+			"sort_by": schema.StringAttribute{
+				MarkdownDescription: "Sort the VMs in ascending order of `created_at`, `name`, `price_per_epoch` or `status`. Values that cannot be parsed sort last and VMs with equal values are ordered by `id`. VMs are listed in API order when omitted",
+				Optional:            true,
+				Validators: []validator.String{
+					stringvalidator.OneOf(vmSortKeys...),
+				},
+			},
+			"limit": schema.Int64Attribute{
+				MarkdownDescription: "Maximum number of VMs to return, applied after filtering and sorting",
+				Optional:            true,
+				Validators: []validator.Int64{
+					int64validator.AtLeast(1),
+				},
+			},
 			"vms": schema.ListNestedAttribute{
 				Computed: true,
 				NestedObject: schema.NestedAttributeObject{
@@ -96,12 +123,85 @@ func (d *vmsDataSource) Schema(_ context.Context, _ datasource.SchemaRequest, re
 				},
 			},
 		},
+		Blocks: map[string]schema.Block{
+			"filter": schema.SingleNestedBlock{
+				MarkdownDescription: "Only list the VMs matching every condition set in this block",
+				Attributes: map[string]schema.Attribute{
+					"statuses": schema.ListAttribute{
+						MarkdownDescription: "VM statuses to keep (e.g., Active, Terminated)",
+						ElementType:         types.StringType,
+						Optional:            true,
+					},
+					"name_regex": schema.StringAttribute{
+						MarkdownDescription: "Regular expression (RE2 syntax) the VM name must match",
+						Optional:            true,
+						Validators: []validator.String{
+							regexpValidator{},
+						},
+					},
+					"has_public_ip": schema.BoolAttribute{
+						MarkdownDescription: "Keep only the VMs with (`true`) or without (`false`) a public IP address",
+						Optional:            true,
+					},
+					"os_image_contains": schema.StringAttribute{
+						MarkdownDescription: "Substring the OS image must contain",
+						Optional:            true,
+					},
+					"created_after": schema.StringAttribute{
+						MarkdownDescription: "Keep only the VMs created after this RFC 3339 time",
+						Optional:            true,
+						Validators: []validator.String{
+							rfc3339Validator{},
+						},
+					},
+					"created_before": schema.StringAttribute{
+						MarkdownDescription: "Keep only the VMs created before this RFC 3339 time",
+						Optional:            true,
+						Validators: []validator.String{
+							rfc3339Validator{},
+						},
+					},
+					"min_price_per_epoch": schema.StringAttribute{
+						MarkdownDescription: "Minimum price per epoch in USD",
+						Optional:            true,
+						Validators: []validator.String{
+							stringvalidator.RegexMatches(decimalPattern, "must be a decimal amount such as \"1.5\""),
+						},
+					},
+					"max_price_per_epoch": schema.StringAttribute{
+						MarkdownDescription: "Maximum price per epoch in USD",
+						Optional:            true,
+						Validators: []validator.String{
+							stringvalidator.RegexMatches(decimalPattern, "must be a decimal amount such as \"1.5\""),
+						},
+					},
+				},
+			},
+		},
 	}
 }
 
+// vmSortKeys lists the accepted values of sort_by.
+var vmSortKeys = []string{"created_at", "name", "price_per_epoch", "status"}
+
 // vmsDataSourceModel maps the data source schema data.
 type vmsDataSourceModel struct {
-	Vms []vmModel `tfsdk:"vms"`
+	Filter *vmsFilterModel `tfsdk:"filter"`
+	SortBy types.String    `tfsdk:"sort_by"`
+	Limit  types.Int64     `tfsdk:"limit"`
+	Vms    []vmModel       `tfsdk:"vms"`
+}
+
+// vmsFilterModel maps the filter block.
+type vmsFilterModel struct {
+	Statuses         []types.String `tfsdk:"statuses"`
+	NameRegex        types.String   `tfsdk:"name_regex"`
+	HasPublicIp      types.Bool     `tfsdk:"has_public_ip"`
+	OsImageContains  types.String   `tfsdk:"os_image_contains"`
+	CreatedAfter     types.String   `tfsdk:"created_after"`
+	CreatedBefore    types.String   `tfsdk:"created_before"`
+	MinPricePerEpoch types.String   `tfsdk:"min_price_per_epoch"`
+	MaxPricePerEpoch types.String   `tfsdk:"max_price_per_epoch"`
 }
 
 // vmModel maps VM data.
@@ -123,7 +223,12 @@ type vmModel struct {
 func (d *vmsDataSource) Read(ctx context.Context, req datasource.ReadRequest, resp *datasource.ReadResponse) {
 	var state vmsDataSourceModel
 
-	vms, err := d.client.ListVmsV3()
+	resp.Diagnostics.Append(req.Config.Get(ctx, &state)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	vms, err := withContext(ctx, d.client).ListVmsV3()
 	if err != nil {
 		resp.Diagnostics.AddError(
 			"Unable to Read Fluence VMs",
@@ -132,38 +237,38 @@ func (d *vmsDataSource) Read(ctx context.Context, req datasource.ReadRequest, re
 		return
 	}
 
-	// Map response body to model
-	for _, vm := range vms {
-		vmState := vmModel{
-			ID:              types.StringValue(vm.Id),
-			Status:          types.StringValue(vm.Status),
-			StatusChangedAt: types.StringValue(vm.StatusChangedAt),
-			PricePerEpoch:   types.StringValue(vm.PricePerEpoch),
-			CreatedAt:       types.StringValue(vm.CreatedAt),
-			NextBillingAt:   types.StringValue(vm.NextBillingAt),
-			ReservedBalance: types.StringValue(vm.ReservedBalance),
-			TotalSpent:      types.StringValue(vm.TotalSpent),
+	if state.Filter != nil {
+		match, err := state.Filter.matcher()
+		if err != nil {
+			resp.Diagnostics.AddAttributeError(path.Root("filter"), "Invalid Filter", err.Error())
+			return
 		}
 
-		if vm.OsImage != nil {
-			vmState.OsImage = types.StringValue(*vm.OsImage)
-		} else {
-			vmState.OsImage = types.StringNull()
+		filtered := []fluenceapi.RunningInstanceV3{}
+		for _, vm := range vms {
+			if match(vm) {
+				filtered = append(filtered, vm)
+			}
 		}
+		vms = filtered
+	}
 
-		if vm.PublicIp != nil {
-			vmState.PublicIp = types.StringValue(*vm.PublicIp)
-		} else {
-			vmState.PublicIp = types.StringNull()
-		}
+	if !state.SortBy.IsNull() {
+		sortVms(vms, state.SortBy.ValueString())
+	}
 
-		if vm.VmName != nil {
-			vmState.VmName = types.StringValue(*vm.VmName)
-		} else {
-			vmState.VmName = types.StringNull()
-		}
+	if !state.Limit.IsNull() && int64(len(vms)) > state.Limit.ValueInt64() {
+		vms = vms[:state.Limit.ValueInt64()]
+	}
+
+	tflog.Debug(ctx, "Listed VMs", map[string]interface{}{
+		"count": len(vms),
+	})
 
-		state.Vms = append(state.Vms, vmState)
+	// Map response body to model
+	state.Vms = []vmModel{}
+	for _, vm := range vms {
+		state.Vms = append(state.Vms, newVmModel(vm))
 	}
 
 	// Set state
@@ -173,3 +278,204 @@ func (d *vmsDataSource) Read(ctx context.Context, req datasource.ReadRequest, re
 		return
 	}
 }
+
+// newVmModel maps a VM returned by the API.
+func newVmModel(vm fluenceapi.RunningInstanceV3) vmModel {
+	vmState := vmModel{
+		ID:              types.StringValue(vm.Id),
+		Status:          types.StringValue(vm.Status),
+		StatusChangedAt: types.StringValue(vm.StatusChangedAt),
+		PricePerEpoch:   types.StringValue(vm.PricePerEpoch),
+		CreatedAt:       types.StringValue(vm.CreatedAt),
+		NextBillingAt:   types.StringValue(vm.NextBillingAt),
+		ReservedBalance: types.StringValue(vm.ReservedBalance),
+		TotalSpent:      types.StringValue(vm.TotalSpent),
+	}
+
+	if vm.OsImage != nil {
+		vmState.OsImage = types.StringValue(*vm.OsImage)
+	} else {
+		vmState.OsImage = types.StringNull()
+	}
+
+	if vm.PublicIp != nil {
+		vmState.PublicIp = types.StringValue(*vm.PublicIp)
+	} else {
+		vmState.PublicIp = types.StringNull()
+	}
+
+	if vm.VmName != nil {
+		vmState.VmName = types.StringValue(*vm.VmName)
+	} else {
+		vmState.VmName = types.StringNull()
+	}
+
+	return vmState
+}
+
+// matcher returns a function reporting whether a VM matches every condition
+// of the filter.
+func (f *vmsFilterModel) matcher() (func(fluenceapi.RunningInstanceV3) bool, error) {
+	statuses := map[string]bool{}
+	for _, status := range f.Statuses {
+		statuses[status.ValueString()] = true
+	}
+
+	var nameRegex *regexp.Regexp
+	if !f.NameRegex.IsNull() {
+		var err error
+		nameRegex, err = regexp.Compile(f.NameRegex.ValueString())
+		if err != nil {
+			return nil, fmt.Errorf("invalid name_regex: %w", err)
+		}
+	}
+
+	parseTime := func(name string, value types.String) (*time.Time, error) {
+		if value.IsNull() {
+			return nil, nil
+		}
+		t, err := time.Parse(time.RFC3339, value.ValueString())
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s: %w", name, err)
+		}
+		return &t, nil
+	}
+	createdAfter, err := parseTime("created_after", f.CreatedAfter)
+	if err != nil {
+		return nil, err
+	}
+	createdBefore, err := parseTime("created_before", f.CreatedBefore)
+	if err != nil {
+		return nil, err
+	}
+
+	parsePrice := func(name string, value types.String) (*float64, error) {
+		if value.IsNull() {
+			return nil, nil
+		}
+		price, err := strconv.ParseFloat(value.ValueString(), 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s: %w", name, err)
+		}
+		return &price, nil
+	}
+	minPrice, err := parsePrice("min_price_per_epoch", f.MinPricePerEpoch)
+	if err != nil {
+		return nil, err
+	}
+	maxPrice, err := parsePrice("max_price_per_epoch", f.MaxPricePerEpoch)
+	if err != nil {
+		return nil, err
+	}
+
+	return func(vm fluenceapi.RunningInstanceV3) bool {
+		if len(statuses) > 0 && !statuses[vm.Status] {
+			return false
+		}
+
+		if nameRegex != nil && (vm.VmName == nil || !nameRegex.MatchString(*vm.VmName)) {
+			return false
+		}
+
+		if !f.HasPublicIp.IsNull() {
+			hasPublicIp := vm.PublicIp != nil && *vm.PublicIp != ""
+			if hasPublicIp != f.HasPublicIp.ValueBool() {
+				return false
+			}
+		}
+
+		if !f.OsImageContains.IsNull() && (vm.OsImage == nil || !strings.Contains(*vm.OsImage, f.OsImageContains.ValueString())) {
+			return false
+		}
+
+		// VMs whose creation time cannot be parsed never match a time range
+		if createdAfter != nil || createdBefore != nil {
+			createdAt, err := time.Parse(time.RFC3339, vm.CreatedAt)
+			if err != nil {
+				return false
+			}
+			if createdAfter != nil && !createdAt.After(*createdAfter) {
+				return false
+			}
+			if createdBefore != nil && !createdAt.Before(*createdBefore) {
+				return false
+			}
+		}
+
+		// Likewise for prices
+		if minPrice != nil || maxPrice != nil {
+			price, err := strconv.ParseFloat(vm.PricePerEpoch, 64)
+			if err != nil {
+				return false
+			}
+			if minPrice != nil && price < *minPrice {
+				return false
+			}
+			if maxPrice != nil && price > *maxPrice {
+				return false
+			}
+		}
+
+		return true
+	}, nil
+}
+
+// sortVms sorts vms in ascending order of one of vmSortKeys. Creation times
+// and prices that cannot be parsed sort after the others, and VMs with equal
+// keys are ordered by ID, so the order does not depend on the API.
+func sortVms(vms []fluenceapi.RunningInstanceV3, sortBy string) {
+	var compare func(a, b fluenceapi.RunningInstanceV3) int
+	switch sortBy {
+	case "created_at":
+		compare = func(a, b fluenceapi.RunningInstanceV3) int {
+			timeA, errA := time.Parse(time.RFC3339, a.CreatedAt)
+			timeB, errB := time.Parse(time.RFC3339, b.CreatedAt)
+			return compareParsed(errA == nil, errB == nil, func() int { return timeA.Compare(timeB) }, a.CreatedAt, b.CreatedAt)
+		}
+	case "name":
+		name := func(vm fluenceapi.RunningInstanceV3) string {
+			if vm.VmName == nil {
+				return ""
+			}
+			return *vm.VmName
+		}
+		compare = func(a, b fluenceapi.RunningInstanceV3) int {
+			return strings.Compare(name(a), name(b))
+		}
+	case "price_per_epoch":
+		compare = func(a, b fluenceapi.RunningInstanceV3) int {
+			priceA, errA := strconv.ParseFloat(a.PricePerEpoch, 64)
+			priceB, errB := strconv.ParseFloat(b.PricePerEpoch, 64)
+			return compareParsed(errA == nil, errB == nil, func() int { return cmp.Compare(priceA, priceB) }, a.PricePerEpoch, b.PricePerEpoch)
+		}
+	case "status":
+		compare = func(a, b fluenceapi.RunningInstanceV3) int {
+			return strings.Compare(a.Status, b.Status)
+		}
+	default:
+		return
+	}
+
+	sort.Slice(vms, func(i, j int) bool {
+		if c := compare(vms[i], vms[j]); c != 0 {
+			return c < 0
+		}
+		return vms[i].Id < vms[j].Id
+	})
+}
+
+// compareParsed compares two values through compareValues when both could be
+// parsed. A value that could not be parsed sorts after one that could, and
+// two such values are compared as strings.
+func compareParsed(okA, okB bool, compareValues func() int, a, b string) int {
+	switch {
+	case okA && okB:
+		return compareValues()
+	case okA:
+		return -1
+	case okB:
+		return 1
+	default:
+		return strings.Compare(a, b)
+	}
+}
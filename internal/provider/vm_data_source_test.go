@@ -0,0 +1,339 @@
+package provider
+
+import (
+	"fmt"
+	"reflect"
+	"regexp"
+	"testing"
+	"time"
+
+	fluenceapi "github.com/decentralized-infrastructure/fluence-api-client-go"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-testing/helper/resource"
+
+	"terraform-provider-fluence/internal/fakeapi"
+)
+
+func TestAccVmsDataSource(t *testing.T) {
+	start := time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC)
+	clock := fakeapi.NewManualClock(start)
+	srv := testAccServer(t, fakeapi.WithClock(clock))
+
+	testAccCreateVms(t, srv, "web-1", "cpu-2-ram-4gb-storage-25gb", "DE", testAccOsImage, 1)
+	clock.Advance(time.Hour)
+	testAccCreateVms(t, srv, "web-2", "cpu-4-ram-8gb-storage-25gb", "US", testAccOsImage, 1)
+	clock.Advance(time.Hour)
+
+	// The VMs above are active with a public IP, this one never launches
+	srv.SetStatusTransitions()
+	testAccCreateVms(t, srv, "db", "cpu-8-ram-16gb-storage-50gb", "CA", "https://cloud.debian.org/images/cloud/bookworm/latest/debian-12-genericcloud-amd64.qcow2", 1)
+
+	resource.Test(t, resource.TestCase{
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		Steps: []resource.TestStep{
+			{
+				Config: testAccVmsDataSourceConfig(srv, ""),
+				Check: resource.ComposeAggregateTestCheckFunc(
+					resource.TestCheckResourceAttr("data.fluence_vms.test", "vms.#", "3"),
+					resource.TestCheckTypeSetElemNestedAttrs("data.fluence_vms.test", "vms.*", map[string]string{
+						"vm_name":         "web-1",
+						"status":          fluenceapi.VmStatusActive,
+						"price_per_epoch": "1.5",
+						"created_at":      "2025-06-01T00:00:00Z",
+						"os_image":        testAccOsImage,
+					}),
+					resource.TestCheckTypeSetElemNestedAttrs("data.fluence_vms.test", "vms.*", map[string]string{
+						"vm_name": "db",
+						"status":  fluenceapi.VmStatusNew,
+					}),
+				),
+			},
+			{
+				Config: testAccVmsDataSourceConfig(srv, `
+  filter {
+    statuses = ["Active"]
+  }
+  sort_by = "price_per_epoch"
+`),
+				Check: resource.ComposeAggregateTestCheckFunc(
+					resource.TestCheckResourceAttr("data.fluence_vms.test", "vms.#", "2"),
+					resource.TestCheckResourceAttr("data.fluence_vms.test", "vms.0.vm_name", "web-1"),
+					resource.TestCheckResourceAttr("data.fluence_vms.test", "vms.1.vm_name", "web-2"),
+				),
+			},
+			{
+				Config: testAccVmsDataSourceConfig(srv, `
+  filter {
+    has_public_ip = false
+  }
+`),
+				Check: resource.ComposeAggregateTestCheckFunc(
+					resource.TestCheckResourceAttr("data.fluence_vms.test", "vms.#", "1"),
+					resource.TestCheckResourceAttr("data.fluence_vms.test", "vms.0.vm_name", "db"),
+					resource.TestCheckNoResourceAttr("data.fluence_vms.test", "vms.0.public_ip"),
+				),
+			},
+			{
+				Config: testAccVmsDataSourceConfig(srv, `
+  filter {
+    created_after  = "2025-06-01T00:30:00Z"
+    created_before = "2025-06-01T01:30:00Z"
+  }
+`),
+				Check: resource.ComposeAggregateTestCheckFunc(
+					resource.TestCheckResourceAttr("data.fluence_vms.test", "vms.#", "1"),
+					resource.TestCheckResourceAttr("data.fluence_vms.test", "vms.0.vm_name", "web-2"),
+				),
+			},
+			{
+				Config: testAccVmsDataSourceConfig(srv, `
+  filter {
+    name_regex          = "^web-"
+    min_price_per_epoch = "2"
+  }
+`),
+				Check: resource.ComposeAggregateTestCheckFunc(
+					resource.TestCheckResourceAttr("data.fluence_vms.test", "vms.#", "1"),
+					resource.TestCheckResourceAttr("data.fluence_vms.test", "vms.0.vm_name", "web-2"),
+				),
+			},
+			{
+				Config: testAccVmsDataSourceConfig(srv, `
+  filter {
+    os_image_contains = "debian"
+  }
+`),
+				Check: resource.ComposeAggregateTestCheckFunc(
+					resource.TestCheckResourceAttr("data.fluence_vms.test", "vms.#", "1"),
+					resource.TestCheckResourceAttr("data.fluence_vms.test", "vms.0.vm_name", "db"),
+				),
+			},
+			{
+				Config: testAccVmsDataSourceConfig(srv, `
+  filter {
+    name_regex = "web-("
+  }
+`),
+				ExpectError: regexp.MustCompile(`Invalid Regular Expression`),
+			},
+			{
+				Config: testAccVmsDataSourceConfig(srv, `
+  filter {
+    created_after = "yesterday"
+  }
+`),
+				ExpectError: regexp.MustCompile(`Invalid Time`),
+			},
+			// The limit applies after sorting
+			{
+				Config: testAccVmsDataSourceConfig(srv, `
+  sort_by = "name"
+  limit   = 2
+`),
+				Check: resource.ComposeAggregateTestCheckFunc(
+					resource.TestCheckResourceAttr("data.fluence_vms.test", "vms.#", "2"),
+					resource.TestCheckResourceAttr("data.fluence_vms.test", "vms.0.vm_name", "db"),
+					resource.TestCheckResourceAttr("data.fluence_vms.test", "vms.1.vm_name", "web-1"),
+				),
+			},
+		},
+	})
+}
+
+func TestVmsFilterModelMatcher(t *testing.T) {
+	name := func(s string) *string { return &s }
+	vms := []fluenceapi.RunningInstanceV3{
+		{
+			Id:            "web-1",
+			VmName:        name("web-1"),
+			Status:        fluenceapi.VmStatusActive,
+			PublicIp:      name("203.0.113.1"),
+			OsImage:       name(testAccOsImage),
+			CreatedAt:     "2025-06-01T00:00:00Z",
+			PricePerEpoch: "1.5",
+		},
+		{
+			Id:            "web-2",
+			VmName:        name("web-2"),
+			Status:        fluenceapi.VmStatusActive,
+			PublicIp:      name(""),
+			CreatedAt:     "2025-06-01T01:00:00Z",
+			PricePerEpoch: "3",
+		},
+		{
+			Id:            "unnamed",
+			Status:        fluenceapi.VmStatusLaunching,
+			CreatedAt:     "not a time",
+			PricePerEpoch: "not a price",
+		},
+	}
+
+	tests := []struct {
+		name     string
+		filter   vmsFilterModel
+		expected []string
+	}{
+		{
+			name:     "empty",
+			expected: []string{"web-1", "web-2", "unnamed"},
+		},
+		{
+			name:     "statuses",
+			filter:   vmsFilterModel{Statuses: []types.String{types.StringValue(fluenceapi.VmStatusLaunching), types.StringValue("Stopped")}},
+			expected: []string{"unnamed"},
+		},
+		{
+			name:     "name_regex skips unnamed VMs",
+			filter:   vmsFilterModel{NameRegex: types.StringValue(".*")},
+			expected: []string{"web-1", "web-2"},
+		},
+		{
+			name:     "has_public_ip treats an empty IP as none",
+			filter:   vmsFilterModel{HasPublicIp: types.BoolValue(true)},
+			expected: []string{"web-1"},
+		},
+		{
+			name:     "no public_ip",
+			filter:   vmsFilterModel{HasPublicIp: types.BoolValue(false)},
+			expected: []string{"web-2", "unnamed"},
+		},
+		{
+			name:     "os_image_contains",
+			filter:   vmsFilterModel{OsImageContains: types.StringValue("24.04")},
+			expected: []string{"web-1"},
+		},
+		{
+			name:     "created range is exclusive",
+			filter:   vmsFilterModel{CreatedAfter: types.StringValue("2025-06-01T00:00:00Z"), CreatedBefore: types.StringValue("2025-06-02T00:00:00Z")},
+			expected: []string{"web-2"},
+		},
+		{
+			name:     "price range is inclusive",
+			filter:   vmsFilterModel{MinPricePerEpoch: types.StringValue("1.5"), MaxPricePerEpoch: types.StringValue("3")},
+			expected: []string{"web-1", "web-2"},
+		},
+		{
+			name:     "conditions are combined",
+			filter:   vmsFilterModel{Statuses: []types.String{types.StringValue(fluenceapi.VmStatusActive)}, MaxPricePerEpoch: types.StringValue("2")},
+			expected: []string{"web-1"},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			match, err := test.filter.matcher()
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+
+			matched := []string{}
+			for _, vm := range vms {
+				if match(vm) {
+					matched = append(matched, vm.Id)
+				}
+			}
+			if fmt.Sprint(matched) != fmt.Sprint(test.expected) {
+				t.Errorf("matched %v, want %v", matched, test.expected)
+			}
+		})
+	}
+}
+
+func TestVmsFilterModelMatcher_invalid(t *testing.T) {
+	filters := map[string]vmsFilterModel{
+		"name_regex":          {NameRegex: types.StringValue("(")},
+		"created_after":       {CreatedAfter: types.StringValue("yesterday")},
+		"created_before":      {CreatedBefore: types.StringValue("2025-06-01")},
+		"min_price_per_epoch": {MinPricePerEpoch: types.StringValue("cheap")},
+		"max_price_per_epoch": {MaxPricePerEpoch: types.StringValue("1,5")},
+	}
+
+	for name, filter := range filters {
+		t.Run(name, func(t *testing.T) {
+			if _, err := filter.matcher(); err == nil {
+				t.Error("expected an error")
+			}
+		})
+	}
+}
+
+func testAccVmsDataSourceConfig(srv *fakeapi.Server, arguments string) string {
+	return testAccProviderConfig(srv) + fmt.Sprintf(`
+data "fluence_vms" "test" {
+%s
+}
+`, arguments)
+}
+
+// testAccCreateVms creates VMs outside of Terraform and lists them until
+// their status transitions are done. It returns the IDs of the VMs.
+func testAccCreateVms(t *testing.T, srv *fakeapi.Server, name, basicConfiguration, country, osImage string, instances int) []string {
+	t.Helper()
+
+	client := testAccClient(t, srv)
+	created, err := client.CreateVmV3(fluenceapi.CreateVmV3{
+		Constraints: &fluenceapi.OfferConstraints{
+			BasicConfiguration: &basicConfiguration,
+			Datacenter:         &fluenceapi.DatacenterConstraint{Countries: []string{country}},
+		},
+		Instances: instances,
+		VmConfiguration: fluenceapi.VmConfiguration{
+			Name:      name,
+			OsImage:   osImage,
+			OpenPorts: []fluenceapi.OpenPorts{{Port: 22, Protocol: fluenceapi.ProtocolTCP}},
+			SshKeys:   []string{},
+		},
+	})
+	if err != nil {
+		t.Fatalf("unable to create VMs: %s", err)
+	}
+
+	// Each listing moves the VMs one status further
+	for i := 0; i < 3; i++ {
+		if _, err := client.ListVmsV3(); err != nil {
+			t.Fatalf("unable to list VMs: %s", err)
+		}
+	}
+
+	ids := []string{}
+	for _, vm := range created {
+		ids = append(ids, vm.VmId)
+	}
+	return ids
+}
+
+func TestSortVms(t *testing.T) {
+	vm := func(id, createdAt, price string) fluenceapi.RunningInstanceV3 {
+		return fluenceapi.RunningInstanceV3{Id: id, CreatedAt: createdAt, PricePerEpoch: price}
+	}
+	vms := []fluenceapi.RunningInstanceV3{
+		vm("vm-5", "unknown", "n/a"),
+		vm("vm-4", "2025-06-01T02:00:00Z", "3"),
+		vm("vm-3", "", ""),
+		vm("vm-2", "2025-06-01T01:00:00Z", "1.5"),
+		vm("vm-1", "2025-06-01T02:00:00Z", "10"),
+	}
+
+	tests := map[string][]string{
+		// Unparsable values sort last, and equal times are ordered by ID
+		"created_at":      {"vm-2", "vm-1", "vm-4", "vm-3", "vm-5"},
+		"price_per_epoch": {"vm-2", "vm-4", "vm-1", "vm-3", "vm-5"},
+		// Every status is empty, so only the IDs order the VMs
+		"status": {"vm-1", "vm-2", "vm-3", "vm-4", "vm-5"},
+	}
+
+	for sortBy, want := range tests {
+		t.Run(sortBy, func(t *testing.T) {
+			sorted := append([]fluenceapi.RunningInstanceV3{}, vms...)
+			sortVms(sorted, sortBy)
+
+			got := make([]string, len(sorted))
+			for i, vm := range sorted {
+				got[i] = vm.Id
+			}
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("sortVms(%q) = %v, want %v", sortBy, got, want)
+			}
+		})
+	}
+}
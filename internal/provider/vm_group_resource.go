@@ -0,0 +1,947 @@
+package provider
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	fluenceapi "github.com/decentralized-infrastructure/fluence-api-client-go"
+	"github.com/hashicorp/go-uuid"
+	"github.com/hashicorp/terraform-plugin-framework-timeouts/resource/timeouts"
+	"github.com/hashicorp/terraform-plugin-framework-validators/int64validator"
+	"github.com/hashicorp/terraform-plugin-framework/attr"
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/int64default"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/planmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/stringplanmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/schema/validator"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-go/tftypes"
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+)
+
+// Ensure provider defined types fully satisfy framework interfaces.
+var _ resource.Resource = &VmGroupResource{}
+var _ resource.ResourceWithImportState = &VmGroupResource{}
+var _ resource.ResourceWithModifyPlan = &VmGroupResource{}
+
+func NewVmGroupResource() resource.Resource {
+	return &VmGroupResource{}
+}
+
+// VmGroupResource defines the resource implementation.
+type VmGroupResource struct {
+	client  *fluenceapi.Client
+	cache   *apiCache
+	polling pollConfig
+	guard   *spendingGuard
+}
+
+// VmGroupResourceModel describes the resource data model.
+type VmGroupResourceModel struct {
+	ID             types.String    `tfsdk:"id"`
+	Name           types.String    `tfsdk:"name"`
+	Hostname       types.String    `tfsdk:"hostname"`
+	OsImage        types.String    `tfsdk:"os_image"`
+	SshKeys        []types.String  `tfsdk:"ssh_keys"`
+	OpenPorts      []OpenPortModel `tfsdk:"open_ports"`
+	Instances      types.Int64     `tfsdk:"instances"`
+	MaxUnavailable types.Int64     `tfsdk:"max_unavailable"`
+
+	// Constraints (optional)
+	ConstraintsModel
+
+	// Computed fields, keyed by member index
+	Members types.Map `tfsdk:"members"`
+
+	// Timeouts
+	Timeouts timeouts.Value `tfsdk:"timeouts"`
+}
+
+// VmGroupMemberModel describes one VM of a group
+type VmGroupMemberModel struct {
+	ID            types.String `tfsdk:"id"`
+	Name          types.String `tfsdk:"name"`
+	PublicIp      types.String `tfsdk:"public_ip"`
+	Status        types.String `tfsdk:"status"`
+	PricePerEpoch types.String `tfsdk:"price_per_epoch"`
+	OsImage       types.String `tfsdk:"os_image"`
+}
+
+// vmGroupMemberType is the object type of the members map elements
+var vmGroupMemberType = types.ObjectType{
+	AttrTypes: map[string]attr.Type{
+		"id":              types.StringType,
+		"name":            types.StringType,
+		"public_ip":       types.StringType,
+		"status":          types.StringType,
+		"price_per_epoch": types.StringType,
+		"os_image":        types.StringType,
+	},
+}
+
+func (r *VmGroupResource) Metadata(ctx context.Context, req resource.MetadataRequest, resp *resource.MetadataResponse) {
+	resp.TypeName = req.ProviderTypeName + "_vm_group"
+}
+
+func (r *VmGroupResource) Schema(ctx context.Context, req resource.SchemaRequest, resp *resource.SchemaResponse) {
+	attributes := map[string]schema.Attribute{
+		"id": schema.StringAttribute{
+			Computed:            true,
+			MarkdownDescription: "Group identifier",
+			PlanModifiers: []planmodifier.String{
+				stringplanmodifier.UseStateForUnknown(),
+			},
+		},
+		"name": schema.StringAttribute{
+			MarkdownDescription: "Name of every VM in the group",
+			Required:            true,
+		},
+		"hostname": schema.StringAttribute{
+			MarkdownDescription: "VM hostname (optional). Changing this forces a new resource to be created",
+			Optional:            true,
+			PlanModifiers: []planmodifier.String{
+				stringRequiresReplaceUnlessImported(),
+			},
+		},
+		"os_image": schema.StringAttribute{
+			MarkdownDescription: "Operating system image to use. Changing this replaces the members running another image in place, " +
+				"`max_unavailable` at a time",
+			Required: true,
+		},
+		"ssh_keys": schema.ListAttribute{
+			MarkdownDescription: "List of SSH key fingerprints to authorize. Changing this forces a new resource to be created",
+			ElementType:         types.StringType,
+			Required:            true,
+			PlanModifiers: []planmodifier.List{
+				listRequiresReplaceUnlessImported(),
+			},
+		},
+		"open_ports": openPortsAttribute(),
+		"instances": schema.Int64Attribute{
+			MarkdownDescription: "Number of VMs in the group. Scaling up creates the missing members and scaling down " +
+				"removes the members with the highest indices",
+			Optional: true,
+			Computed: true,
+			Default:  int64default.StaticInt64(1),
+			Validators: []validator.Int64{
+				int64validator.AtLeast(1),
+			},
+		},
+		"max_unavailable": schema.Int64Attribute{
+			MarkdownDescription: "Maximum number of members replaced at the same time when `os_image` changes. Defaults to 1",
+			Optional:            true,
+			Computed:            true,
+			Default:             int64default.StaticInt64(1),
+			Validators: []validator.Int64{
+				int64validator.AtLeast(1),
+			},
+		},
+
+		// Computed attributes
+		"members": schema.MapNestedAttribute{
+			Computed: true,
+			MarkdownDescription: "VMs of the group, keyed by their index from `0` to `instances - 1`. " +
+				"A member keeps its index when it is replaced",
+			PlanModifiers: []planmodifier.Map{
+				unchangedMembersModifier{},
+			},
+			NestedObject: schema.NestedAttributeObject{
+				Attributes: map[string]schema.Attribute{
+					"id": schema.StringAttribute{
+						Computed:            true,
+						MarkdownDescription: "VM identifier",
+					},
+					"name": schema.StringAttribute{
+						Computed:            true,
+						MarkdownDescription: "VM name",
+					},
+					"public_ip": schema.StringAttribute{
+						Computed:            true,
+						MarkdownDescription: "Public IP address of the VM",
+					},
+					"status": schema.StringAttribute{
+						Computed:            true,
+						MarkdownDescription: "VM status",
+					},
+					"price_per_epoch": schema.StringAttribute{
+						Computed:            true,
+						MarkdownDescription: "Price per epoch",
+					},
+					"os_image": schema.StringAttribute{
+						Computed:            true,
+						MarkdownDescription: "Operating system image the VM runs. Only the members whose image differs from `os_image` are replaced when it changes",
+					},
+				},
+			},
+		},
+	}
+	for name, attribute := range vmConstraintAttributes() {
+		attributes[name] = attribute
+	}
+
+	resp.Schema = schema.Schema{
+		MarkdownDescription: "Group of identical Virtual Machines created from a single specification. `name`, `open_ports`, " +
+			"`instances`, `max_unavailable`, `max_total_price_per_epoch_usd` and `os_image` are updated in place. " +
+			"Changing any other argument replaces the whole group",
+
+		Attributes: attributes,
+		Blocks: map[string]schema.Block{
+			"timeouts": timeouts.Block(ctx, timeouts.Opts{
+				Create: true,
+				Update: true,
+				Delete: true,
+			}),
+		},
+	}
+}
+
+func (r *VmGroupResource) Configure(ctx context.Context, req resource.ConfigureRequest, resp *resource.ConfigureResponse) {
+	// Prevent panic if the provider has not been configured.
+	if req.ProviderData == nil {
+		return
+	}
+
+	providerData, ok := req.ProviderData.(*fluenceProviderData)
+
+	if !ok {
+		resp.Diagnostics.AddError(
+			"Unexpected Resource Configure Type",
+			fmt.Sprintf("Expected *fluenceProviderData, got: %T. Please report this issue to the provider developers.", req.ProviderData),
+		)
+
+		return
+	}
+
+	r.client = providerData.client
+	r.cache = providerData.cache
+	r.polling = providerData.polling
+	r.guard = providerData.guard
+}
+
+func (r *VmGroupResource) waiter() vmWaiter {
+	return vmWaiter{client: r.client, polling: r.polling}
+}
+
+func (r *VmGroupResource) Create(ctx context.Context, req resource.CreateRequest, resp *resource.CreateResponse) {
+	var data VmGroupResourceModel
+
+	// Read Terraform plan data into the model
+	resp.Diagnostics.Append(req.Plan.Get(ctx, &data)...)
+
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	groupId, err := uuid.GenerateUUID()
+	if err != nil {
+		resp.Diagnostics.AddError("Client Error", fmt.Sprintf("Unable to generate VM group ID, got error: %s", err))
+		return
+	}
+	data.ID = types.StringValue(groupId)
+
+	indices := make([]int, data.Instances.ValueInt64())
+	for i := range indices {
+		indices[i] = i
+	}
+
+	createTimeout, diags := data.Timeouts.Create(ctx, 10*time.Minute)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	members := map[int]string{}
+	err = r.createMembers(ctx, &data, members, indices, createTimeout)
+	if err != nil {
+		if len(members) > 0 {
+			// Save the created VMs anyway so Terraform marks the resource as
+			// tainted and releases them on the next apply or destroy
+			resp.Diagnostics.Append(resp.State.Set(ctx, &data)...)
+		}
+		resp.Diagnostics.AddError("VM Group Creation Error", fmt.Sprintf("Unable to create VM group: %s", err))
+		return
+	}
+
+	tflog.Trace(ctx, "created VM group resource", map[string]interface{}{
+		"id":      groupId,
+		"members": members,
+	})
+
+	// Save data into Terraform state
+	resp.Diagnostics.Append(resp.State.Set(ctx, &data)...)
+}
+
+func (r *VmGroupResource) Read(ctx context.Context, req resource.ReadRequest, resp *resource.ReadResponse) {
+	var data VmGroupResourceModel
+
+	// Read Terraform prior state data into the model
+	resp.Diagnostics.Append(req.State.Get(ctx, &data)...)
+
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	members, diags := data.memberIds()
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	err := r.refreshMembers(ctx, &data, members, 1, true)
+	if err != nil {
+		if errors.Is(err, errVmNotFound) {
+			tflog.Warn(ctx, "No VM group member found, removing from state", map[string]interface{}{
+				"id":      data.ID.ValueString(),
+				"members": members,
+			})
+			resp.State.RemoveResource(ctx)
+			return
+		}
+		resp.Diagnostics.AddError("Client Error", fmt.Sprintf("Unable to refresh VM group data: %s", err))
+		return
+	}
+
+	// Save updated data into Terraform state
+	resp.Diagnostics.Append(resp.State.Set(ctx, &data)...)
+}
+
+func (r *VmGroupResource) Update(ctx context.Context, req resource.UpdateRequest, resp *resource.UpdateResponse) {
+	var data, state VmGroupResourceModel
+
+	// Read Terraform plan and prior state data into the models
+	resp.Diagnostics.Append(req.Plan.Get(ctx, &data)...)
+	resp.Diagnostics.Append(req.State.Get(ctx, &state)...)
+
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	members, diags := state.memberIds()
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	images := state.memberImages()
+	plannedMembers := data.Members
+	data.setMembers(members, nil)
+
+	updateTimeout, diags := data.Timeouts.Update(ctx, 10*time.Minute)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	target := int(data.Instances.ValueInt64())
+
+	// Remove the members beyond the new size first, so they are not replaced
+	var removed []string
+	for _, index := range sortedIndices(members) {
+		if index >= target {
+			removed = append(removed, members[index])
+			delete(members, index)
+		}
+	}
+	if len(removed) > 0 {
+		tflog.Debug(ctx, "Scaling down VM group", map[string]interface{}{
+			"id":      data.ID.ValueString(),
+			"removed": removed,
+		})
+
+		_, err := r.guard.removeVms(ctx, removed)
+		r.cache.InvalidateVms()
+		if err != nil {
+			resp.Diagnostics.AddError("Client Error", fmt.Sprintf("Unable to remove VMs while scaling down, got error: %s", err))
+			return
+		}
+		data.setMembers(members, nil)
+
+		// Wait until the removed VMs are actually gone, as when replacing
+		// members, so a new member does not race with the termination
+		err = r.waiter().waitForVmDeleted(ctx, removed, updateTimeout)
+		if err != nil {
+			resp.Diagnostics.Append(resp.State.Set(ctx, &data)...)
+			resp.Diagnostics.AddError("VM Group Scaling Error", fmt.Sprintf("VM removal was requested while scaling down but did not complete: %s", err))
+			return
+		}
+	}
+
+	// Replace the remaining members that do not run the planned image. The
+	// image of members created before it was tracked is the group's one.
+	var stale []int
+	for _, index := range sortedIndices(members) {
+		image, ok := images[members[index]]
+		if !ok {
+			image = state.OsImage.ValueString()
+		}
+		if image != data.OsImage.ValueString() {
+			stale = append(stale, index)
+		}
+	}
+	if len(stale) > 0 {
+		err := r.replaceMembers(ctx, &data, members, stale, updateTimeout)
+		if err != nil {
+			resp.Diagnostics.Append(resp.State.Set(ctx, &data)...)
+			resp.Diagnostics.AddError("VM Group Update Error", fmt.Sprintf("Unable to replace VM group members: %s", err))
+			return
+		}
+	}
+
+	// Create the missing members
+	var missing []int
+	for index := 0; index < target; index++ {
+		if _, ok := members[index]; !ok {
+			missing = append(missing, index)
+		}
+	}
+	if len(missing) > 0 {
+		err := r.createMembers(ctx, &data, members, missing, updateTimeout)
+		if err != nil {
+			resp.Diagnostics.Append(resp.State.Set(ctx, &data)...)
+			resp.Diagnostics.AddError("VM Group Scaling Error", fmt.Sprintf("Unable to add VM group members: %s", err))
+			return
+		}
+	}
+
+	// Apply the name and open ports to every member
+	var vmName *string
+	if !data.Name.IsNull() {
+		name := data.Name.ValueString()
+		vmName = &name
+	}
+
+	vmModel := data.vmModel()
+	openPorts := vmModel.apiOpenPorts()
+	vmIds := memberVmIds(members)
+
+	updates := []fluenceapi.UpdateVm{}
+	for _, vmId := range vmIds {
+		updates = append(updates, fluenceapi.UpdateVm{
+			Id:        vmId,
+			VmName:    vmName,
+			OpenPorts: &openPorts,
+		})
+	}
+
+	err := withContext(ctx, r.client).UpdateVms(updates)
+	r.cache.InvalidateVms()
+	if err != nil {
+		resp.Diagnostics.AddError("Client Error", fmt.Sprintf("Unable to update VM group members, got error: %s", err))
+		return
+	}
+
+	err = r.waiter().waitForVmPorts(ctx, vmIds, openPorts, updateTimeout)
+	if err != nil {
+		resp.Diagnostics.AddError("VM Group Update Error", fmt.Sprintf("VM group was updated but open ports did not settle: %s", err))
+		return
+	}
+
+	// Refresh the data after update, allowing new members to show up
+	err = r.refreshMembers(ctx, &data, members, 5, false)
+	if err != nil {
+		resp.Diagnostics.AddError("Client Error", fmt.Sprintf("Unable to refresh VM group data after update: %s", err))
+		return
+	}
+
+	// Members kept by the plan must be saved as planned
+	if !plannedMembers.IsUnknown() {
+		data.Members = plannedMembers
+	}
+
+	// The configured create-only arguments are now recorded in the state
+	resp.Diagnostics.Append(clearImported(ctx, resp.Private)...)
+
+	// Save updated data into Terraform state
+	resp.Diagnostics.Append(resp.State.Set(ctx, &data)...)
+}
+
+func (r *VmGroupResource) Delete(ctx context.Context, req resource.DeleteRequest, resp *resource.DeleteResponse) {
+	var data VmGroupResourceModel
+
+	// Read Terraform prior state data into the model
+	resp.Diagnostics.Append(req.State.Get(ctx, &data)...)
+
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	members, diags := data.memberIds()
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	vmIds := memberVmIds(members)
+	if len(vmIds) == 0 {
+		return
+	}
+
+	_, err := r.guard.removeVms(ctx, vmIds)
+	r.cache.InvalidateVms()
+	if err != nil {
+		resp.Diagnostics.AddError("Client Error", fmt.Sprintf("Unable to delete VM group, got error: %s", err))
+		return
+	}
+
+	deleteTimeout, diags := data.Timeouts.Delete(ctx, 10*time.Minute)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	err = r.waiter().waitForVmDeleted(ctx, vmIds, deleteTimeout)
+	if err != nil {
+		resp.Diagnostics.AddError("VM Group Deletion Error", fmt.Sprintf("VM group removal was requested but did not complete: %s", err))
+		return
+	}
+}
+
+// ModifyPlan refuses a plan whose new members would break the provider
+// spending limits, before any other resource of the run is applied. Members
+// replaced in place are removed before their replacements are created, so
+// only a larger instance count is checked. createVms checks the limits again
+// during apply.
+func (r *VmGroupResource) ModifyPlan(ctx context.Context, req resource.ModifyPlanRequest, resp *resource.ModifyPlanResponse) {
+	// Nothing to check on destroy or before the provider is configured
+	if req.Plan.Raw.IsNull() || r.guard == nil {
+		return
+	}
+
+	var planned, prior types.Int64
+	resp.Diagnostics.Append(req.Plan.GetAttribute(ctx, path.Root("instances"), &planned)...)
+	if !req.State.Raw.IsNull() {
+		resp.Diagnostics.Append(req.State.GetAttribute(ctx, path.Root("instances"), &prior)...)
+	}
+	if resp.Diagnostics.HasError() || planned.IsUnknown() {
+		return
+	}
+
+	added := int(planned.ValueInt64() - prior.ValueInt64())
+	if added <= 0 {
+		return
+	}
+
+	// The new members are priced like in createVms, once their constraints
+	// are known
+	newPrice := 0.0
+	priced := false
+	if r.guard.limits.MaxTotalPricePerEpoch > 0 {
+		constraints, diags := plannedConstraints(ctx, req.Plan)
+		resp.Diagnostics.Append(diags...)
+		if resp.Diagnostics.HasError() {
+			return
+		}
+
+		if constraints != nil {
+			price, err := r.guard.estimatePrice(ctx, constraints.offerConstraints(), added)
+			if err != nil {
+				resp.Diagnostics.AddWarning("Spending Limit Check Error", fmt.Sprintf("Unable to check the price of the new members at plan time, it is checked again during apply: %s", err))
+			} else {
+				newPrice = price
+				priced = true
+			}
+		}
+	}
+
+	resp.Diagnostics.Append(r.guard.checkPlannedVms(ctx, added, newPrice, priced)...)
+}
+
+// ImportState imports a group from a comma-separated list of VM IDs. The VMs
+// become the members 0, 1, ... in the given order. The first apply after the
+// import records the configured ssh_keys, hostname and constraints in place.
+func (r *VmGroupResource) ImportState(ctx context.Context, req resource.ImportStateRequest, resp *resource.ImportStateResponse) {
+	members := map[int]string{}
+	for i, id := range strings.Split(req.ID, ",") {
+		id = strings.TrimSpace(id)
+		if id == "" {
+			resp.Diagnostics.AddError(
+				"Invalid Import ID",
+				fmt.Sprintf("Expected a comma-separated list of VM IDs, got: %q", req.ID),
+			)
+			return
+		}
+		members[i] = id
+	}
+
+	groupId, err := uuid.GenerateUUID()
+	if err != nil {
+		resp.Diagnostics.AddError("Client Error", fmt.Sprintf("Unable to generate VM group ID, got error: %s", err))
+		return
+	}
+
+	var data VmGroupResourceModel
+	data.setMembers(members, nil)
+
+	resp.Diagnostics.Append(resp.State.SetAttribute(ctx, path.Root("id"), groupId)...)
+	resp.Diagnostics.Append(resp.State.SetAttribute(ctx, path.Root("members"), data.Members)...)
+
+	// ssh_keys, hostname and the constraints cannot be read back from the
+	// API, so they are adopted from the configuration on the next apply
+	// instead of replacing the whole group
+	resp.Diagnostics.Append(markImported(ctx, resp.Private)...)
+}
+
+// createMembers creates one VM for each of the given indices with a single
+// request and waits for them to become active. members is updated as soon as
+// the VMs exist, so the caller can save them even if the wait fails.
+func (r *VmGroupResource) createMembers(ctx context.Context, data *VmGroupResourceModel, members map[int]string, indices []int, timeout time.Duration) error {
+	tflog.Debug(ctx, "Creating VM group members", map[string]interface{}{
+		"id":      data.ID.ValueString(),
+		"indices": indices,
+	})
+
+	vmModel := data.vmModel()
+	createdVms, err := r.guard.createVms(ctx, vmModel.createVmRequest(len(indices)))
+	r.cache.InvalidateVms()
+	if errors.Is(err, errSpendingLimitExceeded) {
+		return err
+	}
+	if err != nil {
+		return fmt.Errorf("unable to create VMs, got error: %w", err)
+	}
+
+	if len(createdVms) != len(indices) {
+		// Record what was created so it is not orphaned
+		for i, createdVm := range createdVms {
+			if i < len(indices) {
+				members[indices[i]] = createdVm.VmId
+			}
+		}
+		data.setMembers(members, nil)
+		return fmt.Errorf("expected %d VMs to be created, got %d", len(indices), len(createdVms))
+	}
+
+	vmIds := make([]string, len(createdVms))
+	for i, createdVm := range createdVms {
+		members[indices[i]] = createdVm.VmId
+		vmIds[i] = createdVm.VmId
+	}
+	data.setMembers(members, nil)
+
+	vms, err := r.waiter().waitForVmActive(ctx, vmIds, timeout)
+	data.setMembers(members, vms)
+	if err != nil {
+		return fmt.Errorf("VMs were created but failed to become active: %w", err)
+	}
+
+	return nil
+}
+
+// replaceMembers replaces the members at the given indices, in increasing
+// order, with VMs created from the planned configuration. At most
+// max_unavailable members are replaced at a time: the old VMs of a batch are
+// terminated before their replacements are created, and the next batch starts
+// once the replacements are active.
+func (r *VmGroupResource) replaceMembers(ctx context.Context, data *VmGroupResourceModel, members map[int]string, indices []int, timeout time.Duration) error {
+	batchSize := int(data.MaxUnavailable.ValueInt64())
+	if batchSize < 1 {
+		batchSize = 1
+	}
+
+	for start := 0; start < len(indices); start += batchSize {
+		end := start + batchSize
+		if end > len(indices) {
+			end = len(indices)
+		}
+		batch := indices[start:end]
+
+		oldIds := make([]string, len(batch))
+		for i, index := range batch {
+			oldIds[i] = members[index]
+		}
+
+		tflog.Info(ctx, "Replacing VM group members", map[string]interface{}{
+			"id":      data.ID.ValueString(),
+			"indices": batch,
+			"vm_ids":  oldIds,
+		})
+
+		_, err := r.guard.removeVms(ctx, oldIds)
+		r.cache.InvalidateVms()
+		if err != nil {
+			return fmt.Errorf("unable to remove VMs %v, got error: %w", oldIds, err)
+		}
+
+		for _, index := range batch {
+			delete(members, index)
+		}
+		data.setMembers(members, nil)
+
+		err = r.waiter().waitForVmDeleted(ctx, oldIds, timeout)
+		if err != nil {
+			return err
+		}
+
+		err = r.createMembers(ctx, data, members, batch, timeout)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// refreshMembers fetches the current data of every member and updates the
+// model. Members that no longer exist or were terminated are dropped, and
+// errVmNotFound is returned when none of them exist. Newly created VMs might
+// not be listed immediately, so the lookup is tried up to maxAttempts times
+// while some members are missing. When refreshPorts is set, the open ports of
+// the member with the lowest index replace the configured ones.
+func (r *VmGroupResource) refreshMembers(ctx context.Context, data *VmGroupResourceModel, members map[int]string, maxAttempts int, refreshPorts bool) error {
+	vmIds := memberVmIds(members)
+	tflog.Debug(ctx, "Attempting to refresh VM group data", map[string]interface{}{
+		"id":     data.ID.ValueString(),
+		"vm_ids": vmIds,
+	})
+
+	p := r.polling.newPoller()
+
+	var foundVms []fluenceapi.RunningInstanceV3
+	for {
+		vms, err := r.cache.ListVms(ctx)
+		if err != nil {
+			return fmt.Errorf("unable to read VMs: %w", err)
+		}
+
+		foundVms = liveVms(findVms(vms, vmIds))
+		if len(foundVms) == len(vmIds) || p.Attempt() >= maxAttempts {
+			break
+		}
+
+		tflog.Debug(ctx, "Not all VM group members found in API response, retrying", map[string]interface{}{
+			"vm_ids":  vmIds,
+			"found":   len(foundVms),
+			"attempt": p.Attempt(),
+		})
+
+		if err := p.Wait(ctx); err != nil {
+			return err
+		}
+
+		// The shared listing predates our VMs, fetch a fresh one
+		r.cache.InvalidateVms()
+	}
+
+	if len(foundVms) == 0 {
+		return fmt.Errorf("%w after %d attempt(s)", errVmNotFound, p.Attempt())
+	}
+
+	// Drop the members that are gone, so they are recreated on the next apply
+	live := map[string]bool{}
+	for _, vm := range foundVms {
+		live[vm.Id] = true
+	}
+	for index, vmId := range members {
+		if !live[vmId] {
+			tflog.Warn(ctx, "VM group member no longer exists", map[string]interface{}{
+				"index": index,
+				"vm_id": vmId,
+			})
+			delete(members, index)
+		}
+	}
+
+	data.setMembers(members, foundVms)
+	data.Instances = types.Int64Value(int64(len(members)))
+
+	// foundVms follows vmIds, which are sorted by member index
+	first := foundVms[0]
+	if first.VmName != nil {
+		data.Name = types.StringValue(*first.VmName)
+	}
+
+	// Report an image that differs from the configured one, such as after an
+	// interrupted rolling replacement, so the next apply resumes it. The image
+	// of every member is tracked, so only the stale ones are replaced then.
+	for _, vm := range foundVms {
+		if vm.OsImage != nil && *vm.OsImage != data.OsImage.ValueString() {
+			data.OsImage = types.StringValue(*vm.OsImage)
+			break
+		}
+	}
+
+	if refreshPorts {
+		vmModel := data.vmModel()
+		vmModel.refreshOpenPorts(first)
+		data.OpenPorts = vmModel.OpenPorts
+	}
+
+	return nil
+}
+
+// vmModel returns a fluence_vm model with the configuration and constraints
+// of the group, so that VM requests are built the same way for both resources.
+func (m *VmGroupResourceModel) vmModel() VmResourceModel {
+	return VmResourceModel{
+		Name:             m.Name,
+		Hostname:         m.Hostname,
+		OsImage:          m.OsImage,
+		SshKeys:          m.SshKeys,
+		OpenPorts:        m.OpenPorts,
+		ConstraintsModel: m.ConstraintsModel,
+	}
+}
+
+// memberIds returns the VM ID of every member, keyed by member index.
+func (m *VmGroupResourceModel) memberIds() (map[int]string, diag.Diagnostics) {
+	var diags diag.Diagnostics
+
+	members := map[int]string{}
+	if m.Members.IsNull() || m.Members.IsUnknown() {
+		return members, diags
+	}
+
+	for key, elem := range m.Members.Elements() {
+		index, err := strconv.Atoi(key)
+		if err != nil {
+			diags.AddError("Invalid State", fmt.Sprintf("VM group member key %q is not an index", key))
+			continue
+		}
+
+		member, ok := elem.(types.Object)
+		if !ok {
+			continue
+		}
+		if id, ok := member.Attributes()["id"].(types.String); ok && !id.IsNull() && !id.IsUnknown() {
+			members[index] = id.ValueString()
+		}
+	}
+	return members, diags
+}
+
+// memberImages returns the OS image of the members whose image is known,
+// keyed by VM ID.
+func (m *VmGroupResourceModel) memberImages() map[string]string {
+	images := map[string]string{}
+	if m.Members.IsNull() || m.Members.IsUnknown() {
+		return images
+	}
+
+	for _, elem := range m.Members.Elements() {
+		member, ok := elem.(types.Object)
+		if !ok {
+			continue
+		}
+		id, ok := member.Attributes()["id"].(types.String)
+		if !ok || id.IsNull() || id.IsUnknown() {
+			continue
+		}
+		if image, ok := member.Attributes()["os_image"].(types.String); ok && !image.IsNull() && !image.IsUnknown() {
+			images[id.ValueString()] = image.ValueString()
+		}
+	}
+	return images
+}
+
+// setMembers records the members of the group. Data of the members is taken
+// from vms when they are listed there; other members only carry their ID.
+func (m *VmGroupResourceModel) setMembers(members map[int]string, vms []fluenceapi.RunningInstanceV3) {
+	byId := make(map[string]fluenceapi.RunningInstanceV3, len(vms))
+	for _, vm := range vms {
+		byId[vm.Id] = vm
+	}
+
+	elems := make(map[string]attr.Value, len(members))
+	for index, vmId := range members {
+		member := map[string]attr.Value{
+			"id":              types.StringValue(vmId),
+			"name":            types.StringNull(),
+			"public_ip":       types.StringNull(),
+			"status":          types.StringNull(),
+			"price_per_epoch": types.StringNull(),
+			"os_image":        types.StringNull(),
+		}
+
+		if vm, ok := byId[vmId]; ok {
+			member["status"] = types.StringValue(vm.Status)
+			member["price_per_epoch"] = types.StringValue(vm.PricePerEpoch)
+			if vm.OsImage != nil {
+				member["os_image"] = types.StringValue(*vm.OsImage)
+			}
+			if vm.VmName != nil {
+				member["name"] = types.StringValue(*vm.VmName)
+			}
+			if vm.PublicIp != nil {
+				member["public_ip"] = types.StringValue(*vm.PublicIp)
+			}
+		}
+
+		elems[strconv.Itoa(index)] = types.ObjectValueMust(vmGroupMemberType.AttrTypes, member)
+	}
+
+	m.Members = types.MapValueMust(vmGroupMemberType, elems)
+}
+
+// sortedIndices returns the member indices in increasing order.
+func sortedIndices(members map[int]string) []int {
+	indices := make([]int, 0, len(members))
+	for index := range members {
+		indices = append(indices, index)
+	}
+	sort.Ints(indices)
+	return indices
+}
+
+// memberVmIds returns the VM IDs of the members, sorted by member index.
+func memberVmIds(members map[int]string) []string {
+	vmIds := []string{}
+	for _, index := range sortedIndices(members) {
+		vmIds = append(vmIds, members[index])
+	}
+	return vmIds
+}
+
+// memberIndependentAttributes are the attributes of a group whose changes
+// leave its members as they are.
+var memberIndependentAttributes = map[string]bool{
+	"id":                            true,
+	"members":                       true,
+	"max_unavailable":               true,
+	"max_total_price_per_epoch_usd": true,
+	"timeouts":                      true,
+}
+
+// unchangedMembersModifier plans the members of the prior state when only
+// attributes that do not affect them change, so that such updates do not show
+// every member as unknown. Update keeps planned members as they are.
+type unchangedMembersModifier struct{}
+
+func (m unchangedMembersModifier) Description(_ context.Context) string {
+	return "Keeps the prior members unless an attribute affecting them changes."
+}
+
+func (m unchangedMembersModifier) MarkdownDescription(ctx context.Context) string {
+	return m.Description(ctx)
+}
+
+func (m unchangedMembersModifier) PlanModifyMap(ctx context.Context, req planmodifier.MapRequest, resp *planmodifier.MapResponse) {
+	// Nothing to keep on create, and known values are left alone
+	if req.StateValue.IsNull() || !req.PlanValue.IsUnknown() {
+		return
+	}
+
+	var planned, prior map[string]tftypes.Value
+	if err := req.Plan.Raw.As(&planned); err != nil {
+		resp.Diagnostics.AddError("Unable to Read Plan", err.Error())
+		return
+	}
+	if err := req.State.Raw.As(&prior); err != nil {
+		resp.Diagnostics.AddError("Unable to Read State", err.Error())
+		return
+	}
+
+	for name, value := range planned {
+		if !memberIndependentAttributes[name] && !value.Equal(prior[name]) {
+			return
+		}
+	}
+
+	resp.PlanValue = req.StateValue
+}
@@ -0,0 +1,231 @@
+package provider
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-testing/helper/resource"
+	"github.com/hashicorp/terraform-plugin-testing/knownvalue"
+	"github.com/hashicorp/terraform-plugin-testing/plancheck"
+	"github.com/hashicorp/terraform-plugin-testing/terraform"
+	"github.com/hashicorp/terraform-plugin-testing/tfjsonpath"
+
+	"terraform-provider-fluence/internal/fakeapi"
+)
+
+// testAccOtherOsImage is the image the acceptance tests upgrade VMs to.
+const testAccOtherOsImage = "https://cloud-images.ubuntu.com/releases/22.04/release/ubuntu-22.04-server-cloudimg-amd64.img"
+
+func TestAccVmGroupResource(t *testing.T) {
+	srv := testAccServer(t)
+	publicKey := testAccPublicKey(t, "")
+
+	resource.Test(t, resource.TestCase{
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		CheckDestroy:             testAccCheckVmsDestroyed(srv),
+		Steps: []resource.TestStep{
+			{
+				Config: testAccVmGroupResourceConfig(srv, publicKey, "acc-group", testAccOsImage, 2, 22),
+				Check: resource.ComposeAggregateTestCheckFunc(
+					resource.TestCheckResourceAttrSet("fluence_vm_group.test", "id"),
+					resource.TestCheckResourceAttr("fluence_vm_group.test", "members.%", "2"),
+					resource.TestCheckResourceAttr("fluence_vm_group.test", "members.0.status", "Active"),
+					resource.TestCheckResourceAttr("fluence_vm_group.test", "members.1.name", "acc-group"),
+					resource.TestCheckResourceAttr("fluence_vm_group.test", "members.1.price_per_epoch", "1.5"),
+					testAccCheckFakeVms(srv, 2, "acc-group", 22),
+				),
+			},
+			// Rename, open a port and scale up in place
+			{
+				Config: testAccVmGroupResourceConfig(srv, publicKey, "acc-group-renamed", testAccOsImage, 3, 22, 443),
+				ConfigPlanChecks: resource.ConfigPlanChecks{
+					PreApply: []plancheck.PlanCheck{
+						plancheck.ExpectResourceAction("fluence_vm_group.test", plancheck.ResourceActionUpdate),
+					},
+				},
+				Check: resource.ComposeAggregateTestCheckFunc(
+					resource.TestCheckResourceAttr("fluence_vm_group.test", "members.%", "3"),
+					resource.TestCheckResourceAttr("fluence_vm_group.test", "members.2.name", "acc-group-renamed"),
+					testAccCheckFakeVms(srv, 3, "acc-group-renamed", 22, 443),
+				),
+			},
+			// Closing a port updates the members, which are refreshed
+			{
+				Config: testAccVmGroupResourceConfig(srv, publicKey, "acc-group-renamed", testAccOsImage, 3, 22),
+				ConfigPlanChecks: resource.ConfigPlanChecks{
+					PreApply: []plancheck.PlanCheck{
+						plancheck.ExpectResourceAction("fluence_vm_group.test", plancheck.ResourceActionUpdate),
+						plancheck.ExpectUnknownValue("fluence_vm_group.test", tfjsonpath.New("members")),
+					},
+				},
+				Check: testAccCheckFakeVms(srv, 3, "acc-group-renamed", 22),
+			},
+			// Changing max_unavailable leaves the members as they are
+			{
+				Config: strings.Replace(testAccVmGroupResourceConfig(srv, publicKey, "acc-group-renamed", testAccOsImage, 3, 22),
+					"max_unavailable = 2", "max_unavailable = 3", 1),
+				ConfigPlanChecks: resource.ConfigPlanChecks{
+					PreApply: []plancheck.PlanCheck{
+						plancheck.ExpectResourceAction("fluence_vm_group.test", plancheck.ResourceActionUpdate),
+						plancheck.ExpectKnownValue("fluence_vm_group.test", tfjsonpath.New("members"), knownvalue.MapSizeExact(3)),
+					},
+				},
+				Check: resource.TestCheckResourceAttr("fluence_vm_group.test", "max_unavailable", "3"),
+			},
+			// A new image replaces the members in place, keeping their indices
+			{
+				Config: testAccVmGroupResourceConfig(srv, publicKey, "acc-group-renamed", testAccOtherOsImage, 3, 22, 443),
+				ConfigPlanChecks: resource.ConfigPlanChecks{
+					PreApply: []plancheck.PlanCheck{
+						plancheck.ExpectResourceAction("fluence_vm_group.test", plancheck.ResourceActionUpdate),
+					},
+				},
+				Check: resource.ComposeAggregateTestCheckFunc(
+					resource.TestCheckResourceAttr("fluence_vm_group.test", "members.%", "3"),
+					resource.TestCheckResourceAttr("fluence_vm_group.test", "members.0.os_image", testAccOtherOsImage),
+					resource.TestCheckResourceAttr("fluence_vm_group.test", "members.2.os_image", testAccOtherOsImage),
+					testAccCheckFakeVms(srv, 3, "acc-group-renamed", 22, 443),
+					testAccCheckFakeVmImages(srv, testAccOtherOsImage),
+				),
+			},
+			// Scaling down removes the highest indices
+			{
+				Config: testAccVmGroupResourceConfig(srv, publicKey, "acc-group-renamed", testAccOtherOsImage, 1, 22, 443),
+				Check: resource.ComposeAggregateTestCheckFunc(
+					resource.TestCheckResourceAttr("fluence_vm_group.test", "members.%", "1"),
+					resource.TestCheckResourceAttrSet("fluence_vm_group.test", "members.0.id"),
+					testAccCheckFakeVms(srv, 1, "acc-group-renamed", 22, 443),
+				),
+			},
+		},
+	})
+}
+
+func TestAccVmGroupResource_adopt(t *testing.T) {
+	srv := testAccServer(t)
+	publicKey := testAccPublicKey(t, "")
+	ids := testAccCreateVms(t, srv, "acc-group", "cpu-2-ram-4gb-storage-25gb", "DE", testAccOsImage, 2)
+
+	resource.Test(t, resource.TestCase{
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		CheckDestroy:             testAccCheckVmsDestroyed(srv),
+		Steps: []resource.TestStep{
+			{
+				Config:             testAccVmGroupResourceConfig(srv, publicKey, "acc-group", testAccOsImage, 2, 22),
+				ResourceName:       "fluence_vm_group.test",
+				ImportState:        true,
+				ImportStateId:      strings.Join(ids, ","),
+				ImportStatePersist: true,
+			},
+			// The create-only arguments are adopted in place, after which
+			// nothing is planned
+			{
+				Config: testAccVmGroupResourceConfig(srv, publicKey, "acc-group", testAccOsImage, 2, 22),
+				ConfigPlanChecks: resource.ConfigPlanChecks{
+					PreApply: []plancheck.PlanCheck{
+						plancheck.ExpectResourceAction("fluence_vm_group.test", plancheck.ResourceActionUpdate),
+					},
+				},
+				Check: resource.ComposeAggregateTestCheckFunc(
+					resource.TestCheckResourceAttr("fluence_vm_group.test", "members.0.id", ids[0]),
+					resource.TestCheckResourceAttr("fluence_vm_group.test", "members.1.id", ids[1]),
+					testAccCheckFakeVms(srv, 2, "acc-group", 22),
+				),
+			},
+			{
+				Config: testAccVmGroupResourceConfig(srv, publicKey, "acc-group", testAccOsImage, 2, 22),
+				ConfigPlanChecks: resource.ConfigPlanChecks{
+					PreApply: []plancheck.PlanCheck{
+						plancheck.ExpectEmptyPlan(),
+					},
+				},
+			},
+		},
+	})
+}
+
+func TestAccVmGroupResource_spendingLimits(t *testing.T) {
+	srv := testAccServer(t)
+	publicKey := testAccPublicKey(t, "")
+
+	resource.Test(t, resource.TestCase{
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		CheckDestroy:             testAccCheckVmsDestroyed(srv),
+		Steps: []resource.TestStep{
+			{
+				Config:      testAccProviderConfig(srv, "max_vm_count = 2") + testAccVmGroupConfig(publicKey, "acc-group", testAccOsImage, 3, 22),
+				PlanOnly:    true,
+				ExpectError: regexp.MustCompile(`Spending Limit Exceeded`),
+			},
+			{
+				Config:      testAccProviderConfig(srv, `max_total_price_per_epoch_usd = "2"`) + testAccVmGroupConfig(publicKey, "acc-group", testAccOsImage, 2, 22),
+				PlanOnly:    true,
+				ExpectError: regexp.MustCompile(`Spending Limit Exceeded`),
+			},
+			{
+				Config: testAccProviderConfig(srv, "max_vm_count = 2") + testAccVmGroupConfig(publicKey, "acc-group", testAccOsImage, 2, 22),
+				Check:  testAccCheckFakeVms(srv, 2, "acc-group", 22),
+			},
+			// Scaling up past the limit is refused before anything changes
+			{
+				Config:      testAccProviderConfig(srv, "max_vm_count = 2") + testAccVmGroupConfig(publicKey, "acc-group", testAccOsImage, 3, 22),
+				PlanOnly:    true,
+				ExpectError: regexp.MustCompile(`Spending Limit Exceeded`),
+			},
+			// Replacing the members in place does not add VMs
+			{
+				Config: testAccProviderConfig(srv, "max_vm_count = 2") + testAccVmGroupConfig(publicKey, "acc-group", testAccOtherOsImage, 2, 22),
+				Check: resource.ComposeAggregateTestCheckFunc(
+					testAccCheckFakeVms(srv, 2, "acc-group", 22),
+					testAccCheckFakeVmImages(srv, testAccOtherOsImage),
+				),
+			},
+		},
+	})
+}
+
+func testAccVmGroupResourceConfig(srv *fakeapi.Server, publicKey, name, osImage string, instances int, ports ...int) string {
+	return testAccProviderConfig(srv) + testAccVmGroupConfig(publicKey, name, osImage, instances, ports...)
+}
+
+// testAccVmGroupConfig declares an SSH key and a VM group using it, without
+// the provider block.
+func testAccVmGroupConfig(publicKey, name, osImage string, instances int, ports ...int) string {
+	openPorts := []string{}
+	for _, port := range ports {
+		openPorts = append(openPorts, fmt.Sprintf(`{ port = %d, protocol = "tcp" }`, port))
+	}
+
+	return fmt.Sprintf(`
+resource "fluence_ssh_key" "test" {
+  public_key = %q
+}
+
+resource "fluence_vm_group" "test" {
+  name            = %q
+  os_image        = %q
+  ssh_keys        = [fluence_ssh_key.test.fingerprint]
+  instances       = %d
+  max_unavailable = 2
+
+  open_ports = [%s]
+
+  basic_configuration  = "cpu-2-ram-4gb-storage-25gb"
+  datacenter_countries = ["DE"]
+}
+`, publicKey, name, osImage, instances, strings.Join(openPorts, ", "))
+}
+
+// testAccCheckFakeVmImages checks that every live VM of the fake runs osImage.
+func testAccCheckFakeVmImages(srv *fakeapi.Server, osImage string) resource.TestCheckFunc {
+	return func(_ *terraform.State) error {
+		for _, vm := range liveVms(srv.Vms()) {
+			if vm.OsImage == nil || *vm.OsImage != osImage {
+				return fmt.Errorf("VM %s runs %v, expected %q", vm.Id, vm.OsImage, osImage)
+			}
+		}
+		return nil
+	}
+}
@@ -0,0 +1,317 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	fluenceapi "github.com/decentralized-infrastructure/fluence-api-client-go"
+	"github.com/hashicorp/terraform-plugin-framework-validators/stringvalidator"
+	"github.com/hashicorp/terraform-plugin-framework/datasource"
+	"github.com/hashicorp/terraform-plugin-framework/datasource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/schema/validator"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+)
+
+// Ensure the implementation satisfies the expected interfaces.
+var (
+	_ datasource.DataSource = &vmDataSource{}
+)
+
+// NewVmDataSource is a helper function to simplify the provider implementation.
+func NewVmDataSource() datasource.DataSource {
+	return &vmDataSource{}
+}
+
+// vmDataSource looks up a single VM by id or name. Unlike fluence_vm, it has
+// no constraint attributes: the API only returns what the VM was allocated,
+// not the constraints it was created with.
+type vmDataSource struct {
+	client *fluenceapi.Client
+}
+
+// vmDataSourceModel maps the data source schema data.
+type vmDataSourceModel struct {
+	vmModel
+
+	Name       types.String            `tfsdk:"name"`
+	OpenPorts  []OpenPortModel         `tfsdk:"open_ports"`
+	Datacenter *vmDatacenterModel      `tfsdk:"datacenter"`
+	Resources  []vmResourceSupplyModel `tfsdk:"resources"`
+}
+
+// vmDatacenterModel maps the datacenter a VM was placed in.
+type vmDatacenterModel struct {
+	CountryCode    types.String   `tfsdk:"country_code"`
+	CityCode       types.String   `tfsdk:"city_code"`
+	CityIndex      types.Int64    `tfsdk:"city_index"`
+	Tier           types.Int64    `tfsdk:"tier"`
+	Certifications []types.String `tfsdk:"certifications"`
+}
+
+// newVmDatacenterModel maps a datacenter returned by the API.
+func newVmDatacenterModel(datacenter fluenceapi.Datacenter) *vmDatacenterModel {
+	model := &vmDatacenterModel{
+		CountryCode:    types.StringValue(datacenter.CountryCode),
+		CityCode:       types.StringValue(datacenter.CityCode),
+		CityIndex:      types.Int64Value(int64(datacenter.CityIndex)),
+		Tier:           types.Int64Value(int64(datacenter.Tier)),
+		Certifications: []types.String{},
+	}
+	for _, certification := range datacenter.Certifications {
+		model.Certifications = append(model.Certifications, types.StringValue(certification))
+	}
+	return model
+}
+
+// datacenterAttributes returns the schema of vmDatacenterModel.
+func datacenterAttributes() map[string]schema.Attribute {
+	return map[string]schema.Attribute{
+		"country_code": schema.StringAttribute{
+			MarkdownDescription: "Country code of the datacenter",
+			Computed:            true,
+		},
+		"city_code": schema.StringAttribute{
+			MarkdownDescription: "City code of the datacenter",
+			Computed:            true,
+		},
+		"city_index": schema.Int64Attribute{
+			MarkdownDescription: "City index of the datacenter",
+			Computed:            true,
+		},
+		"tier": schema.Int64Attribute{
+			MarkdownDescription: "Datacenter tier",
+			Computed:            true,
+		},
+		"certifications": schema.ListAttribute{
+			MarkdownDescription: "Datacenter certifications",
+			ElementType:         types.StringType,
+			Computed:            true,
+		},
+	}
+}
+
+// vmResourceSupplyModel maps a resource allocated to a VM.
+type vmResourceSupplyModel struct {
+	Type   types.String `tfsdk:"type"`
+	Supply types.Int64  `tfsdk:"supply"`
+	Units  types.String `tfsdk:"units"`
+}
+
+// Metadata returns the data source type name.
+func (d *vmDataSource) Metadata(_ context.Context, req datasource.MetadataRequest, resp *datasource.MetadataResponse) {
+	resp.TypeName = req.ProviderTypeName + "_vm"
+}
+
+// Schema defines the schema for the data source.
+func (d *vmDataSource) Schema(_ context.Context, _ datasource.SchemaRequest, resp *datasource.SchemaResponse) {
+	resp.Schema = schema.Schema{
+		MarkdownDescription: "Look up a single VM by `id` or `name`, for example to reference a VM managed by another configuration. Terminated VMs are ignored, and the lookup fails unless exactly one VM matches. The API does not return the constraints, `ssh_keys` and `hostname` the VM was created with, so they are not exposed. Use `datacenter` and `resources` to see where the VM was placed and what it was allocated",
+
+		Attributes: map[string]schema.Attribute{
+			"id": schema.StringAttribute{
+				MarkdownDescription: "VM identifier. Exactly one of `id` and `name` must be set",
+				Optional:            true,
+				Computed:            true,
+				Validators: []validator.String{
+					stringvalidator.ExactlyOneOf(path.MatchRoot("name")),
+				},
+			},
+			"name": schema.StringAttribute{
+				MarkdownDescription: "VM name. Exactly one of `id` and `name` must be set",
+				Optional:            true,
+				Computed:            true,
+			},
+			"status": schema.StringAttribute{
+				MarkdownDescription: "VM status",
+				Computed:            true,
+			},
+			"status_changed_at": schema.StringAttribute{
+				MarkdownDescription: "VM status change timestamp",
+				Computed:            true,
+			},
+			"price_per_epoch": schema.StringAttribute{
+				MarkdownDescription: "Price per epoch",
+				Computed:            true,
+			},
+			"created_at": schema.StringAttribute{
+				MarkdownDescription: "VM creation time",
+				Computed:            true,
+			},
+			"next_billing_at": schema.StringAttribute{
+				MarkdownDescription: "Next billing time",
+				Computed:            true,
+			},
+			"reserved_balance": schema.StringAttribute{
+				MarkdownDescription: "Reserved balance",
+				Computed:            true,
+			},
+			"total_spent": schema.StringAttribute{
+				MarkdownDescription: "Total amount spent",
+				Computed:            true,
+			},
+			"os_image": schema.StringAttribute{
+				MarkdownDescription: "Operating system image",
+				Computed:            true,
+			},
+			"public_ip": schema.StringAttribute{
+				MarkdownDescription: "Public IP address of the VM",
+				Computed:            true,
+			},
+			"vm_name": schema.StringAttribute{
+				MarkdownDescription: "VM name, as in the `fluence_vms` data source",
+				Computed:            true,
+			},
+			"open_ports": schema.ListNestedAttribute{
+				MarkdownDescription: "Ports open on the VM",
+				Computed:            true,
+				NestedObject: schema.NestedAttributeObject{
+					Attributes: map[string]schema.Attribute{
+						"port": schema.Int64Attribute{
+							MarkdownDescription: "Port number",
+							Computed:            true,
+						},
+						"protocol": schema.StringAttribute{
+							MarkdownDescription: "Protocol (tcp/udp)",
+							Computed:            true,
+						},
+					},
+				},
+			},
+			"datacenter": schema.SingleNestedAttribute{
+				MarkdownDescription: "Datacenter the VM was placed in",
+				Computed:            true,
+				Attributes:          datacenterAttributes(),
+			},
+			"resources": schema.ListNestedAttribute{
+				MarkdownDescription: "Resources allocated to the VM",
+				Computed:            true,
+				NestedObject: schema.NestedAttributeObject{
+					Attributes: map[string]schema.Attribute{
+						"type": schema.StringAttribute{
+							MarkdownDescription: "Resource type",
+							Computed:            true,
+						},
+						"supply": schema.Int64Attribute{
+							MarkdownDescription: "Amount of the resource allocated",
+							Computed:            true,
+						},
+						"units": schema.StringAttribute{
+							MarkdownDescription: "Units of `supply`",
+							Computed:            true,
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+// Configure adds the provider configured client to the data source.
+func (d *vmDataSource) Configure(_ context.Context, req datasource.ConfigureRequest, resp *datasource.ConfigureResponse) {
+	// Add a nil check when handling ProviderData because Terraform
+	// sets that data after it calls the ConfigureProvider RPC.
+	if req.ProviderData == nil {
+		return
+	}
+
+	client, ok := req.ProviderData.(*fluenceapi.Client)
+	if !ok {
+		resp.Diagnostics.AddError(
+			"Unexpected Data Source Configure Type",
+			fmt.Sprintf("Expected *fluenceapi.Client, got: %T. Please report this issue to the provider developers.", req.ProviderData),
+		)
+
+		return
+	}
+
+	d.client = client
+}
+
+// Read refreshes the Terraform state with the latest data.
+func (d *vmDataSource) Read(ctx context.Context, req datasource.ReadRequest, resp *datasource.ReadResponse) {
+	var config vmDataSourceModel
+
+	resp.Diagnostics.Append(req.Config.Get(ctx, &config)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	vms, err := withContext(ctx, d.client).ListVmsV3()
+	if err != nil {
+		resp.Diagnostics.AddError("Client Error", fmt.Sprintf("Unable to list VMs, got error: %s", err))
+		return
+	}
+
+	lookup := fmt.Sprintf("id %q", config.ID.ValueString())
+	if !config.Name.IsNull() {
+		lookup = fmt.Sprintf("name %q", config.Name.ValueString())
+	}
+
+	var matches []fluenceapi.RunningInstanceV3
+	for _, vm := range liveVms(vms) {
+		if !config.ID.IsNull() && vm.Id == config.ID.ValueString() {
+			matches = append(matches, vm)
+		}
+		if !config.Name.IsNull() && vm.VmName != nil && *vm.VmName == config.Name.ValueString() {
+			matches = append(matches, vm)
+		}
+	}
+
+	if len(matches) == 0 {
+		resp.Diagnostics.AddError(
+			"VM Not Found",
+			fmt.Sprintf("No VM with %s was found in the account.", lookup),
+		)
+		return
+	}
+	if len(matches) > 1 {
+		ids := []string{}
+		for _, vm := range matches {
+			ids = append(ids, vm.Id)
+		}
+		resp.Diagnostics.AddError(
+			"Multiple VMs Found",
+			fmt.Sprintf("%d VMs have the %s: %s. Look the VM up by id instead.", len(matches), lookup, strings.Join(ids, ", ")),
+		)
+		return
+	}
+
+	vm := matches[0]
+	tflog.Debug(ctx, "Found VM", map[string]interface{}{
+		"lookup": lookup,
+		"vm_id":  vm.Id,
+	})
+
+	state := vmDataSourceModel{
+		vmModel:   newVmModel(vm),
+		OpenPorts: []OpenPortModel{},
+		Resources: []vmResourceSupplyModel{},
+	}
+	state.Name = state.VmName
+
+	if vm.Ports != nil {
+		for _, port := range *vm.Ports {
+			state.OpenPorts = append(state.OpenPorts, OpenPortModel{
+				Port:     types.Int64Value(int64(port.Port)),
+				Protocol: types.StringValue(port.Protocol),
+			})
+		}
+	}
+
+	if vm.Datacenter != nil {
+		state.Datacenter = newVmDatacenterModel(*vm.Datacenter)
+	}
+
+	for _, resource := range vm.Resources {
+		state.Resources = append(state.Resources, vmResourceSupplyModel{
+			Type:   types.StringValue(resource.Type),
+			Supply: types.Int64Value(int64(resource.Supply.Supply)),
+			Units:  types.StringValue(resource.Units),
+		})
+	}
+
+	resp.Diagnostics.Append(resp.State.Set(ctx, &state)...)
+}
@@ -0,0 +1,75 @@
+package provider
+
+import (
+	"fmt"
+	"regexp"
+	"testing"
+
+	fluenceapi "github.com/decentralized-infrastructure/fluence-api-client-go"
+	"github.com/hashicorp/terraform-plugin-testing/helper/resource"
+
+	"terraform-provider-fluence/internal/fakeapi"
+)
+
+func TestAccVmDataSource(t *testing.T) {
+	srv := testAccServer(t)
+	ids := testAccCreateVms(t, srv, "web", "cpu-2-ram-4gb-storage-25gb", "DE", testAccOsImage, 1)
+	testAccCreateVms(t, srv, "pool", "cpu-2-ram-4gb-storage-25gb", "US", testAccOsImage, 2)
+	terminated := testAccCreateVms(t, srv, "old", "cpu-2-ram-4gb-storage-25gb", "US", testAccOsImage, 1)
+	srv.SetVmStatus(terminated[0], fluenceapi.VmStatusTerminated)
+
+	checks := resource.ComposeAggregateTestCheckFunc(
+		resource.TestCheckResourceAttr("data.fluence_vm.test", "id", ids[0]),
+		resource.TestCheckResourceAttr("data.fluence_vm.test", "name", "web"),
+		resource.TestCheckResourceAttr("data.fluence_vm.test", "vm_name", "web"),
+		resource.TestCheckResourceAttr("data.fluence_vm.test", "status", fluenceapi.VmStatusActive),
+		resource.TestCheckResourceAttrSet("data.fluence_vm.test", "public_ip"),
+		resource.TestCheckResourceAttr("data.fluence_vm.test", "os_image", testAccOsImage),
+		resource.TestCheckResourceAttr("data.fluence_vm.test", "price_per_epoch", "1.5"),
+		resource.TestCheckResourceAttr("data.fluence_vm.test", "open_ports.#", "1"),
+		resource.TestCheckResourceAttr("data.fluence_vm.test", "open_ports.0.port", "22"),
+		resource.TestCheckResourceAttr("data.fluence_vm.test", "open_ports.0.protocol", "tcp"),
+		resource.TestCheckResourceAttr("data.fluence_vm.test", "datacenter.country_code", "DE"),
+		resource.TestCheckResourceAttr("data.fluence_vm.test", "datacenter.city_code", "FRA"),
+		resource.TestCheckResourceAttr("data.fluence_vm.test", "datacenter.tier", "4"),
+	)
+
+	resource.Test(t, resource.TestCase{
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		Steps: []resource.TestStep{
+			{
+				Config: testAccVmDataSourceConfig(srv, fmt.Sprintf("id = %q", ids[0])),
+				Check:  checks,
+			},
+			{
+				Config:      testAccVmDataSourceConfig(srv, `name = "pool"`),
+				ExpectError: regexp.MustCompile(`Multiple VMs Found`),
+			},
+			{
+				Config:      testAccVmDataSourceConfig(srv, `name = "missing"`),
+				ExpectError: regexp.MustCompile(`VM Not Found`),
+			},
+			// Terminated VMs are ignored
+			{
+				Config:      testAccVmDataSourceConfig(srv, fmt.Sprintf("id = %q", terminated[0])),
+				ExpectError: regexp.MustCompile(`VM Not Found`),
+			},
+			{
+				Config:      testAccVmDataSourceConfig(srv, fmt.Sprintf("id = %q\nname = \"web\"", ids[0])),
+				ExpectError: regexp.MustCompile(`Invalid Attribute Combination`),
+			},
+			{
+				Config: testAccVmDataSourceConfig(srv, `name = "web"`),
+				Check:  checks,
+			},
+		},
+	})
+}
+
+func testAccVmDataSourceConfig(srv *fakeapi.Server, arguments string) string {
+	return testAccProviderConfig(srv) + fmt.Sprintf(`
+data "fluence_vm" "test" {
+%s
+}
+`, arguments)
+}
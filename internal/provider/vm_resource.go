@@ -2,31 +2,61 @@ package provider
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"strconv"
+	"strings"
 	"time"
 
 	fluenceapi "github.com/decentralized-infrastructure/fluence-api-client-go"
 	"github.com/hashicorp/terraform-plugin-framework-timeouts/resource/timeouts"
+	"github.com/hashicorp/terraform-plugin-framework-validators/int64validator"
+	"github.com/hashicorp/terraform-plugin-framework-validators/stringvalidator"
+	"github.com/hashicorp/terraform-plugin-framework/attr"
+	"github.com/hashicorp/terraform-plugin-framework/diag"
 	"github.com/hashicorp/terraform-plugin-framework/path"
 	"github.com/hashicorp/terraform-plugin-framework/resource"
 	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/int64default"
 	"github.com/hashicorp/terraform-plugin-framework/resource/schema/planmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/stringdefault"
 	"github.com/hashicorp/terraform-plugin-framework/resource/schema/stringplanmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/schema/validator"
 	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-go/tftypes"
 	"github.com/hashicorp/terraform-plugin-log/tflog"
 )
 
 // Ensure provider defined types fully satisfy framework interfaces.
 var _ resource.Resource = &VmResource{}
 var _ resource.ResourceWithImportState = &VmResource{}
+var _ resource.ResourceWithModifyPlan = &VmResource{}
+
+// Supported values of the scale_down_order attribute
+const (
+	scaleDownNewestFirst = "newest_first"
+	scaleDownOldestFirst = "oldest_first"
+)
 
 func NewVmResource() resource.Resource {
 	return &VmResource{}
 }
 
+// errVmNotFound is returned when none of the tracked VM instances exist
+// anymore. API failures are reported as different errors so that a transient
+// outage is never mistaken for a deleted VM.
+var errVmNotFound = errors.New("VM not found")
+
+// errVmPending is returned by a single status check when the VM has not yet
+// reached the awaited state.
+var errVmPending = errors.New("VM not yet in the expected state")
+
 // VmResource defines the resource implementation.
 type VmResource struct {
-	client *fluenceapi.Client
+	client  *fluenceapi.Client
+	cache   *apiCache
+	polling pollConfig
+	guard   *spendingGuard
 }
 
 // VmResourceModel describes the resource data model.
@@ -39,12 +69,16 @@ type VmResourceModel struct {
 	OpenPorts []OpenPortModel `tfsdk:"open_ports"`
 	Instances types.Int64     `tfsdk:"instances"`
 
+	// Order in which instances are removed when scaling down
+	ScaleDownOrder types.String `tfsdk:"scale_down_order"`
+
+	// Budget guard and the plan-time estimate it is checked against
+	MaxDepositUsdc         types.String `tfsdk:"max_deposit_usdc"`
+	EstimatedDepositUsdc   types.String `tfsdk:"estimated_deposit_usdc"`
+	EstimatedPricePerEpoch types.String `tfsdk:"estimated_price_per_epoch"`
+
 	// Constraints (optional)
-	BasicConfiguration       types.String              `tfsdk:"basic_configuration"`
-	MaxTotalPricePerEpochUsd types.String              `tfsdk:"max_total_price_per_epoch_usd"`
-	Countries                []types.String            `tfsdk:"datacenter_countries"`
-	HardwareConstraints      []HardwareConstraintModel `tfsdk:"hardware_constraints"`
-	AdditionalResources      []AdditionalResourceModel `tfsdk:"additional_resources"`
+	ConstraintsModel
 
 	// Computed fields
 	Status          types.String `tfsdk:"status"`
@@ -56,6 +90,12 @@ type VmResourceModel struct {
 	TotalSpent      types.String `tfsdk:"total_spent"`
 	PublicIp        types.String `tfsdk:"public_ip"`
 
+	// Per-instance computed fields, aligned by index with VmIds
+	VmIds          types.List `tfsdk:"vm_ids"`
+	PublicIps      types.List `tfsdk:"public_ips"`
+	Statuses       types.List `tfsdk:"statuses"`
+	PricesPerEpoch types.List `tfsdk:"prices_per_epoch"`
+
 	// Timeouts
 	Timeouts timeouts.Value `tfsdk:"timeouts"`
 }
@@ -66,238 +106,207 @@ type OpenPortModel struct {
 	Protocol types.String `tfsdk:"protocol"`
 }
 
-// HardwareConstraintModel represents hardware constraints
-type HardwareConstraintModel struct {
-	Cpu     []CpuHardwareModel     `tfsdk:"cpu"`
-	Memory  []MemoryHardwareModel  `tfsdk:"memory"`
-	Storage []StorageHardwareModel `tfsdk:"storage"`
-}
-
-// AdditionalResourceModel represents additional resources
-type AdditionalResourceModel struct {
-	Storage []AdditionalStorageModel `tfsdk:"storage"`
-}
-
-// AdditionalStorageModel represents additional storage resources
-type AdditionalStorageModel struct {
-	Supply types.Int64  `tfsdk:"supply"`
-	Units  types.String `tfsdk:"units"`
-	Type   types.String `tfsdk:"type"`
-}
-
 func (r *VmResource) Metadata(ctx context.Context, req resource.MetadataRequest, resp *resource.MetadataResponse) {
 	resp.TypeName = req.ProviderTypeName + "_vm"
 }
 
 func (r *VmResource) Schema(ctx context.Context, req resource.SchemaRequest, resp *resource.SchemaResponse) {
-	resp.Schema = schema.Schema{
-		MarkdownDescription: "Virtual Machine resource",
-
-		Attributes: map[string]schema.Attribute{
-			"id": schema.StringAttribute{
-				Computed:            true,
-				MarkdownDescription: "VM identifier",
-				PlanModifiers: []planmodifier.String{
-					stringplanmodifier.UseStateForUnknown(),
-				},
-			},
-			"name": schema.StringAttribute{
-				MarkdownDescription: "VM name",
-				Required:            true,
-			},
-			"hostname": schema.StringAttribute{
-				MarkdownDescription: "VM hostname (optional)",
-				Optional:            true,
-			},
-			"os_image": schema.StringAttribute{
-				MarkdownDescription: "Operating system image to use",
-				Required:            true,
-				PlanModifiers: []planmodifier.String{
-					stringplanmodifier.RequiresReplace(),
-				},
-			},
-			"ssh_keys": schema.ListAttribute{
-				MarkdownDescription: "List of SSH key fingerprints to authorize",
-				ElementType:         types.StringType,
-				Required:            true,
-			},
-			"open_ports": schema.ListNestedAttribute{
-				MarkdownDescription: "List of ports to open on the VM",
-				Optional:            true,
-				NestedObject: schema.NestedAttributeObject{
-					Attributes: map[string]schema.Attribute{
-						"port": schema.Int64Attribute{
-							MarkdownDescription: "Port number",
-							Required:            true,
-						},
-						"protocol": schema.StringAttribute{
-							MarkdownDescription: "Protocol (tcp/udp)",
-							Required:            true,
-						},
-					},
-				},
+	attributes := map[string]schema.Attribute{
+		"id": schema.StringAttribute{
+			Computed:            true,
+			MarkdownDescription: "VM identifier",
+			PlanModifiers: []planmodifier.String{
+				stringplanmodifier.UseStateForUnknown(),
 			},
-			"instances": schema.Int64Attribute{
-				MarkdownDescription: "Number of VM instances to create",
-				Optional:            true,
-				Computed:            true,
+		},
+		"name": schema.StringAttribute{
+			MarkdownDescription: "VM name",
+			Required:            true,
+		},
+		"hostname": schema.StringAttribute{
+			MarkdownDescription: "VM hostname (optional). Changing this forces a new resource to be created",
+			Optional:            true,
+			PlanModifiers: []planmodifier.String{
+				stringRequiresReplaceUnlessImported(),
 			},
-
-			// Constraint attributes
-			"basic_configuration": schema.StringAttribute{
-				MarkdownDescription: "Basic configuration constraint",
-				Optional:            true,
+		},
+		"os_image": schema.StringAttribute{
+			MarkdownDescription: "Operating system image to use. Changing this forces a new resource to be created",
+			Required:            true,
+			PlanModifiers: []planmodifier.String{
+				stringplanmodifier.RequiresReplace(),
 			},
-			"max_total_price_per_epoch_usd": schema.StringAttribute{
-				MarkdownDescription: "Maximum total price per epoch in USD",
-				Optional:            true,
+		},
+		"ssh_keys": schema.ListAttribute{
+			MarkdownDescription: "List of SSH key fingerprints to authorize. Changing this forces a new resource to be created",
+			ElementType:         types.StringType,
+			Required:            true,
+			PlanModifiers: []planmodifier.List{
+				listRequiresReplaceUnlessImported(),
 			},
-			"datacenter_countries": schema.ListAttribute{
-				MarkdownDescription: "List of allowed datacenter countries",
-				ElementType:         types.StringType,
-				Optional:            true,
+		},
+		"open_ports": openPortsAttribute(),
+		"instances": schema.Int64Attribute{
+			MarkdownDescription: "Number of VM instances to create. Changing this value scales the resource in place: " +
+				"scale-up creates only the missing instances and scale-down removes instances according to `scale_down_order`",
+			Optional: true,
+			Computed: true,
+			Default:  int64default.StaticInt64(1),
+			Validators: []validator.Int64{
+				int64validator.AtLeast(1),
 			},
-			"hardware_constraints": schema.ListNestedAttribute{
-				MarkdownDescription: "Hardware constraints for VM placement",
-				Optional:            true,
-				NestedObject: schema.NestedAttributeObject{
-					Attributes: map[string]schema.Attribute{
-						"cpu": schema.ListNestedAttribute{
-							MarkdownDescription: "CPU hardware constraints",
-							Optional:            true,
-							NestedObject: schema.NestedAttributeObject{
-								Attributes: map[string]schema.Attribute{
-									"architecture": schema.StringAttribute{
-										MarkdownDescription: "CPU architecture (e.g., x86_64, arm64)",
-										Required:            true,
-									},
-									"manufacturer": schema.StringAttribute{
-										MarkdownDescription: "CPU manufacturer (e.g., Intel, AMD)",
-										Required:            true,
-									},
-								},
-							},
-						},
-						"memory": schema.ListNestedAttribute{
-							MarkdownDescription: "Memory hardware constraints",
-							Optional:            true,
-							NestedObject: schema.NestedAttributeObject{
-								Attributes: map[string]schema.Attribute{
-									"type": schema.StringAttribute{
-										MarkdownDescription: "Memory type (e.g., DDR4, DDR5)",
-										Required:            true,
-									},
-									"generation": schema.StringAttribute{
-										MarkdownDescription: "Memory generation",
-										Required:            true,
-									},
-								},
-							},
-						},
-						"storage": schema.ListNestedAttribute{
-							MarkdownDescription: "Storage hardware constraints",
-							Optional:            true,
-							NestedObject: schema.NestedAttributeObject{
-								Attributes: map[string]schema.Attribute{
-									"type": schema.StringAttribute{
-										MarkdownDescription: "Storage type (HDD, SSD, NVMe)",
-										Required:            true,
-									},
-								},
-							},
-						},
-					},
-				},
+		},
+		"scale_down_order": schema.StringAttribute{
+			MarkdownDescription: "Which instances are removed first when `instances` is decreased: `newest_first` (default) or `oldest_first`. " +
+				"The primary instance (`id`) is never removed by a scale-down",
+			Optional: true,
+			Computed: true,
+			Default:  stringdefault.StaticString(scaleDownNewestFirst),
+			Validators: []validator.String{
+				stringvalidator.OneOf(scaleDownNewestFirst, scaleDownOldestFirst),
 			},
-			"additional_resources": schema.ListNestedAttribute{
-				MarkdownDescription: "Additional resources to be allocated",
-				Optional:            true,
-				NestedObject: schema.NestedAttributeObject{
-					Attributes: map[string]schema.Attribute{
-						"storage": schema.ListNestedAttribute{
-							MarkdownDescription: "Additional storage resources",
-							Optional:            true,
-							NestedObject: schema.NestedAttributeObject{
-								Attributes: map[string]schema.Attribute{
-									"supply": schema.Int64Attribute{
-										MarkdownDescription: "Amount of storage to allocate",
-										Required:            true,
-									},
-									"units": schema.StringAttribute{
-										MarkdownDescription: "Storage units (GB, TB)",
-										Required:            true,
-									},
-									"type": schema.StringAttribute{
-										MarkdownDescription: "Storage type (HDD, SSD, NVMe)",
-										Required:            true,
-									},
-								},
-							},
-						},
-					},
-				},
+		},
+		"max_deposit_usdc": schema.StringAttribute{
+			MarkdownDescription: "Maximum deposit in USDC. The plan fails when `estimated_deposit_usdc` exceeds this amount",
+			Optional:            true,
+			Validators: []validator.String{
+				stringvalidator.RegexMatches(decimalPattern, "must be a decimal amount such as \"25\" or \"12.5\""),
 			},
+		},
 
-			// Computed attributes
-			"status": schema.StringAttribute{
-				Computed:            true,
-				MarkdownDescription: "VM status",
-			},
-			"status_changed_at": schema.StringAttribute{
-				Computed:            true,
-				MarkdownDescription: "VM status change timestamp",
-			},
-			"price_per_epoch": schema.StringAttribute{
-				Computed:            true,
-				MarkdownDescription: "Price per epoch",
-			},
-			"created_at": schema.StringAttribute{
-				Computed:            true,
-				MarkdownDescription: "VM creation time",
-			},
-			"next_billing_at": schema.StringAttribute{
-				Computed:            true,
-				MarkdownDescription: "Next billing time",
-			},
-			"reserved_balance": schema.StringAttribute{
-				Computed:            true,
-				MarkdownDescription: "Reserved balance",
-			},
-			"total_spent": schema.StringAttribute{
-				Computed:            true,
-				MarkdownDescription: "Total amount spent",
-			},
-			"public_ip": schema.StringAttribute{
-				Computed:            true,
-				MarkdownDescription: "Public IP address of the VM",
-			},
+		// Computed attributes
+		"estimated_deposit_usdc": schema.StringAttribute{
+			Computed: true,
+			MarkdownDescription: "Deposit in USDC estimated by the API for `instances` VMs matching the constraints. " +
+				"Estimated at plan time when the resource is created or when `instances` or a constraint changes",
+		},
+		"estimated_price_per_epoch": schema.StringAttribute{
+			Computed:            true,
+			MarkdownDescription: "Total price per epoch in USD estimated together with `estimated_deposit_usdc`",
+		},
+		"status": schema.StringAttribute{
+			Computed:            true,
+			MarkdownDescription: "VM status",
+		},
+		"status_changed_at": schema.StringAttribute{
+			Computed:            true,
+			MarkdownDescription: "VM status change timestamp",
+		},
+		"price_per_epoch": schema.StringAttribute{
+			Computed:            true,
+			MarkdownDescription: "Price per epoch",
+		},
+		"created_at": schema.StringAttribute{
+			Computed:            true,
+			MarkdownDescription: "VM creation time",
+		},
+		"next_billing_at": schema.StringAttribute{
+			Computed:            true,
+			MarkdownDescription: "Next billing time",
+		},
+		"reserved_balance": schema.StringAttribute{
+			Computed:            true,
+			MarkdownDescription: "Reserved balance",
+		},
+		"total_spent": schema.StringAttribute{
+			Computed:            true,
+			MarkdownDescription: "Total amount spent",
+		},
+		"public_ip": schema.StringAttribute{
+			Computed:            true,
+			MarkdownDescription: "Public IP address of the VM",
+		},
+		"vm_ids": schema.ListAttribute{
+			Computed:            true,
+			ElementType:         types.StringType,
+			MarkdownDescription: "Identifiers of every VM instance managed by this resource. The first element is the same as `id`",
+		},
+		"public_ips": schema.ListAttribute{
+			Computed:            true,
+			ElementType:         types.StringType,
+			MarkdownDescription: "Public IP address of each VM instance, in the same order as `vm_ids`",
 		},
+		"statuses": schema.ListAttribute{
+			Computed:            true,
+			ElementType:         types.StringType,
+			MarkdownDescription: "Status of each VM instance, in the same order as `vm_ids`",
+		},
+		"prices_per_epoch": schema.ListAttribute{
+			Computed:            true,
+			ElementType:         types.StringType,
+			MarkdownDescription: "Price per epoch of each VM instance, in the same order as `vm_ids`",
+		},
+	}
+	for name, attribute := range vmConstraintAttributes() {
+		attributes[name] = attribute
+	}
+
+	resp.Schema = schema.Schema{
+		MarkdownDescription: "Virtual Machine resource. `name`, `open_ports`, `instances`, `scale_down_order`, `max_deposit_usdc` and " +
+			"`max_total_price_per_epoch_usd` are updated in place. Changing any other argument replaces every VM instance",
+
+		Attributes: attributes,
 		Blocks: map[string]schema.Block{
 			"timeouts": timeouts.Block(ctx, timeouts.Opts{
 				Create: true,
+				Update: true,
+				Delete: true,
 			}),
 		},
 	}
 }
 
+// openPortsAttribute returns the schema of the open_ports attribute shared by
+// the VM resources.
+func openPortsAttribute() schema.ListNestedAttribute {
+	return schema.ListNestedAttribute{
+		MarkdownDescription: "List of ports to open on the VM. Each port and protocol pair may only be listed once. An empty or omitted list closes every port",
+		Optional:            true,
+		Validators: []validator.List{
+			uniqueOpenPortsValidator{},
+		},
+		NestedObject: schema.NestedAttributeObject{
+			Attributes: map[string]schema.Attribute{
+				"port": schema.Int64Attribute{
+					MarkdownDescription: "Port number (1-65535)",
+					Required:            true,
+					Validators: []validator.Int64{
+						int64validator.Between(1, 65535),
+					},
+				},
+				"protocol": schema.StringAttribute{
+					MarkdownDescription: "Protocol (tcp/udp)",
+					Required:            true,
+					Validators: []validator.String{
+						stringvalidator.OneOf(fluenceapi.ProtocolTCP, fluenceapi.ProtocolUDP),
+					},
+				},
+			},
+		},
+	}
+}
+
 func (r *VmResource) Configure(ctx context.Context, req resource.ConfigureRequest, resp *resource.ConfigureResponse) {
 	// Prevent panic if the provider has not been configured.
 	if req.ProviderData == nil {
 		return
 	}
 
-	client, ok := req.ProviderData.(*fluenceapi.Client)
+	providerData, ok := req.ProviderData.(*fluenceProviderData)
 
 	if !ok {
 		resp.Diagnostics.AddError(
 			"Unexpected Resource Configure Type",
-			fmt.Sprintf("Expected *fluenceapi.Client, got: %T. Please report this issue to the provider developers.", req.ProviderData),
+			fmt.Sprintf("Expected *fluenceProviderData, got: %T. Please report this issue to the provider developers.", req.ProviderData),
 		)
 
 		return
 	}
 
-	r.client = client
+	r.client = providerData.client
+	r.cache = providerData.cache
+	r.polling = providerData.polling
+	r.guard = providerData.guard
 }
 
 func (r *VmResource) Create(ctx context.Context, req resource.CreateRequest, resp *resource.CreateResponse) {
@@ -310,106 +319,10 @@ func (r *VmResource) Create(ctx context.Context, req resource.CreateRequest, res
 		return
 	}
 
-	// Build the VM configuration
-	vmConfig := fluenceapi.VmConfiguration{
-		Name:      data.Name.ValueString(),
-		OsImage:   data.OsImage.ValueString(),
-		OpenPorts: []fluenceapi.OpenPorts{},
-		SshKeys:   []string{},
-	}
-
-	// Set hostname if provided
-	if !data.Hostname.IsNull() && !data.Hostname.IsUnknown() {
-		hostname := data.Hostname.ValueString()
-		vmConfig.Hostname = &hostname
-	}
-
-	// Convert SSH keys
-	for _, sshKey := range data.SshKeys {
-		vmConfig.SshKeys = append(vmConfig.SshKeys, sshKey.ValueString())
-	}
-
-	// Convert open ports
-	for _, port := range data.OpenPorts {
-		vmConfig.OpenPorts = append(vmConfig.OpenPorts, fluenceapi.OpenPorts{
-			Port:     uint16(port.Port.ValueInt64()),
-			Protocol: port.Protocol.ValueString(),
-		})
-	}
-
-	// Build constraints
-	var constraints *fluenceapi.OfferConstraints
-	if !data.BasicConfiguration.IsNull() || !data.MaxTotalPricePerEpochUsd.IsNull() || len(data.Countries) > 0 || len(data.HardwareConstraints) > 0 || len(data.AdditionalResources) > 0 {
-		constraints = &fluenceapi.OfferConstraints{}
-
-		if !data.BasicConfiguration.IsNull() {
-			basicConfig := data.BasicConfiguration.ValueString()
-			constraints.BasicConfiguration = &basicConfig
-		}
-
-		if !data.MaxTotalPricePerEpochUsd.IsNull() {
-			maxPrice := data.MaxTotalPricePerEpochUsd.ValueString()
-			constraints.MaxTotalPricePerEpochUsd = &maxPrice
-		}
-
-		if len(data.Countries) > 0 {
-			countries := []string{}
-			for _, country := range data.Countries {
-				countries = append(countries, country.ValueString())
-			}
-			constraints.Datacenter = &fluenceapi.DatacenterConstraint{
-				Countries: countries,
-			}
-		}
-
-		// Build hardware constraints
-		if len(data.HardwareConstraints) > 0 {
-			hwConstraint := &fluenceapi.HardwareConstraints{}
-
-			for _, hwc := range data.HardwareConstraints {
-				// Convert CPU constraints
-				for _, cpu := range hwc.Cpu {
-					hwConstraint.Cpu = append(hwConstraint.Cpu, fluenceapi.CpuHardware{
-						Architecture: cpu.Architecture.ValueString(),
-						Manufacturer: cpu.Manufacturer.ValueString(),
-					})
-				}
-
-				// Convert memory constraints
-				for _, mem := range hwc.Memory {
-					hwConstraint.Memory = append(hwConstraint.Memory, fluenceapi.MemoryHardware{
-						Type:       mem.Type.ValueString(),
-						Generation: mem.Generation.ValueString(),
-					})
-				}
-
-				// Convert storage constraints
-				for _, storage := range hwc.Storage {
-					hwConstraint.Storage = append(hwConstraint.Storage, fluenceapi.StorageHardware{
-						Type: storage.Type.ValueString(),
-					})
-				}
-			}
-
-			constraints.Hardware = hwConstraint
-		}
-
-		// Build additional resources
-		if len(data.AdditionalResources) > 0 {
-			addlResources := &fluenceapi.AdditionalResources{}
-
-			for _, ar := range data.AdditionalResources {
-				for _, storage := range ar.Storage {
-					addlResources.Storage = append(addlResources.Storage, fluenceapi.AdditionalStorage{
-						Supply: uint64(storage.Supply.ValueInt64()),
-						Units:  storage.Units.ValueString(),
-						Type:   storage.Type.ValueString(),
-					})
-				}
-			}
-
-			constraints.AdditionalResources = addlResources
-		}
+	// Enforce the deposit limit before anything is created
+	resp.Diagnostics.Append(r.ensureDepositEstimate(ctx, &data)...)
+	if resp.Diagnostics.HasError() {
+		return
 	}
 
 	// Set instances (default to 1 if not specified)
@@ -419,31 +332,47 @@ func (r *VmResource) Create(ctx context.Context, req resource.CreateRequest, res
 	}
 
 	// Create VM using the client
-	createReq := fluenceapi.CreateVmV3{
-		Constraints:     constraints,
-		Instances:       instances,
-		VmConfiguration: vmConfig,
+	createdVms, err := r.guard.createVms(ctx, data.createVmRequest(instances))
+	r.cache.InvalidateVms()
+	if errors.Is(err, errSpendingLimitExceeded) {
+		resp.Diagnostics.AddError("Spending Limit Exceeded", fmt.Sprintf("Unable to create VM: %s", err))
+		return
 	}
-
-	createdVms, err := r.client.CreateVmV3(createReq)
 	if err != nil {
 		resp.Diagnostics.AddError("Client Error", fmt.Sprintf("Unable to create VM, got error: %s", err))
 		return
 	}
 
-	if len(createdVms) == 0 {
-		resp.Diagnostics.AddError("Client Error", "No VMs were created")
-		return
+	// Track every created VM. The first one is used as the resource ID
+	vmIds := make([]string, len(createdVms))
+	for i, createdVm := range createdVms {
+		vmIds[i] = createdVm.VmId
 	}
 
-	// For now, we'll handle single VM instances. Use the first created VM as the resource ID
-	createdVm := createdVms[0]
-	data.ID = types.StringValue(createdVm.VmId)
-	data.Instances = types.Int64Value(int64(instances))
+	if len(createdVms) != instances {
+		resp.Diagnostics.AddError("Client Error", fmt.Sprintf("Expected %d VMs to be created, got %d", instances, len(createdVms)))
+		if len(vmIds) == 0 {
+			return
+		}
+
+		// Remove the partial set rather than leaving it unmanaged. If that
+		// fails too, save it so Terraform taints the resource and removes
+		// it on the next apply or destroy.
+		_, err := r.guard.removeVms(ctx, vmIds)
+		r.cache.InvalidateVms()
+		if err != nil {
+			data.setVmIds(vmIds)
+			data.Instances = types.Int64Value(int64(len(vmIds)))
+			resp.Diagnostics.Append(resp.State.Set(ctx, &data)...)
+			resp.Diagnostics.AddError("Client Error", fmt.Sprintf("Unable to remove the partially created VMs, got error: %s", err))
+		}
+		return
+	}
+	data.setVmIds(vmIds)
 
 	// Set the VM name from the response if available
-	if createdVm.VmName != "" {
-		data.Name = types.StringValue(createdVm.VmName)
+	if createdVms[0].VmName != "" {
+		data.Name = types.StringValue(createdVms[0].VmName)
 	}
 
 	// Wait for VM to become active before considering creation complete
@@ -453,14 +382,20 @@ func (r *VmResource) Create(ctx context.Context, req resource.CreateRequest, res
 		return
 	}
 
-	err = r.waitForVmActive(ctx, &data, createTimeout)
+	vms, err := r.waiter().waitForVmActive(ctx, data.vmIds(), createTimeout)
+	data.applyVms(vms)
 	if err != nil {
+		// Save the created VMs anyway so Terraform marks the resource as
+		// tainted and releases them on the next apply or destroy
+		resp.Diagnostics.Append(resp.State.Set(ctx, &data)...)
 		resp.Diagnostics.AddError("VM Creation Error", fmt.Sprintf("VM was created but failed to become active: %s", err))
 		return
 	}
 
 	// Write logs using the tflog package
-	tflog.Trace(ctx, "created VM resource")
+	tflog.Trace(ctx, "created VM resource", map[string]interface{}{
+		"vm_ids": vmIds,
+	})
 
 	// Save data into Terraform state
 	resp.Diagnostics.Append(resp.State.Set(ctx, &data)...)
@@ -476,11 +411,15 @@ func (r *VmResource) Read(ctx context.Context, req resource.ReadRequest, resp *r
 		return
 	}
 
-	// Refresh the VM data
-	err := r.refreshVmData(ctx, &data)
+	// Refresh the VM data. A single attempt is enough here: VMs that were
+	// deleted outside of Terraform should be detected right away
+	err := r.refreshVmData(ctx, &data, 1, true)
 	if err != nil {
-		if err.Error() == "VM not found" {
-			// VM not found, remove from state
+		if errors.Is(err, errVmNotFound) {
+			// VM not found, remove from state so it is planned for recreation
+			tflog.Warn(ctx, "VM not found, removing from state", map[string]interface{}{
+				"vm_ids": data.vmIds(),
+			})
 			resp.State.RemoveResource(ctx)
 			return
 		}
@@ -492,145 +431,233 @@ func (r *VmResource) Read(ctx context.Context, req resource.ReadRequest, resp *r
 	resp.Diagnostics.Append(resp.State.Set(ctx, &data)...)
 }
 
-// refreshVmData fetches the current VM data and updates the model
-func (r *VmResource) refreshVmData(ctx context.Context, data *VmResourceModel) error {
-	vmId := data.ID.ValueString()
+// refreshVmData fetches the current data of every tracked VM and updates the model.
+// Instances that no longer exist or were terminated are dropped from the model.
+// Newly created VMs might not be listed immediately, so the lookup is tried
+// up to maxAttempts times while some instances are missing. errVmNotFound is
+// returned when none of the instances exist. When refreshPorts is set, the
+// open ports of the primary instance replace the configured ones.
+func (r *VmResource) refreshVmData(ctx context.Context, data *VmResourceModel, maxAttempts int, refreshPorts bool) error {
+	vmIds := data.vmIds()
 	tflog.Debug(ctx, "Attempting to refresh VM data", map[string]interface{}{
-		"vm_id": vmId,
+		"vm_ids": vmIds,
 	})
 
-	// Retry logic for newly created VMs that might not be immediately available
-	maxRetries := 5
-	retryDelay := time.Second * 2
-
-	for attempt := 0; attempt < maxRetries; attempt++ {
-		if attempt > 0 {
-			tflog.Debug(ctx, "Retrying VM data refresh", map[string]interface{}{
-				"attempt": attempt + 1,
-				"vm_id":   vmId,
-			})
-			time.Sleep(retryDelay)
-		}
+	p := r.polling.newPoller()
 
-		// Get all VMs and find the one matching our ID
-		vms, err := r.client.ListVmsV3()
+	var foundVms []fluenceapi.RunningInstanceV3
+	for {
+		// Get all VMs and find the ones matching our IDs. The listing is
+		// shared with the other resources refreshed during this run.
+		vms, err := r.cache.ListVms(ctx)
 		if err != nil {
-			return fmt.Errorf("unable to read VMs: %s", err)
+			return fmt.Errorf("unable to read VMs: %w", err)
 		}
 
 		tflog.Debug(ctx, "Retrieved VMs from API", map[string]interface{}{
 			"vm_count": len(vms),
-			"vm_id":    vmId,
+			"vm_ids":   vmIds,
 		})
 
-		// Find the VM by ID
-		var foundVm *fluenceapi.RunningInstanceV3
-		for _, vm := range vms {
-			tflog.Trace(ctx, "Checking VM", map[string]interface{}{
-				"api_vm_id":    vm.Id,
-				"target_vm_id": vmId,
-				"match":        vm.Id == vmId,
-			})
-			if vm.Id == vmId {
-				foundVm = &vm
-				break
-			}
+		foundVms = liveVms(findVms(vms, vmIds))
+		if len(foundVms) == len(vmIds) || p.Attempt() >= maxAttempts {
+			break
 		}
 
-		if foundVm != nil {
-			tflog.Debug(ctx, "Found VM in API", map[string]interface{}{
-				"vm_id":   vmId,
-				"status":  foundVm.Status,
-				"attempt": attempt + 1,
-			})
-
-			// Update the model with the current data
-			data.Status = types.StringValue(foundVm.Status)
-			data.StatusChangedAt = types.StringValue(foundVm.StatusChangedAt)
-			data.PricePerEpoch = types.StringValue(foundVm.PricePerEpoch)
-			data.CreatedAt = types.StringValue(foundVm.CreatedAt)
-			data.NextBillingAt = types.StringValue(foundVm.NextBillingAt)
-			data.ReservedBalance = types.StringValue(foundVm.ReservedBalance)
-			data.TotalSpent = types.StringValue(foundVm.TotalSpent)
-
-			if foundVm.OsImage != nil {
-				data.OsImage = types.StringValue(*foundVm.OsImage)
-			}
+		tflog.Debug(ctx, "Not all VMs found in API response, retrying", map[string]interface{}{
+			"vm_ids":  vmIds,
+			"found":   len(foundVms),
+			"attempt": p.Attempt(),
+		})
 
-			if foundVm.PublicIp != nil {
-				data.PublicIp = types.StringValue(*foundVm.PublicIp)
-			} else {
-				data.PublicIp = types.StringNull()
-			}
+		if err := p.Wait(ctx); err != nil {
+			return err
+		}
 
-			if foundVm.VmName != nil {
-				data.Name = types.StringValue(*foundVm.VmName)
-			}
+		// The shared listing predates our VMs, fetch a fresh one
+		r.cache.InvalidateVms()
+	}
 
-			return nil
-		}
+	if len(foundVms) == 0 {
+		return fmt.Errorf("%w after %d attempt(s)", errVmNotFound, p.Attempt())
+	}
 
-		tflog.Debug(ctx, "VM not found in API response", map[string]interface{}{
-			"vm_id":   vmId,
-			"attempt": attempt + 1,
+	if len(foundVms) < len(vmIds) {
+		tflog.Warn(ctx, "Some VM instances no longer exist", map[string]interface{}{
+			"vm_ids": vmIds,
+			"found":  len(foundVms),
 		})
 	}
 
-	return fmt.Errorf("VM not found after %d attempts", maxRetries)
+	// Update the model with the current data
+	data.applyVms(foundVms)
+	data.Instances = types.Int64Value(int64(len(foundVms)))
+
+	if refreshPorts {
+		data.refreshOpenPorts(foundVms[0])
+	}
+
+	return nil
 }
 
 func (r *VmResource) Update(ctx context.Context, req resource.UpdateRequest, resp *resource.UpdateResponse) {
-	var data VmResourceModel
+	var data, state VmResourceModel
 
-	// Read Terraform plan data into the model
+	// Read Terraform plan and prior state data into the models
 	resp.Diagnostics.Append(req.Plan.Get(ctx, &data)...)
+	resp.Diagnostics.Append(req.State.Get(ctx, &state)...)
 
 	if resp.Diagnostics.HasError() {
 		return
 	}
 
-	// Build update request - only name and open ports can be updated
-	updates := []fluenceapi.UpdateVm{
-		{
-			Id: data.ID.ValueString(),
-		},
+	// The set of instances is only known from the prior state
+	data.setVmIds(state.vmIds())
+
+	// Enforce the deposit limit before scaling up
+	resp.Diagnostics.Append(r.ensureDepositEstimate(ctx, &data)...)
+	if resp.Diagnostics.HasError() {
+		return
 	}
 
-	// Update VM name if changed
+	// Scaling and the open port changes share one update timeout
+	updateTimeout, diags := data.Timeouts.Update(ctx, 10*time.Minute)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	deadline := time.Now().Add(updateTimeout)
+
+	// Build update request - only name and open ports can be updated.
+	// Scaling refreshes the model from the API, so the planned name is
+	// taken beforehand.
+	var vmName *string
 	if !data.Name.IsNull() {
-		vmName := data.Name.ValueString()
-		updates[0].VmName = &vmName
+		name := data.Name.ValueString()
+		vmName = &name
 	}
 
-	// Update open ports if changed
-	if len(data.OpenPorts) > 0 {
-		openPorts := []fluenceapi.OpenPorts{}
-		for _, port := range data.OpenPorts {
-			openPorts = append(openPorts, fluenceapi.OpenPorts{
-				Port:     uint16(port.Port.ValueInt64()),
-				Protocol: port.Protocol.ValueString(),
-			})
-		}
-		updates[0].OpenPorts = &openPorts
+	// Scale the number of instances if it changed
+	resp.Diagnostics.Append(r.scaleInstances(ctx, &data, deadline, resp)...)
+	if resp.Diagnostics.HasError() {
+		return
 	}
 
-	err := r.client.UpdateVms(updates)
+	// Open ports are always sent, so an empty or removed list closes every port
+	openPorts := data.apiOpenPorts()
+
+	// Apply the same changes to every instance
+	updates := []fluenceapi.UpdateVm{}
+	for _, vmId := range data.vmIds() {
+		updates = append(updates, fluenceapi.UpdateVm{
+			Id:        vmId,
+			VmName:    vmName,
+			OpenPorts: &openPorts,
+		})
+	}
+
+	err := withContext(ctx, r.client).UpdateVms(updates)
+	r.cache.InvalidateVms()
 	if err != nil {
 		resp.Diagnostics.AddError("Client Error", fmt.Sprintf("Unable to update VM, got error: %s", err))
 		return
 	}
 
-	// Refresh the data after update
-	err = r.refreshVmData(ctx, &data)
+	// Wait for the open port changes to be applied to every instance
+	err = r.waiter().waitForVmPorts(ctx, data.vmIds(), openPorts, time.Until(deadline))
+	if err != nil {
+		resp.Diagnostics.AddError("VM Update Error", fmt.Sprintf("VM was updated but open ports did not settle: %s", err))
+		return
+	}
+
+	// Refresh the data after update, allowing new instances to show up
+	err = r.refreshVmData(ctx, &data, 5, false)
 	if err != nil {
 		resp.Diagnostics.AddError("Client Error", fmt.Sprintf("Unable to refresh VM data after update: %s", err))
 		return
 	}
 
+	// The configured create-only arguments are now recorded in the state
+	resp.Diagnostics.Append(clearImported(ctx, resp.Private)...)
+
 	// Save updated data into Terraform state
 	resp.Diagnostics.Append(resp.State.Set(ctx, &data)...)
 }
 
+// scaleInstances creates or removes VM instances until the tracked set matches
+// the planned instance count. New instances use the stored configuration and
+// constraints. Waiting for the instances stops at deadline. If new instances
+// fail to become active, the enlarged set is saved to state before returning
+// the error so none of them are orphaned.
+func (r *VmResource) scaleInstances(ctx context.Context, data *VmResourceModel, deadline time.Time, resp *resource.UpdateResponse) diag.Diagnostics {
+	var diags diag.Diagnostics
+
+	vmIds := data.vmIds()
+	target := int(data.Instances.ValueInt64())
+
+	switch {
+	case target > len(vmIds):
+		tflog.Debug(ctx, "Scaling up VM instances", map[string]interface{}{
+			"vm_ids": vmIds,
+			"target": target,
+		})
+
+		createdVms, err := r.guard.createVms(ctx, data.createVmRequest(target-len(vmIds)))
+		r.cache.InvalidateVms()
+		if errors.Is(err, errSpendingLimitExceeded) {
+			diags.AddError("Spending Limit Exceeded", fmt.Sprintf("Unable to create additional VMs: %s", err))
+			return diags
+		}
+		if err != nil {
+			diags.AddError("Client Error", fmt.Sprintf("Unable to create additional VMs, got error: %s", err))
+			return diags
+		}
+
+		for _, createdVm := range createdVms {
+			vmIds = append(vmIds, createdVm.VmId)
+		}
+		data.setVmIds(vmIds)
+
+		vms, err := r.waiter().waitForVmActive(ctx, data.vmIds(), time.Until(deadline))
+		data.applyVms(vms)
+		if err != nil {
+			data.Instances = types.Int64Value(int64(len(vmIds)))
+			diags.Append(resp.State.Set(ctx, data)...)
+			diags.AddError("VM Scaling Error", fmt.Sprintf("Additional VMs were created but failed to become active: %s", err))
+			return diags
+		}
+
+	case target < len(vmIds):
+		remaining, removed := scaleDownVms(vmIds, target, data.ScaleDownOrder.ValueString())
+
+		tflog.Debug(ctx, "Scaling down VM instances", map[string]interface{}{
+			"vm_ids":  vmIds,
+			"removed": removed,
+		})
+
+		_, err := r.guard.removeVms(ctx, removed)
+		r.cache.InvalidateVms()
+		if err != nil {
+			diags.AddError("Client Error", fmt.Sprintf("Unable to remove VMs while scaling down, got error: %s", err))
+			return diags
+		}
+
+		data.setVmIds(remaining)
+
+		// Wait until the removed VMs are actually gone, as on delete, so a
+		// replacement with the same name does not race with the termination
+		err = r.waiter().waitForVmDeleted(ctx, removed, time.Until(deadline))
+		if err != nil {
+			data.Instances = types.Int64Value(int64(len(remaining)))
+			diags.Append(resp.State.Set(ctx, data)...)
+			diags.AddError("VM Scaling Error", fmt.Sprintf("VM removal was requested while scaling down but did not complete: %s", err))
+			return diags
+		}
+	}
+
+	return diags
+}
+
 func (r *VmResource) Delete(ctx context.Context, req resource.DeleteRequest, resp *resource.DeleteResponse) {
 	var data VmResourceModel
 
@@ -641,126 +668,698 @@ func (r *VmResource) Delete(ctx context.Context, req resource.DeleteRequest, res
 		return
 	}
 
-	// Delete the VM using the VM ID
-	vmIds := []string{data.ID.ValueString()}
-	_, err := r.client.RemoveVms(vmIds)
+	// Delete every VM instance tracked by the resource
+	vmIds := data.vmIds()
+	_, err := r.guard.removeVms(ctx, vmIds)
+	r.cache.InvalidateVms()
 	if err != nil {
 		resp.Diagnostics.AddError("Client Error", fmt.Sprintf("Unable to delete VM, got error: %s", err))
 		return
 	}
+
+	// Wait until the VMs are actually gone so a replacement with the same
+	// name does not race with the termination
+	deleteTimeout, diags := data.Timeouts.Delete(ctx, 10*time.Minute)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	err = r.waiter().waitForVmDeleted(ctx, vmIds, deleteTimeout)
+	if err != nil {
+		resp.Diagnostics.AddError("VM Deletion Error", fmt.Sprintf("VM removal was requested but did not complete: %s", err))
+		return
+	}
 }
 
 func (r *VmResource) ImportState(ctx context.Context, req resource.ImportStateRequest, resp *resource.ImportStateResponse) {
 	// Use the ID field for import
 	resource.ImportStatePassthroughID(ctx, path.Root("id"), req, resp)
+
+	// The create-only arguments are adopted from the configuration on the
+	// next apply instead of replacing the VM
+	resp.Diagnostics.Append(markImported(ctx, resp.Private)...)
+}
+
+// depositEstimateInputs lists the attributes that determine the deposit estimate.
+var depositEstimateInputs = []string{
+	"instances",
+	"basic_configuration",
+	"max_total_price_per_epoch_usd",
+	"datacenter_countries",
+	"hardware_constraints",
+	"additional_resources",
 }
 
-// waitForVmActive waits for a VM to reach the "Active" status
-func (r *VmResource) waitForVmActive(ctx context.Context, data *VmResourceModel, timeout time.Duration) error {
-	vmId := data.ID.ValueString()
+// ModifyPlan estimates the deposit of the planned VMs and enforces
+// max_deposit_usdc. The estimate is only refreshed when the resource is
+// created or its instance count or constraints change, so that price
+// fluctuations do not cause perpetual diffs.
+func (r *VmResource) ModifyPlan(ctx context.Context, req resource.ModifyPlanRequest, resp *resource.ModifyPlanResponse) {
+	// Nothing to estimate on destroy or before the provider is configured
+	if req.Plan.Raw.IsNull() || r.client == nil {
+		return
+	}
+
+	var planned, prior map[string]tftypes.Value
+	if err := req.Plan.Raw.As(&planned); err != nil {
+		resp.Diagnostics.AddError("Plan Error", fmt.Sprintf("Unable to read the planned VM, got error: %s", err))
+		return
+	}
+	if !req.State.Raw.IsNull() {
+		if err := req.State.Raw.As(&prior); err != nil {
+			resp.Diagnostics.AddError("Plan Error", fmt.Sprintf("Unable to read the VM state, got error: %s", err))
+			return
+		}
+	}
+
+	changed := prior == nil
+	for _, name := range depositEstimateInputs {
+		if !planned[name].IsFullyKnown() {
+			// The estimate is made during apply once every input is known
+			return
+		}
+		if prior != nil && !planned[name].Equal(prior[name]) {
+			changed = true
+		}
+	}
+
+	var data VmResourceModel
+	resp.Diagnostics.Append(req.Plan.GetAttribute(ctx, path.Root("max_deposit_usdc"), &data.MaxDepositUsdc)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	if changed {
+		resp.Diagnostics.Append(req.Plan.GetAttribute(ctx, path.Root("instances"), &data.Instances)...)
+		constraints, diags := plannedConstraints(ctx, req.Plan)
+		resp.Diagnostics.Append(diags...)
+		if resp.Diagnostics.HasError() {
+			return
+		}
+		data.ConstraintsModel = *constraints
+
+		estimate, diags := r.estimateDeposit(ctx, &data)
+		resp.Diagnostics.Append(diags...)
+		if resp.Diagnostics.HasError() {
+			return
+		}
+
+		// Refuse the plan when the new instances would break the provider
+		// spending limits, before any other resource of the run is applied
+		var priorInstances types.Int64
+		if prior != nil {
+			resp.Diagnostics.Append(req.State.GetAttribute(ctx, path.Root("instances"), &priorInstances)...)
+		}
+		resp.Diagnostics.Append(r.checkSpendingLimits(ctx, &data, int(priorInstances.ValueInt64()), estimate)...)
+		if resp.Diagnostics.HasError() || data.EstimatedDepositUsdc.IsNull() {
+			return
+		}
+	} else {
+		// Keep the estimate made when the VMs were last planned
+		resp.Diagnostics.Append(req.State.GetAttribute(ctx, path.Root("estimated_deposit_usdc"), &data.EstimatedDepositUsdc)...)
+		resp.Diagnostics.Append(req.State.GetAttribute(ctx, path.Root("estimated_price_per_epoch"), &data.EstimatedPricePerEpoch)...)
+		if resp.Diagnostics.HasError() {
+			return
+		}
+	}
+
+	resp.Diagnostics.Append(resp.Plan.SetAttribute(ctx, path.Root("estimated_deposit_usdc"), data.EstimatedDepositUsdc)...)
+	resp.Diagnostics.Append(resp.Plan.SetAttribute(ctx, path.Root("estimated_price_per_epoch"), data.EstimatedPricePerEpoch)...)
+	resp.Diagnostics.Append(data.checkMaxDeposit()...)
+}
+
+// checkSpendingLimits checks at plan time that the instances added to the
+// prior ones fit within the provider spending limits. Their price is taken
+// from the deposit estimate of the plan, which may be nil when it failed, as
+// the same maximum price that createVms checks again at apply time.
+func (r *VmResource) checkSpendingLimits(ctx context.Context, data *VmResourceModel, priorInstances int, estimate *fluenceapi.EstimatedDepositV3DTO) diag.Diagnostics {
+	planned := int(data.Instances.ValueInt64())
+	added := planned - priorInstances
+	if r.guard == nil || added <= 0 {
+		return nil
+	}
+
+	newPrice := 0.0
+	priced := false
+	if estimate != nil {
+		if price, err := limitedPrice(estimate); err == nil {
+			newPrice = price * float64(added) / float64(planned)
+			priced = true
+		}
+	}
+
+	return r.guard.checkPlannedVms(ctx, added, newPrice, priced)
+}
+
+// ensureDepositEstimate estimates the deposit during apply when it could
+// not be estimated at plan time, and enforces max_deposit_usdc before any VM
+// is created.
+func (r *VmResource) ensureDepositEstimate(ctx context.Context, data *VmResourceModel) diag.Diagnostics {
+	var diags diag.Diagnostics
+
+	if data.EstimatedDepositUsdc.IsUnknown() || data.EstimatedPricePerEpoch.IsUnknown() {
+		_, estimateDiags := r.estimateDeposit(ctx, data)
+		diags.Append(estimateDiags...)
+		if diags.HasError() {
+			return diags
+		}
+	}
+
+	diags.Append(data.checkMaxDeposit()...)
+	return diags
+}
+
+// estimateDeposit asks the API for the deposit of the planned instances,
+// stores it in the model and returns the estimate. A failed estimate is only
+// a warning, and leaves the estimate null, unless max_deposit_usdc is set and
+// cannot be checked.
+func (r *VmResource) estimateDeposit(ctx context.Context, data *VmResourceModel) (*fluenceapi.EstimatedDepositV3DTO, diag.Diagnostics) {
+	var diags diag.Diagnostics
+
+	instances := 1
+	if !data.Instances.IsNull() && !data.Instances.IsUnknown() {
+		instances = int(data.Instances.ValueInt64())
+	}
+
+	estimateRequest := fluenceapi.EstimateDepositRequestV3{
+		Constraints: data.offerConstraints(),
+		Instances:   instances,
+	}
+
+	tflog.Debug(ctx, "Estimating VM deposit", map[string]interface{}{
+		"instances":   estimateRequest.Instances,
+		"constraints": estimateRequest.Constraints,
+	})
+
+	estimate, err := withContext(ctx, r.client).EstimateDeposit(estimateRequest)
+	if err != nil {
+		data.EstimatedDepositUsdc = types.StringNull()
+		data.EstimatedPricePerEpoch = types.StringNull()
+
+		if !data.MaxDepositUsdc.IsNull() {
+			diags.AddAttributeError(
+				path.Root("max_deposit_usdc"),
+				"Deposit Estimate Error",
+				fmt.Sprintf("Unable to estimate the deposit of %d VM(s), so max_deposit_usdc cannot be enforced, got error: %s", instances, err),
+			)
+			return nil, diags
+		}
+
+		diags.AddWarning("Deposit Estimate Error", fmt.Sprintf("Unable to estimate the deposit of %d VM(s), got error: %s", instances, err))
+		return nil, diags
+	}
+
+	tflog.Debug(ctx, "Received deposit estimate", map[string]interface{}{
+		"deposit_amount_usdc":   estimate.DepositAmountUsdc,
+		"total_price_per_epoch": estimate.TotalPricePerEpoch,
+	})
+
+	data.EstimatedDepositUsdc = types.StringValue(estimate.DepositAmountUsdc)
+	data.EstimatedPricePerEpoch = types.StringValue(estimate.TotalPricePerEpoch)
+	return estimate, diags
+}
+
+// checkMaxDeposit reports an error when the estimated deposit exceeds
+// max_deposit_usdc.
+func (m *VmResourceModel) checkMaxDeposit() diag.Diagnostics {
+	var diags diag.Diagnostics
+
+	if m.MaxDepositUsdc.IsNull() || m.MaxDepositUsdc.IsUnknown() || m.EstimatedDepositUsdc.IsNull() || m.EstimatedDepositUsdc.IsUnknown() {
+		return diags
+	}
+
+	maxDeposit, err := strconv.ParseFloat(m.MaxDepositUsdc.ValueString(), 64)
+	if err != nil {
+		diags.AddAttributeError(path.Root("max_deposit_usdc"), "Invalid Deposit Limit", fmt.Sprintf("Unable to parse max_deposit_usdc: %s", err))
+		return diags
+	}
+
+	estimate, err := strconv.ParseFloat(m.EstimatedDepositUsdc.ValueString(), 64)
+	if err != nil {
+		diags.AddError("Deposit Estimate Error", fmt.Sprintf("Unable to parse the estimated deposit %q: %s", m.EstimatedDepositUsdc.ValueString(), err))
+		return diags
+	}
+
+	if estimate > maxDeposit {
+		diags.AddAttributeError(
+			path.Root("max_deposit_usdc"),
+			"Deposit Limit Exceeded",
+			fmt.Sprintf("The estimated deposit for %d VM(s) is %s USDC, which exceeds max_deposit_usdc (%s USDC). "+
+				"Check the instances count and constraints, or raise max_deposit_usdc.",
+				m.Instances.ValueInt64(), m.EstimatedDepositUsdc.ValueString(), m.MaxDepositUsdc.ValueString()),
+		)
+	}
+
+	return diags
+}
+
+// vmWaiter polls the API until VM instances reach an expected state. It is
+// shared by the resources that manage VMs.
+type vmWaiter struct {
+	client  *fluenceapi.Client
+	polling pollConfig
+}
+
+func (r *VmResource) waiter() vmWaiter {
+	return vmWaiter{client: r.client, polling: r.polling}
+}
+
+// waitForVmActive waits for every VM instance to reach the "Active" status.
+// The VMs last returned by the API are returned even on error, so the caller
+// can record them.
+func (w vmWaiter) waitForVmActive(ctx context.Context, vmIds []string, timeout time.Duration) ([]fluenceapi.RunningInstanceV3, error) {
 	tflog.Debug(ctx, "Waiting for VM to become active", map[string]interface{}{
-		"vm_id":   vmId,
+		"vm_ids":  vmIds,
 		"timeout": timeout.String(),
 	})
 
-	retryInterval := time.Second * 10 // Check every 10 seconds
-	maxRetries := int(timeout / retryInterval)
+	waitCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
 
-	for attempt := 0; attempt < maxRetries; attempt++ {
-		if attempt > 0 {
-			tflog.Debug(ctx, "Waiting for VM status check", map[string]interface{}{
-				"attempt":      attempt + 1,
-				"max_attempts": maxRetries,
-				"vm_id":        vmId,
-			})
-			time.Sleep(retryInterval)
+	var foundVms []fluenceapi.RunningInstanceV3
+	p := w.polling.newPoller()
+	for {
+		vms, err := w.checkVmActive(ctx, vmIds, p)
+		if vms != nil {
+			foundVms = vms
+		}
+		if err == nil {
+			return foundVms, nil
+		}
+		if !errors.Is(err, errVmPending) {
+			return foundVms, err
 		}
 
-		// Get all VMs and find the one matching our ID
-		vms, err := r.client.ListVmsV3()
+		if err := p.Wait(waitCtx); err != nil {
+			break
+		}
+	}
+
+	// Stop right away if Terraform cancelled the operation
+	if ctx.Err() != nil {
+		return foundVms, ctx.Err()
+	}
+
+	// If we've run out of time, return the current status in the error
+	currentStatus := "unknown"
+	if len(foundVms) > 0 {
+		currentStatus = foundVms[0].Status
+	}
+
+	return foundVms, fmt.Errorf("VM did not become active within %v (current status: %s)", timeout, currentStatus)
+}
+
+// checkVmActive polls the status of every VM instance once and returns the
+// instances found. The error is nil when all of them are active and
+// errVmPending when the caller should keep waiting.
+func (w vmWaiter) checkVmActive(ctx context.Context, vmIds []string, p *poller) ([]fluenceapi.RunningInstanceV3, error) {
+	// Get all VMs and find the ones matching our IDs
+	vms, err := withContext(ctx, w.client).ListVmsV3()
+	if err != nil {
+		tflog.Warn(ctx, "Error retrieving VMs while waiting for availability", map[string]interface{}{
+			"error":   err.Error(),
+			"attempt": p.Attempt(),
+			"vm_ids":  vmIds,
+		})
+		return nil, errVmPending // Continue retrying on API errors
+	}
+
+	foundVms := findVms(vms, vmIds)
+	if len(foundVms) < len(vmIds) {
+		tflog.Warn(ctx, "VM not found while waiting for availability", map[string]interface{}{
+			"attempt": p.Attempt(),
+			"vm_ids":  vmIds,
+			"found":   len(foundVms),
+		})
+		return nil, errVmPending // Continue retrying if VM not found
+	}
+
+	// Check whether all instances have reached active status
+	active := 0
+	for _, vm := range foundVms {
+		tflog.Debug(ctx, "Checking VM status", map[string]interface{}{
+			"vm_id":   vm.Id,
+			"status":  vm.Status,
+			"attempt": p.Attempt(),
+		})
+
+		// Check for failure states that we should not wait through
+		if vm.Status == "failed" || vm.Status == "error" || vm.Status == "Failed" || vm.Status == "Error" {
+			return foundVms, fmt.Errorf("VM %s creation failed with status: %s", vm.Id, vm.Status)
+		}
+
+		if vm.Status == "Active" {
+			active++
+		}
+	}
+
+	if active == len(foundVms) {
+		tflog.Info(ctx, "VM is now active", map[string]interface{}{
+			"vm_ids":       vmIds,
+			"total_time":   p.Elapsed().String(),
+			"final_status": foundVms[0].Status,
+		})
+		return foundVms, nil
+	}
+
+	tflog.Debug(ctx, "VM not yet active, continuing to wait", map[string]interface{}{
+		"vm_ids":         vmIds,
+		"active":         active,
+		"current_status": foundVms[0].Status,
+		"time_elapsed":   p.Elapsed().String(),
+	})
+
+	return foundVms, errVmPending
+}
+
+// waitForVmDeleted waits until none of the VMs are listed by the API anymore
+// or all of them have reached the "Terminated" status
+func (w vmWaiter) waitForVmDeleted(ctx context.Context, vmIds []string, timeout time.Duration) error {
+	tflog.Debug(ctx, "Waiting for VM to be deleted", map[string]interface{}{
+		"vm_ids":  vmIds,
+		"timeout": timeout.String(),
+	})
+
+	waitCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	remaining := len(vmIds)
+	p := w.polling.newPoller()
+	for {
+		vms, err := withContext(ctx, w.client).ListVmsV3()
 		if err != nil {
-			tflog.Warn(ctx, "Error retrieving VMs while waiting for availability", map[string]interface{}{
+			tflog.Warn(ctx, "Error retrieving VMs while waiting for deletion", map[string]interface{}{
 				"error":   err.Error(),
-				"attempt": attempt + 1,
-				"vm_id":   vmId,
+				"attempt": p.Attempt(),
+				"vm_ids":  vmIds,
+			})
+		} else {
+			remaining = len(liveVms(findVms(vms, vmIds)))
+
+			if remaining == 0 {
+				tflog.Info(ctx, "VM is now deleted", map[string]interface{}{
+					"vm_ids":     vmIds,
+					"total_time": p.Elapsed().String(),
+				})
+				return nil
+			}
+
+			tflog.Debug(ctx, "VM not yet deleted, continuing to wait", map[string]interface{}{
+				"vm_ids":    vmIds,
+				"remaining": remaining,
+				"attempt":   p.Attempt(),
 			})
-			continue // Continue retrying on API errors
 		}
 
-		// Find the VM by ID
-		var foundVm *fluenceapi.RunningInstanceV3
-		for _, vm := range vms {
-			if vm.Id == vmId {
-				foundVm = &vm
-				break
-			}
+		if err := p.Wait(waitCtx); err != nil {
+			break
 		}
+	}
+
+	// Stop right away if Terraform cancelled the operation
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+
+	return fmt.Errorf("%d VM(s) were not terminated within %v", remaining, timeout)
+}
+
+// waitForVmPorts waits until every VM reports exactly the given open ports
+func (w vmWaiter) waitForVmPorts(ctx context.Context, vmIds []string, openPorts []fluenceapi.OpenPorts, timeout time.Duration) error {
+	tflog.Debug(ctx, "Waiting for VM open ports to settle", map[string]interface{}{
+		"vm_ids":  vmIds,
+		"timeout": timeout.String(),
+	})
+
+	waitCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	pending := len(vmIds)
+	p := w.polling.newPoller()
+	for {
+		vms, err := withContext(ctx, w.client).ListVmsV3()
+		if err != nil {
+			tflog.Warn(ctx, "Error retrieving VMs while waiting for open ports", map[string]interface{}{
+				"error":   err.Error(),
+				"attempt": p.Attempt(),
+				"vm_ids":  vmIds,
+			})
+		} else {
+			foundVms := findVms(vms, vmIds)
+			pending = len(vmIds) - len(foundVms)
+			for _, vm := range foundVms {
+				if !portsMatch(vm.Ports, openPorts) {
+					pending++
+				}
+			}
 
-		if foundVm == nil {
-			tflog.Warn(ctx, "VM not found while waiting for availability", map[string]interface{}{
-				"attempt": attempt + 1,
-				"vm_id":   vmId,
+			if pending == 0 {
+				return nil
+			}
+
+			tflog.Debug(ctx, "VM open ports not yet applied, continuing to wait", map[string]interface{}{
+				"vm_ids":  vmIds,
+				"pending": pending,
+				"attempt": p.Attempt(),
 			})
-			continue // Continue retrying if VM not found
 		}
 
-		tflog.Debug(ctx, "Checking VM status", map[string]interface{}{
-			"vm_id":   vmId,
-			"status":  foundVm.Status,
-			"attempt": attempt + 1,
-		})
+		if err := p.Wait(waitCtx); err != nil {
+			break
+		}
+	}
 
-		// Update the model with current data
-		data.Status = types.StringValue(foundVm.Status)
-		data.StatusChangedAt = types.StringValue(foundVm.StatusChangedAt)
-		data.PricePerEpoch = types.StringValue(foundVm.PricePerEpoch)
-		data.CreatedAt = types.StringValue(foundVm.CreatedAt)
-		data.NextBillingAt = types.StringValue(foundVm.NextBillingAt)
-		data.ReservedBalance = types.StringValue(foundVm.ReservedBalance)
-		data.TotalSpent = types.StringValue(foundVm.TotalSpent)
+	// Stop right away if Terraform cancelled the operation
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
 
-		if foundVm.OsImage != nil {
-			data.OsImage = types.StringValue(*foundVm.OsImage)
+	return fmt.Errorf("open ports were not applied to %d VM(s) within %v", pending, timeout)
+}
+
+// portsMatch reports whether the ports reported by the API are the same set
+// as the requested open ports.
+func portsMatch(actual *[]fluenceapi.PortSpec, expected []fluenceapi.OpenPorts) bool {
+	actualSet := map[string]bool{}
+	if actual != nil {
+		for _, p := range *actual {
+			actualSet[fmt.Sprintf("%d/%s", p.Port, strings.ToLower(p.Protocol))] = true
 		}
+	}
 
-		if foundVm.PublicIp != nil {
-			data.PublicIp = types.StringValue(*foundVm.PublicIp)
-		} else {
-			data.PublicIp = types.StringNull()
+	expectedSet := map[string]bool{}
+	for _, p := range expected {
+		expectedSet[fmt.Sprintf("%d/%s", p.Port, strings.ToLower(p.Protocol))] = true
+	}
+
+	if len(actualSet) != len(expectedSet) {
+		return false
+	}
+	for key := range expectedSet {
+		if !actualSet[key] {
+			return false
 		}
+	}
+	return true
+}
 
-		if foundVm.VmName != nil {
-			data.Name = types.StringValue(*foundVm.VmName)
+// createVmRequest builds the request that creates the given number of VM
+// instances from the configuration and constraints of the model.
+func (m *VmResourceModel) createVmRequest(instances int) fluenceapi.CreateVmV3 {
+	// Build the VM configuration
+	vmConfig := fluenceapi.VmConfiguration{
+		Name:      m.Name.ValueString(),
+		OsImage:   m.OsImage.ValueString(),
+		OpenPorts: []fluenceapi.OpenPorts{},
+		SshKeys:   []string{},
+	}
+
+	// Set hostname if provided
+	if !m.Hostname.IsNull() && !m.Hostname.IsUnknown() {
+		hostname := m.Hostname.ValueString()
+		vmConfig.Hostname = &hostname
+	}
+
+	// Convert SSH keys
+	for _, sshKey := range m.SshKeys {
+		vmConfig.SshKeys = append(vmConfig.SshKeys, sshKey.ValueString())
+	}
+
+	// Convert open ports
+	vmConfig.OpenPorts = m.apiOpenPorts()
+
+	// A unique request ID lets the API deduplicate a create that is
+	// submitted again after a rate-limited attempt
+	return fluenceapi.CreateVmV3{
+		Constraints:     m.offerConstraints(),
+		Instances:       instances,
+		RequestId:       newRequestId(),
+		VmConfiguration: vmConfig,
+	}
+}
+
+// apiOpenPorts converts the configured open ports to their API form. It never
+// returns nil, so the API always receives an explicit list.
+func (m *VmResourceModel) apiOpenPorts() []fluenceapi.OpenPorts {
+	openPorts := []fluenceapi.OpenPorts{}
+	for _, port := range m.OpenPorts {
+		openPorts = append(openPorts, fluenceapi.OpenPorts{
+			Port:     uint16(port.Port.ValueInt64()),
+			Protocol: port.Protocol.ValueString(),
+		})
+	}
+	return openPorts
+}
+
+// refreshOpenPorts updates open_ports from the ports reported by the API so
+// that firewall changes made outside of Terraform show up as drift. The
+// existing list is kept when it describes the same set of ports, which
+// avoids diffs caused only by ordering or by null versus empty lists.
+func (m *VmResourceModel) refreshOpenPorts(vm fluenceapi.RunningInstanceV3) {
+	if vm.Ports == nil {
+		return
+	}
+
+	if portsMatch(vm.Ports, m.apiOpenPorts()) {
+		return
+	}
+
+	openPorts := []OpenPortModel{}
+	for _, port := range *vm.Ports {
+		openPorts = append(openPorts, OpenPortModel{
+			Port:     types.Int64Value(int64(port.Port)),
+			Protocol: types.StringValue(port.Protocol),
+		})
+	}
+	m.OpenPorts = openPorts
+}
+
+// vmIds returns the IDs of every VM instance tracked by the model. States
+// written before multiple instances were tracked only carry the primary ID.
+func (m *VmResourceModel) vmIds() []string {
+	if m.VmIds.IsNull() || m.VmIds.IsUnknown() || len(m.VmIds.Elements()) == 0 {
+		return []string{m.ID.ValueString()}
+	}
+
+	vmIds := []string{}
+	for _, elem := range m.VmIds.Elements() {
+		if id, ok := elem.(types.String); ok {
+			vmIds = append(vmIds, id.ValueString())
 		}
+	}
+	return vmIds
+}
 
-		// Check if VM has reached active status
-		if foundVm.Status == "Active" {
-			tflog.Info(ctx, "VM is now active", map[string]interface{}{
-				"vm_id":        vmId,
-				"total_time":   time.Duration(attempt) * retryInterval,
-				"final_status": foundVm.Status,
-			})
-			return nil
+// setVmIds records the tracked VM instances. The first ID becomes the resource ID.
+func (m *VmResourceModel) setVmIds(vmIds []string) {
+	elems := make([]attr.Value, len(vmIds))
+	for i, id := range vmIds {
+		elems[i] = types.StringValue(id)
+	}
+	m.VmIds = types.ListValueMust(types.StringType, elems)
+	if len(vmIds) > 0 {
+		m.ID = types.StringValue(vmIds[0])
+	}
+}
+
+// applyVms updates the model from the API data of its instances. The first
+// instance is the primary one and populates the single-value attributes.
+func (m *VmResourceModel) applyVms(vms []fluenceapi.RunningInstanceV3) {
+	if len(vms) == 0 {
+		return
+	}
+
+	vmIds := make([]string, len(vms))
+	publicIps := make([]attr.Value, len(vms))
+	statuses := make([]attr.Value, len(vms))
+	prices := make([]attr.Value, len(vms))
+	for i, vm := range vms {
+		vmIds[i] = vm.Id
+		statuses[i] = types.StringValue(vm.Status)
+		prices[i] = types.StringValue(vm.PricePerEpoch)
+		if vm.PublicIp != nil {
+			publicIps[i] = types.StringValue(*vm.PublicIp)
+		} else {
+			publicIps[i] = types.StringNull()
 		}
+	}
+	m.setVmIds(vmIds)
+	m.PublicIps = types.ListValueMust(types.StringType, publicIps)
+	m.Statuses = types.ListValueMust(types.StringType, statuses)
+	m.PricesPerEpoch = types.ListValueMust(types.StringType, prices)
+
+	primary := vms[0]
+	m.Status = types.StringValue(primary.Status)
+	m.StatusChangedAt = types.StringValue(primary.StatusChangedAt)
+	m.PricePerEpoch = types.StringValue(primary.PricePerEpoch)
+	m.CreatedAt = types.StringValue(primary.CreatedAt)
+	m.NextBillingAt = types.StringValue(primary.NextBillingAt)
+	m.ReservedBalance = types.StringValue(primary.ReservedBalance)
+	m.TotalSpent = types.StringValue(primary.TotalSpent)
+
+	if primary.OsImage != nil {
+		m.OsImage = types.StringValue(*primary.OsImage)
+	}
 
-		// Check for failure states that we should not wait through
-		if foundVm.Status == "failed" || foundVm.Status == "error" || foundVm.Status == "Failed" || foundVm.Status == "Error" {
-			return fmt.Errorf("VM creation failed with status: %s", foundVm.Status)
+	if primary.PublicIp != nil {
+		m.PublicIp = types.StringValue(*primary.PublicIp)
+	} else {
+		m.PublicIp = types.StringNull()
+	}
+
+	if primary.VmName != nil {
+		m.Name = types.StringValue(*primary.VmName)
+	}
+}
+
+// liveVms returns the VMs that have not been terminated.
+func liveVms(vms []fluenceapi.RunningInstanceV3) []fluenceapi.RunningInstanceV3 {
+	live := []fluenceapi.RunningInstanceV3{}
+	for _, vm := range vms {
+		if vm.Status != fluenceapi.VmStatusTerminated {
+			live = append(live, vm)
 		}
+	}
+	return live
+}
 
-		tflog.Debug(ctx, "VM not yet active, continuing to wait", map[string]interface{}{
-			"vm_id":          vmId,
-			"current_status": foundVm.Status,
-			"time_elapsed":   time.Duration(attempt+1) * retryInterval,
-			"time_remaining": timeout - (time.Duration(attempt+1) * retryInterval),
-		})
+// scaleDownVms splits vmIds, which are kept in creation order, into the
+// instances that remain and the instances to remove when shrinking to target.
+// The primary instance at index 0 is always kept.
+func scaleDownVms(vmIds []string, target int, order string) (remaining, removed []string) {
+	if target < 1 {
+		target = 1
+	}
+	if target >= len(vmIds) {
+		return vmIds, nil
 	}
 
-	// If we've exhausted all retries, return the current status in the error
-	currentStatus := "unknown"
-	if !data.Status.IsNull() {
-		currentStatus = data.Status.ValueString()
+	count := len(vmIds) - target
+	if order == scaleDownOldestFirst {
+		remaining = append([]string{vmIds[0]}, vmIds[1+count:]...)
+		removed = append([]string{}, vmIds[1:1+count]...)
+		return remaining, removed
 	}
 
-	return fmt.Errorf("VM did not become active within %v (current status: %s)", timeout, currentStatus)
+	remaining = append([]string{}, vmIds[:target]...)
+	removed = append([]string{}, vmIds[target:]...)
+	return remaining, removed
+}
+
+// findVms returns the VMs matching vmIds, in the order of vmIds. IDs missing
+// from vms are skipped.
+func findVms(vms []fluenceapi.RunningInstanceV3, vmIds []string) []fluenceapi.RunningInstanceV3 {
+	byId := make(map[string]fluenceapi.RunningInstanceV3, len(vms))
+	for _, vm := range vms {
+		byId[vm.Id] = vm
+	}
+
+	found := []fluenceapi.RunningInstanceV3{}
+	for _, id := range vmIds {
+		if vm, ok := byId[id]; ok {
+			found = append(found, vm)
+		}
+	}
+	return found
 }
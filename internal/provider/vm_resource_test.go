@@ -0,0 +1,529 @@
+package provider
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"regexp"
+	"strings"
+	"testing"
+
+	fluenceapi "github.com/decentralized-infrastructure/fluence-api-client-go"
+	"github.com/hashicorp/terraform-plugin-testing/helper/resource"
+	"github.com/hashicorp/terraform-plugin-testing/plancheck"
+	"github.com/hashicorp/terraform-plugin-testing/terraform"
+
+	"terraform-provider-fluence/internal/fakeapi"
+)
+
+func TestAccVmResource(t *testing.T) {
+	srv := testAccServer(t)
+	publicKey := testAccPublicKey(t, "")
+
+	resource.Test(t, resource.TestCase{
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		CheckDestroy:             testAccCheckVmsDestroyed(srv),
+		Steps: []resource.TestStep{
+			// Create, while the new VM is missing from the first listings,
+			// goes through several statuses and the API fails once
+			{
+				PreConfig: func() {
+					srv.SetStatusTransitions(fluenceapi.VmStatusLaunching, fluenceapi.VmStatusLaunching, fluenceapi.VmStatusActive)
+					srv.SetListLag(2)
+					srv.InjectFault(http.MethodGet, "/vms/v3", http.StatusServiceUnavailable, 1, 0)
+				},
+				Config: testAccVmResourceConfig(srv, publicKey, "acc-vm", 1, 22),
+				Check: resource.ComposeAggregateTestCheckFunc(
+					resource.TestCheckResourceAttrSet("fluence_vm.test", "id"),
+					resource.TestCheckResourceAttr("fluence_vm.test", "status", fluenceapi.VmStatusActive),
+					resource.TestCheckResourceAttrSet("fluence_vm.test", "public_ip"),
+					resource.TestCheckResourceAttr("fluence_vm.test", "price_per_epoch", "1.5"),
+					resource.TestCheckResourceAttr("fluence_vm.test", "estimated_price_per_epoch", "1.5"),
+					resource.TestCheckResourceAttr("fluence_vm.test", "estimated_deposit_usdc", "3"),
+					resource.TestCheckResourceAttr("fluence_vm.test", "vm_ids.#", "1"),
+					resource.TestCheckResourceAttrPair("fluence_vm.test", "vm_ids.0", "fluence_vm.test", "id"),
+					resource.TestCheckResourceAttr("fluence_vm.test", "open_ports.#", "1"),
+					testAccCheckFakeVms(srv, 1, "acc-vm", 22),
+				),
+			},
+			// Rename, open a port and scale up in place
+			{
+				Config: testAccVmResourceConfig(srv, publicKey, "acc-vm-renamed", 3, 22, 80),
+				ConfigPlanChecks: resource.ConfigPlanChecks{
+					PreApply: []plancheck.PlanCheck{
+						plancheck.ExpectResourceAction("fluence_vm.test", plancheck.ResourceActionUpdate),
+					},
+				},
+				Check: resource.ComposeAggregateTestCheckFunc(
+					resource.TestCheckResourceAttr("fluence_vm.test", "name", "acc-vm-renamed"),
+					resource.TestCheckResourceAttr("fluence_vm.test", "vm_ids.#", "3"),
+					resource.TestCheckResourceAttr("fluence_vm.test", "public_ips.#", "3"),
+					resource.TestCheckResourceAttr("fluence_vm.test", "statuses.#", "3"),
+					resource.TestCheckResourceAttr("fluence_vm.test", "prices_per_epoch.#", "3"),
+					resource.TestCheckResourceAttr("fluence_vm.test", "estimated_price_per_epoch", "4.5"),
+					resource.TestCheckResourceAttr("fluence_vm.test", "estimated_deposit_usdc", "9"),
+					testAccCheckFakeVms(srv, 3, "acc-vm-renamed", 22, 80),
+				),
+			},
+			// Scale down in place
+			{
+				Config: testAccVmResourceConfig(srv, publicKey, "acc-vm-renamed", 2, 22, 80),
+				ConfigPlanChecks: resource.ConfigPlanChecks{
+					PreApply: []plancheck.PlanCheck{
+						plancheck.ExpectResourceAction("fluence_vm.test", plancheck.ResourceActionUpdate),
+					},
+				},
+				Check: resource.ComposeAggregateTestCheckFunc(
+					resource.TestCheckResourceAttr("fluence_vm.test", "vm_ids.#", "2"),
+					testAccCheckFakeVms(srv, 2, "acc-vm-renamed", 22, 80),
+				),
+			},
+			// Ports edited outside of Terraform are restored
+			{
+				PreConfig: func() {
+					for _, vm := range srv.Vms() {
+						srv.SetVmPorts(vm.Id, []fluenceapi.PortSpec{{Port: 8080, Protocol: fluenceapi.ProtocolTCP}})
+					}
+				},
+				Config: testAccVmResourceConfig(srv, publicKey, "acc-vm-renamed", 2, 22, 80),
+				ConfigPlanChecks: resource.ConfigPlanChecks{
+					PreApply: []plancheck.PlanCheck{
+						plancheck.ExpectResourceAction("fluence_vm.test", plancheck.ResourceActionUpdate),
+					},
+				},
+				Check: testAccCheckFakeVms(srv, 2, "acc-vm-renamed", 22, 80),
+			},
+			// A changed os_image replaces every instance
+			{
+				Config: strings.Replace(testAccVmResourceConfig(srv, publicKey, "acc-vm-renamed", 2, 22, 80), "24.04", "22.04", 2),
+				ConfigPlanChecks: resource.ConfigPlanChecks{
+					PreApply: []plancheck.PlanCheck{
+						plancheck.ExpectResourceAction("fluence_vm.test", plancheck.ResourceActionDestroyBeforeCreate),
+					},
+				},
+				Check: testAccCheckFakeVms(srv, 2, "acc-vm-renamed", 22, 80),
+			},
+		},
+	})
+}
+
+func TestAccVmResource_import(t *testing.T) {
+	srv := testAccServer(t)
+	publicKey := testAccPublicKey(t, "")
+
+	resource.Test(t, resource.TestCase{
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		CheckDestroy:             testAccCheckVmsDestroyed(srv),
+		Steps: []resource.TestStep{
+			{
+				Config: testAccVmResourceConfig(srv, publicKey, "acc-vm", 1, 22),
+			},
+			{
+				ResourceName:      "fluence_vm.test",
+				ImportState:       true,
+				ImportStateVerify: true,
+				// The API does not return the create-only arguments or the
+				// scale-down order, and the deposit is only estimated during
+				// plan
+				ImportStateVerifyIgnore: []string{
+					"ssh_keys",
+					"scale_down_order",
+					"basic_configuration",
+					"datacenter_countries",
+					"estimated_deposit_usdc",
+					"estimated_price_per_epoch",
+				},
+			},
+		},
+	})
+}
+
+func TestAccVmResource_adopt(t *testing.T) {
+	srv := testAccServer(t)
+	publicKey := testAccPublicKey(t, "")
+	basicConfiguration := "cpu-2-ram-4gb-storage-25gb"
+	created, err := testAccClient(t, srv).CreateVmV3(fluenceapi.CreateVmV3{
+		Constraints: &fluenceapi.OfferConstraints{
+			BasicConfiguration: &basicConfiguration,
+			Datacenter:         &fluenceapi.DatacenterConstraint{Countries: []string{"DE"}},
+		},
+		Instances: 1,
+		VmConfiguration: fluenceapi.VmConfiguration{
+			Name:      "acc-vm",
+			OsImage:   testAccOsImage,
+			OpenPorts: []fluenceapi.OpenPorts{{Port: 22, Protocol: fluenceapi.ProtocolTCP}},
+			SshKeys:   []string{},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	resource.Test(t, resource.TestCase{
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		CheckDestroy:             testAccCheckVmsDestroyed(srv),
+		Steps: []resource.TestStep{
+			{
+				Config:             testAccVmResourceConfig(srv, publicKey, "acc-vm", 1, 22),
+				ResourceName:       "fluence_vm.test",
+				ImportState:        true,
+				ImportStateId:      created[0].VmId,
+				ImportStatePersist: true,
+			},
+			// The create-only arguments of an imported VM are adopted in
+			// place, after which nothing is planned
+			{
+				Config: testAccVmResourceConfig(srv, publicKey, "acc-vm", 1, 22),
+				ConfigPlanChecks: resource.ConfigPlanChecks{
+					PreApply: []plancheck.PlanCheck{
+						plancheck.ExpectResourceAction("fluence_vm.test", plancheck.ResourceActionUpdate),
+					},
+				},
+				Check: resource.ComposeAggregateTestCheckFunc(
+					resource.TestCheckResourceAttr("fluence_vm.test", "id", created[0].VmId),
+					testAccCheckFakeVms(srv, 1, "acc-vm", 22),
+				),
+			},
+			{
+				Config: testAccVmResourceConfig(srv, publicKey, "acc-vm", 1, 22),
+				ConfigPlanChecks: resource.ConfigPlanChecks{
+					PreApply: []plancheck.PlanCheck{
+						plancheck.ExpectEmptyPlan(),
+					},
+				},
+			},
+		},
+	})
+}
+
+func TestAccVmResource_deletedOutsideTerraform(t *testing.T) {
+	srv := testAccServer(t)
+	publicKey := testAccPublicKey(t, "")
+
+	resource.Test(t, resource.TestCase{
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		CheckDestroy:             testAccCheckVmsDestroyed(srv),
+		Steps: []resource.TestStep{
+			{
+				Config: testAccVmResourceConfig(srv, publicKey, "acc-vm", 1, 22),
+			},
+			{
+				PreConfig: func() {
+					for _, vm := range srv.Vms() {
+						srv.DeleteVm(vm.Id)
+					}
+				},
+				Config: testAccVmResourceConfig(srv, publicKey, "acc-vm", 1, 22),
+				ConfigPlanChecks: resource.ConfigPlanChecks{
+					PreApply: []plancheck.PlanCheck{
+						plancheck.ExpectResourceAction("fluence_vm.test", plancheck.ResourceActionCreate),
+					},
+				},
+				Check: testAccCheckFakeVms(srv, 1, "acc-vm", 22),
+			},
+		},
+	})
+}
+
+func TestAccVmResource_failedLaunch(t *testing.T) {
+	srv := testAccServer(t)
+	srv.SetStatusTransitions(fluenceapi.VmStatusLaunching, fluenceapi.VmStatusFailed)
+
+	resource.Test(t, resource.TestCase{
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		Steps: []resource.TestStep{
+			{
+				Config:      testAccVmResourceConfig(srv, testAccPublicKey(t, ""), "acc-vm", 1, 22),
+				ExpectError: regexp.MustCompile(`Failed`),
+			},
+		},
+	})
+}
+
+func TestAccVmResource_maxDeposit(t *testing.T) {
+	srv := testAccServer(t)
+	publicKey := testAccPublicKey(t, "")
+
+	resource.Test(t, resource.TestCase{
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		CheckDestroy:             testAccCheckVmsDestroyed(srv),
+		Steps: []resource.TestStep{
+			// The deposit of one VM covers two epochs at 1.5 USD
+			{
+				Config:      testAccVmMaxDepositConfig(srv, publicKey, 1, "2.5"),
+				ExpectError: regexp.MustCompile(`Deposit Limit Exceeded`),
+			},
+			{
+				Config: testAccVmMaxDepositConfig(srv, publicKey, 1, "3"),
+				Check: resource.ComposeAggregateTestCheckFunc(
+					resource.TestCheckResourceAttr("fluence_vm.test", "estimated_deposit_usdc", "3"),
+					testAccCheckFakeVms(srv, 1, "acc-vm", 22),
+				),
+			},
+			// Scaling up is refused before any VM is created
+			{
+				Config:      testAccVmMaxDepositConfig(srv, publicKey, 2, "3"),
+				ExpectError: regexp.MustCompile(`Deposit Limit Exceeded`),
+			},
+			{
+				Config: testAccVmMaxDepositConfig(srv, publicKey, 1, "3"),
+				Check:  testAccCheckFakeVms(srv, 1, "acc-vm", 22),
+			},
+		},
+	})
+}
+
+func TestAccVmResource_spendingLimits(t *testing.T) {
+	srv := testAccServer(t)
+	publicKey := testAccPublicKey(t, "")
+
+	// Without a basic configuration the VM may land on any offer in DE,
+	// from 1.5 to 9.5 USD per epoch
+	anyConfiguration := strings.Replace(testAccVmConfig(publicKey, "acc-vm", 1, 22), `basic_configuration  = "cpu-2-ram-4gb-storage-25gb"`, "", 1)
+
+	resource.Test(t, resource.TestCase{
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		CheckDestroy:             testAccCheckVmsDestroyed(srv),
+		Steps: []resource.TestStep{
+			{
+				Config:      testAccProviderConfig(srv, "max_vm_count = 2") + testAccVmConfig(publicKey, "acc-vm", 3, 22),
+				PlanOnly:    true,
+				ExpectError: regexp.MustCompile(`Spending Limit Exceeded`),
+			},
+			{
+				Config:      testAccProviderConfig(srv, `max_total_price_per_epoch_usd = "2"`) + testAccVmConfig(publicKey, "acc-vm", 2, 22),
+				PlanOnly:    true,
+				ExpectError: regexp.MustCompile(`Spending Limit Exceeded`),
+			},
+			// The plan is checked against the highest price, like the apply
+			{
+				Config:      testAccProviderConfig(srv, `max_total_price_per_epoch_usd = "5"`) + anyConfiguration,
+				PlanOnly:    true,
+				ExpectError: regexp.MustCompile(`Spending Limit Exceeded`),
+			},
+			{
+				Config: testAccProviderConfig(srv, "max_vm_count = 2") + testAccVmConfig(publicKey, "acc-vm", 2, 22),
+				Check:  testAccCheckFakeVms(srv, 2, "acc-vm", 22),
+			},
+		},
+	})
+}
+
+func TestAccVmResource_partialCreate(t *testing.T) {
+	api := fakeapi.New(fakeapi.WithApiKey(testAccApiKey))
+
+	// The API launches only the first of the requested VMs
+	srv := &fakeapi.Server{API: api, Server: httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost || r.URL.Path != "/vms/v3" {
+			api.ServeHTTP(w, r)
+			return
+		}
+
+		rec := httptest.NewRecorder()
+		api.ServeHTTP(rec, r)
+		var created []fluenceapi.CreatedVm
+		if err := json.Unmarshal(rec.Body.Bytes(), &created); err != nil || len(created) < 2 {
+			w.WriteHeader(rec.Code)
+			w.Write(rec.Body.Bytes())
+			return
+		}
+		for _, vm := range created[1:] {
+			api.DeleteVm(vm.VmId)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(rec.Code)
+		json.NewEncoder(w).Encode(created[:1])
+	}))}
+	t.Cleanup(srv.Close)
+
+	resource.Test(t, resource.TestCase{
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		CheckDestroy:             testAccCheckVmsDestroyed(srv),
+		Steps: []resource.TestStep{
+			{
+				Config:      testAccVmResourceConfig(srv, testAccPublicKey(t, ""), "acc-vm", 2, 22),
+				ExpectError: regexp.MustCompile(`Expected 2 VMs to be created, got 1`),
+			},
+			// The VM that was created has been removed
+			{
+				Config: testAccProviderConfig(srv),
+				Check:  testAccCheckVmsDestroyed(srv),
+			},
+		},
+	})
+}
+
+func testAccVmResourceConfig(srv *fakeapi.Server, publicKey, name string, instances int, ports ...int) string {
+	return testAccProviderConfig(srv) + testAccVmConfig(publicKey, name, instances, ports...)
+}
+
+// testAccVmMaxDepositConfig configures a VM with the given max_deposit_usdc.
+func testAccVmMaxDepositConfig(srv *fakeapi.Server, publicKey string, instances int, maxDeposit string) string {
+	return strings.Replace(testAccVmResourceConfig(srv, publicKey, "acc-vm", instances, 22),
+		"  basic_configuration", fmt.Sprintf("  max_deposit_usdc     = %q\n  basic_configuration", maxDeposit), 1)
+}
+
+// testAccVmConfig declares an SSH key and a VM using it, without the
+// provider block.
+func testAccVmConfig(publicKey, name string, instances int, ports ...int) string {
+	openPorts := []string{}
+	for _, port := range ports {
+		openPorts = append(openPorts, fmt.Sprintf(`{ port = %d, protocol = "tcp" }`, port))
+	}
+
+	return fmt.Sprintf(`
+resource "fluence_ssh_key" "test" {
+  public_key = %q
+}
+
+resource "fluence_vm" "test" {
+  name      = %q
+  os_image  = %q
+  ssh_keys  = [fluence_ssh_key.test.fingerprint]
+  instances = %d
+
+  open_ports = [%s]
+
+  basic_configuration  = "cpu-2-ram-4gb-storage-25gb"
+  datacenter_countries = ["DE"]
+}
+`, publicKey, name, testAccOsImage, instances, strings.Join(openPorts, ", "))
+}
+
+// testAccCheckFakeVms checks that the fake holds count live VMs named name,
+// each with exactly the given TCP ports open.
+func testAccCheckFakeVms(srv *fakeapi.Server, count int, name string, ports ...int) resource.TestCheckFunc {
+	return func(_ *terraform.State) error {
+		live := liveVms(srv.Vms())
+		if len(live) != count {
+			return fmt.Errorf("expected %d live VM(s), got %d", count, len(live))
+		}
+
+		expected := []fluenceapi.OpenPorts{}
+		for _, port := range ports {
+			expected = append(expected, fluenceapi.OpenPorts{Port: uint16(port), Protocol: fluenceapi.ProtocolTCP})
+		}
+		for _, vm := range live {
+			if vm.VmName == nil || *vm.VmName != name {
+				return fmt.Errorf("VM %s is named %v, expected %q", vm.Id, vm.VmName, name)
+			}
+			if !portsMatch(vm.Ports, expected) {
+				return fmt.Errorf("VM %s has ports %v, expected %v", vm.Id, vm.Ports, expected)
+			}
+			if vm.Datacenter == nil || vm.Datacenter.CountryCode != "DE" {
+				return fmt.Errorf("VM %s was not placed in DE", vm.Id)
+			}
+		}
+		return nil
+	}
+}
+
+// testAccCheckVmsDestroyed checks that every VM of the fake was terminated.
+func testAccCheckVmsDestroyed(srv *fakeapi.Server) resource.TestCheckFunc {
+	return func(_ *terraform.State) error {
+		if live := liveVms(srv.Vms()); len(live) > 0 {
+			return fmt.Errorf("%d VM(s) still exist", len(live))
+		}
+		return nil
+	}
+}
+
+func TestPortsMatch(t *testing.T) {
+	ports := func(specs ...fluenceapi.PortSpec) *[]fluenceapi.PortSpec {
+		return &specs
+	}
+	tcp22 := fluenceapi.PortSpec{Port: 22, Protocol: "tcp"}
+	udp53 := fluenceapi.PortSpec{Port: 53, Protocol: "udp"}
+	expected := []fluenceapi.OpenPorts{
+		{Port: 22, Protocol: "tcp"},
+		{Port: 53, Protocol: "udp"},
+	}
+
+	tests := map[string]struct {
+		actual   *[]fluenceapi.PortSpec
+		expected []fluenceapi.OpenPorts
+		want     bool
+	}{
+		"same ports":         {actual: ports(tcp22, udp53), expected: expected, want: true},
+		"other order":        {actual: ports(udp53, tcp22), expected: expected, want: true},
+		"protocol case":      {actual: ports(fluenceapi.PortSpec{Port: 22, Protocol: "TCP"}, fluenceapi.PortSpec{Port: 53, Protocol: "UDP"}), expected: expected, want: true},
+		"missing port":       {actual: ports(tcp22), expected: expected, want: false},
+		"extra port":         {actual: ports(tcp22, udp53, fluenceapi.PortSpec{Port: 80, Protocol: "tcp"}), expected: expected, want: false},
+		"other protocol":     {actual: ports(tcp22, fluenceapi.PortSpec{Port: 53, Protocol: "tcp"}), expected: expected, want: false},
+		"nil, none expected": {actual: nil, expected: nil, want: true},
+		"nil, some expected": {actual: nil, expected: expected, want: false},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			if got := portsMatch(test.actual, test.expected); got != test.want {
+				t.Errorf("portsMatch() = %t, want %t", got, test.want)
+			}
+		})
+	}
+}
+
+func TestScaleDownVms(t *testing.T) {
+	vmIds := []string{"a", "b", "c", "d"}
+
+	tests := map[string]struct {
+		target        int
+		order         string
+		wantRemaining []string
+		wantRemoved   []string
+	}{
+		"newest first": {
+			target:        2,
+			order:         scaleDownNewestFirst,
+			wantRemaining: []string{"a", "b"},
+			wantRemoved:   []string{"c", "d"},
+		},
+		"oldest first keeps the primary": {
+			target:        2,
+			order:         scaleDownOldestFirst,
+			wantRemaining: []string{"a", "d"},
+			wantRemoved:   []string{"b", "c"},
+		},
+		"down to the primary": {
+			target:        1,
+			order:         scaleDownOldestFirst,
+			wantRemaining: []string{"a"},
+			wantRemoved:   []string{"b", "c", "d"},
+		},
+		"below one": {
+			target:        0,
+			order:         scaleDownNewestFirst,
+			wantRemaining: []string{"a"},
+			wantRemoved:   []string{"b", "c", "d"},
+		},
+		"nothing to remove": {
+			target:        4,
+			order:         scaleDownNewestFirst,
+			wantRemaining: []string{"a", "b", "c", "d"},
+		},
+		"more than tracked": {
+			target:        6,
+			order:         scaleDownOldestFirst,
+			wantRemaining: []string{"a", "b", "c", "d"},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			remaining, removed := scaleDownVms(vmIds, test.target, test.order)
+			if !reflect.DeepEqual(remaining, test.wantRemaining) {
+				t.Errorf("remaining = %v, want %v", remaining, test.wantRemaining)
+			}
+			if len(removed) != len(test.wantRemoved) || (len(removed) > 0 && !reflect.DeepEqual(removed, test.wantRemoved)) {
+				t.Errorf("removed = %v, want %v", removed, test.wantRemoved)
+			}
+		})
+	}
+
+	// The input is never modified
+	if !reflect.DeepEqual(vmIds, []string{"a", "b", "c", "d"}) {
+		t.Errorf("scaleDownVms modified its input: %v", vmIds)
+	}
+}